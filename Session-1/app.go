@@ -2,62 +2,273 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"time"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
+	"github.com/21keshav/IBackendApplication/auth"
 	"github.com/21keshav/IBackendApplication/config"
 	"github.com/21keshav/IBackendApplication/controller"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/health"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/metrics"
+	"github.com/21keshav/IBackendApplication/migrations"
+	"github.com/21keshav/IBackendApplication/money"
 	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
 	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
 	"github.com/21keshav/IBackendApplication/util"
-	"github.com/BurntSushi/toml"
-	"github.com/golang/glog"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
-	// ---- Application Startup Logs ----
-	glog.Info("Starting IBackendApplication...")
-	defer glog.Info("Application stopped.")
-
 	// ---- Load Configuration ----
-	var conf config.Config
-	if _, err := toml.DecodeFile("./config.toml", &conf); err != nil {
-		glog.Error("Failed to read config file: ", err)
-		// TODO: consider panic/exit if config is critical
+	// defaults < config file < BIDDING_* environment variables < command-line flags
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	conf, err := config.Load(*flags.ConfigFile, os.LookupEnv, flags.Overrides())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *flags.PrintConfig {
+		if err := conf.PrintConfig(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	log, closeLog, err := newLogger(conf.Logging)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer closeLog()
+	logging.SetDefault(log)
+
+	// ---- Admin Commands ----
+	// e.g. "IBackendApplication --config prod.toml migrate status"
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(conf, flag.Args()))
 	}
 
+	// ---- Application Startup Logs ----
+	log.Info("Starting IBackendApplication...")
+	defer log.Info("Application stopped.")
+
 	// ---- Initialize Echo Web Framework ----
 	e := echo.New()
-	e.Use(middleware.Logger())   // Log all HTTP requests
-	e.Use(middleware.Recover())  // Recover from panics and return HTTP 500
+	e.Server.ReadTimeout = conf.HTTP.ReadTimeout.Duration
+	e.Server.WriteTimeout = conf.HTTP.WriteTimeout.Duration
+	appMetrics := metrics.New()
+	e.Use(logging.Middleware(log)) // Tag requests with X-Request-ID and log each one
+	e.Use(appMetrics.Middleware)   // Count and time requests by route and status
+	e.Use(middleware.Recover())    // Recover from panics and return HTTP 500
+	if conf.HTTP.BodyLimit != "" {
+		e.Use(middleware.BodyLimit(conf.HTTP.BodyLimit)) // Reject oversized requests with 413
+	}
 
-	// ---- Setup Database Connection (MongoDB) ----
-	mongoURL := fmt.Sprintf("%s:%s", conf.Database.Server, conf.Database.Port)
-	mongoClient := util.NewMongoClient(context.Background(), mongoURL)
+	// ---- Setup Database Connection (MongoDB or in-memory) ----
+	// Retry with backoff while Mongo comes up; never serve without a client
+	mongoClient, err := connectDatabase(conf)
+	if err != nil {
+		log.Error("Could not connect to MongoDB", "retry_budget", conf.Mongo.ConnectRetryBudget.String(), logging.Err(err))
+		os.Exit(1)
+	}
 
-	// Create a context with timeout for DB operations
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	// Record latency and errors of every database operation
+	mongoClient = metrics.InstrumentMongoClient(mongoClient, appMetrics)
+
+	// Leases keep replicas from migrating, or closing the same project, at once
+	locker := scheduler.NewLocker(mongoClient, conf.DatabaseDetails.ProjectDBName, leaseOwner())
+
+	// ---- Apply Schema Migrations ----
+	// Indexes and backfills the new version relies on, see the migrations package
+	migrator := migrations.NewMigrator(mongoClient, conf.DatabaseDetails, locker, migrations.Options{})
+	if conf.Migrations.Auto {
+		if _, err := migrator.Up(context.Background(), false); err != nil {
+			log.Error("Could not apply schema migrations", logging.Err(err))
+			os.Exit(1)
+		}
+	} else if pending, err := migrator.Up(context.Background(), true); err != nil {
+		log.Error("Could not read schema migrations", logging.Err(err))
+	} else if len(pending) > 0 {
+		log.Warn("Schema migrations are pending; run the migrate up command", "pending", len(pending))
+	}
 
 	// ---- Initialize Resource Managers ----
+	// Each call runs under the context of the request (or scheduler run) that needs it.
 	// Project Manager handles project-related operations
-	projectManager := project.NewProjectManager(mongoClient, ctx, conf.DatabaseDetails)
+	projectManager := project.NewProjectManager(mongoClient, conf.DatabaseDetails)
+
+	// ---- Setup Event Publishing ----
+	// Events are logged and fanned out to the clients streaming them;
+	// the streams end when the server shuts down
+	hub := events.NewHub(events.HubConfig{History: conf.Events.History, Buffer: conf.Events.Buffer})
+	e.Server.RegisterOnShutdown(hub.Close)
+	publisher := events.NewMultiPublisher(events.NewLogPublisher(), hub)
+
+	// Bid Manager handles bidding logic, depends on ProjectManager, publishes
+	// bid, leader and award events, converts bids with the configured exchange rates
+	// and is instrumented to count bids and computed auctions
+	rates := money.NoRates
+	if conf.Currency.Rates != "" {
+		table, err := money.LoadRates(conf.Currency.Rates)
+		if err != nil {
+			log.Error("Could not load exchange rates", "path", conf.Currency.Rates, logging.Err(err))
+			os.Exit(1)
+		}
+		rates = table
+	}
+	bidManager := metrics.InstrumentBidManager(
+		bidManager.NewBidManager(projectManager, bidManager.Options{Publisher: publisher, Rates: rates}), appMetrics)
+
+	// ---- Setup Authentication ----
+	tokenManager := auth.NewTokenManager(conf.Auth.Secret, conf.Auth.TokenTTL.Duration, conf.Auth.AdminPassword)
 
-	// Bid Manager handles bidding logic, depends on ProjectManager
-	bidManager := bidManager.NewBidManager(projectManager, ctx)
+	// ---- Start Auction Scheduler ----
+	// Closes and awards projects once their end date has passed; stopped on shutdown
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	schedDone := make(chan struct{})
+	if !conf.Scheduler.Enabled {
+		close(schedDone)
+	} else {
+		sched := scheduler.NewScheduler(bidManager, projectManager, locker, publisher,
+			scheduler.Config{
+				Interval: conf.Scheduler.Interval.Duration,
+				LeaseTTL: conf.Scheduler.LeaseTTL.Duration,
+			})
+		go func() {
+			defer close(schedDone)
+			sched.Run(schedCtx)
+		}()
+	}
 
 	// ---- Setup Controller & Route Handlers ----
 	// Controller wires HTTP routes to application logic
-	ctrl := controller.NewController(bidManager, projectManager)
+	keys := idempotency.NewStore(mongoClient, conf.DatabaseDetails.ProjectDBName, idempotency.Options{
+		TTL:              conf.Idempotency.TTL.Duration,
+		OperationTimeout: conf.HTTP.OperationTimeout.Duration,
+	})
+	ctrl := controller.NewController(bidManager, projectManager, tokenManager, controller.Options{
+		OperationTimeout: conf.HTTP.OperationTimeout.Duration,
+		Subscriber:       hub,
+		KeepAlive:        conf.Events.KeepAlive.Duration,
+		Idempotency:      keys,
+	})
 	ctrl.AttachHandlers(e)
 
+	// Liveness (/healthz) and readiness (/readyz) probes
+	checker := health.NewHealthChecker(conf.HTTP.ReadinessTimeout.Duration, map[string]health.Check{
+		"mongo": mongoClient.Ping,
+	})
+	checker.AttachHandlers(e)
+
+	// Prometheus scrape endpoint (/metrics)
+	appMetrics.AttachHandlers(e)
+
 	// ---- Start HTTP Server ----
-	// TODO: replace with graceful shutdown (e.Shutdown) for production use
-	port := ":1234"
-	glog.Infof("Server listening on %s", port)
-	if err := e.Start(port); err != nil {
-		glog.Errorf("Error starting server: %v", err)
+	go func() {
+		log.Info("Server listening", "addr", conf.HTTP.Addr)
+		if err := e.Start(conf.HTTP.Addr); err != nil && err != http.ErrServerClosed {
+			log.Error("Error starting server", logging.Err(err))
+			os.Exit(1)
+		}
+	}()
+
+	// ---- Graceful Shutdown ----
+	// On SIGTERM/SIGINT stop accepting connections, let in-flight requests
+	// finish, stop the scheduler and only then close the Mongo connections.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+	sig := <-quit
+	log.Info("Shutting down", "signal", sig.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.HTTP.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Error("Error draining requests", logging.Err(err))
+	}
+	stopScheduler()
+	select {
+	case <-schedDone:
+	case <-shutdownCtx.Done():
+		log.Warn("Scheduler did not stop before the shutdown timeout")
+	}
+	if err := mongoClient.Disconnect(shutdownCtx); err != nil {
+		log.Error("Error disconnecting from MongoDB", logging.Err(err))
+	}
+}
+
+// connectDatabase returns the client of the configured storage backend.
+func connectDatabase(conf config.Config) (util.MongoClient, error) {
+	if conf.Database.Backend == config.BackendMemory {
+		logging.Default().Warn("Using in-memory database backend; data will not be persisted")
+		return util.NewMemoryMongoClient(), nil
+	}
+	return util.ConnectMongoClient(context.Background(), mongoOptions(conf.Mongo), util.RetryPolicy{
+		Budget:         conf.Mongo.ConnectRetryBudget.Duration,
+		InitialBackoff: conf.Mongo.ConnectInitialBackoff.Duration,
+		MaxBackoff:     conf.Mongo.ConnectMaxBackoff.Duration,
+		AttemptTimeout: conf.Mongo.ConnectTimeout.Duration,
+	})
+}
+
+// leaseOwner identifies this process in the leases it holds.
+func leaseOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// mongoOptions turns the [mongo] settings into driver options.
+// Username and password, when set, replace any credentials in the URI.
+func mongoOptions(conf config.Mongo) *options.ClientOptions {
+	opts := options.Client().
+		ApplyURI(conf.URI).
+		SetMaxPoolSize(uint64(conf.MaxPoolSize)).
+		SetConnectTimeout(conf.ConnectTimeout.Duration).
+		SetServerSelectionTimeout(conf.ServerSelectionTimeout.Duration)
+	if conf.SocketTimeout.Duration > 0 {
+		opts.SetSocketTimeout(conf.SocketTimeout.Duration)
+	}
+	if conf.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:   conf.Username,
+			Password:   conf.Password,
+			AuthSource: conf.AuthSource,
+		})
+	}
+	return opts
+}
+
+// newLogger builds the logger described by the [logging] settings. It writes
+// to stderr, or appends to bidding.log in the configured directory; the
+// returned function closes that file.
+func newLogger(conf config.Logging) (*slog.Logger, func(), error) {
+	if conf.Dir == "" {
+		log, err := logging.New(os.Stderr, conf.Format, conf.Level)
+		return log, func() {}, err
+	}
+	if err := os.MkdirAll(conf.Dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(conf.Dir, "bidding.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	log, err := logging.New(file, conf.Format, conf.Level)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
 	}
+	return log, func() { file.Close() }, nil
 }
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//
+// Authentication
+//
+// Callers authenticate with an HS256-signed JWT in the Authorization header.
+// The token's subject is the buyer or seller ID it was issued for and its
+// role decides which operations are allowed. Admin tokens can act on behalf
+// of anyone.
+//
+
+// Role is the kind of account a token was issued to.
+type Role string
+
+const (
+	RoleBuyer  Role = "buyer"  // Places bids under its own BuyerID
+	RoleSeller Role = "seller" // Creates and awards projects under its own SellerID
+	RoleAdmin  Role = "admin"  // Unrestricted
+)
+
+// AdminSubject is the subject of tokens issued to the administrator.
+const AdminSubject = "admin"
+
+// DefaultTokenTTL is how long tokens stay valid when no lifetime is configured.
+const DefaultTokenTTL = 24 * time.Hour
+
+// Errors returned while issuing or checking tokens.
+var (
+	ErrUnauthorized       = errors.New("missing or invalid token")
+	ErrForbidden          = errors.New("not allowed for this account")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidRole        = errors.New("invalid role")
+)
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleBuyer, RoleSeller, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Claims are the JWT claims carried by every token.
+type Claims struct {
+	Role Role `json:"role"`
+	jwt.StandardClaims
+}
+
+// Valid checks the standard time-based claims and the role.
+func (c *Claims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+	if !c.Role.Valid() || c.Subject == "" {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+// IsAdmin reports whether the claims belong to an administrator.
+func (c *Claims) IsAdmin() bool {
+	return c.Role == RoleAdmin
+}
+
+// Is reports whether the claims were issued to the given role and subject.
+// Admin claims match any role and subject.
+func (c *Claims) Is(role Role, subject string) bool {
+	return c.IsAdmin() || (c.Role == role && c.Subject == subject)
+}
+
+// ContextKey is the echo context key holding the authenticated caller's *Claims.
+const ContextKey = "claims"
+
+// ClaimsFrom returns the claims stored on c, or nil for unauthenticated requests.
+func ClaimsFrom(c echo.Context) *Claims {
+	claims, _ := c.Get(ContextKey).(*Claims)
+	return claims
+}
+
+// TokenManager issues and validates tokens.
+type TokenManager interface {
+	IssueToken(role Role, subject string) (string, time.Time, error)
+	ParseToken(token string) (*Claims, error)
+	CheckAdminPassword(password string) error
+}
+
+// TokenManagerImpl signs tokens with a shared HMAC secret.
+type TokenManagerImpl struct {
+	secret        []byte
+	ttl           time.Duration
+	adminPassword string
+	now           func() time.Time
+}
+
+// NewTokenManager creates a TokenManager. A zero ttl uses DefaultTokenTTL;
+// an empty adminPassword disables admin logins.
+func NewTokenManager(secret string, ttl time.Duration, adminPassword string) TokenManager {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &TokenManagerImpl{
+		secret:        []byte(secret),
+		ttl:           ttl,
+		adminPassword: adminPassword,
+		now:           time.Now,
+	}
+}
+
+// IssueToken signs a token for subject with the given role.
+// It returns the token and its expiry time.
+func (tm *TokenManagerImpl) IssueToken(role Role, subject string) (string, time.Time, error) {
+	if !role.Valid() || subject == "" {
+		return "", time.Time{}, ErrInvalidRole
+	}
+	now := tm.now()
+	expiresAt := now.Add(tm.ttl)
+	claims := &Claims{
+		Role: role,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   subject,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tm.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken verifies the signature and claims of token.
+// Any failure is reported as ErrUnauthorized.
+func (tm *TokenManagerImpl) ParseToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrUnauthorized
+		}
+		return tm.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrUnauthorized
+	}
+	return claims, nil
+}
+
+// CheckAdminPassword compares password with the configured admin password.
+func (tm *TokenManagerImpl) CheckAdminPassword(password string) error {
+	if tm.adminPassword == "" ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(tm.adminPassword)) != 1 {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// HashPassword returns the bcrypt hash stored for an account password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compares password with a hash from HashPassword.
+func CheckPassword(hash, password string) error {
+	if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
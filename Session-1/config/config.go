@@ -1,17 +1,39 @@
 package config
 
+import "time"
+
 // Config is the top-level configuration struct for the application.
 // It contains both general database connection info (host/port)
 // and logical database details (specific DBs and collection names).
+//
+// Settings are read from config.toml and may be overridden by environment
+// variables and command-line flags, see Load.
 type Config struct {
-	Database        database        // Basic DB connection settings (host, port)
+	Database        database        // Storage backend and legacy connection settings
 	DatabaseDetails DatabaseDetails // Names of logical DBs and collections
+	Mongo           Mongo           // MongoDB connection
+	HTTP            HTTP            // HTTP server
+	Logging         Logging         // Log verbosity and destination
+	Auth            authentication  // Token signing and admin credentials
+	Scheduler       scheduling      // Automatic closing of expired auctions
+	Events          eventStreams    // Real-time project event streams
+	Currency        currencies      // Conversion of bids between currencies
+	Migrations      migrating       // Schema migrations at startup
+	Idempotency     idempotencyKeys // Replaying retried requests
 }
 
-// database holds the raw connection details for the database server.
+// Supported values for database.Backend.
+const (
+	BackendMongo  = "mongo"  // Real MongoDB server (default)
+	BackendMemory = "memory" // In-process store, no database required
+)
+
+// database selects the storage backend.
+// Server and Port are the legacy way to locate MongoDB; Mongo.URI takes precedence.
 type database struct {
-	Server string // Database server hostname or IP address
-	Port   string // Port on which the database server is listening
+	Server  string // Database server hostname or IP address, e.g. "mongodb://localhost"
+	Port    string // Port on which the database server is listening
+	Backend string // Storage backend: "mongo" (default) or "memory"
 }
 
 // DatabaseDetails holds logical names of databases and collections
@@ -20,5 +42,96 @@ type DatabaseDetails struct {
 	BuyersDBName   string // Name of the database that stores Buyers
 	SellersDBName  string // Name of the database that stores Sellers
 	ProjectDBName  string // Name of the database that stores Projects
+	BidsDBName     string // Name of the database that stores Bids and their history
 	CollectionName string // Shared or default collection name for inserts/queries
 }
+
+// Mongo holds the MongoDB connection settings.
+type Mongo struct {
+	URI                    string   // Connection string, e.g. "mongodb://db-1:27017,db-2:27017/?replicaSet=rs0"
+	Username               string   // Overrides credentials in URI when set
+	Password               string   // Secret; redacted by --print-config
+	AuthSource             string   // Database to authenticate against (driver default "admin")
+	MaxPoolSize            int      // Maximum connections per server
+	ConnectTimeout         Duration // Timeout for establishing a connection
+	ServerSelectionTimeout Duration // How long an operation waits for a usable server
+	SocketTimeout          Duration // Timeout for a single read or write on a connection (0 = none)
+	ConnectRetryBudget     Duration // How long startup keeps retrying an unreachable server (0 = try once)
+	ConnectInitialBackoff  Duration // Wait after the first failed attempt; doubled on each retry
+	ConnectMaxBackoff      Duration // Upper bound for the wait between attempts
+}
+
+// HTTP holds the HTTP server settings.
+type HTTP struct {
+	Addr             string   // Listen address, host:port
+	ReadTimeout      Duration // Maximum time to read a whole request
+	WriteTimeout     Duration // Maximum time to write a response
+	BodyLimit        string   // Largest accepted request body, e.g. "1M"
+	OperationTimeout Duration // Deadline for the database work of one request
+	ShutdownTimeout  Duration // How long to wait for in-flight requests on SIGTERM
+	ReadinessTimeout Duration // Deadline for each dependency check of /readyz
+}
+
+// Logging holds the structured logger settings.
+type Logging struct {
+	Level  string // Lowest level written: "debug", "info" (default), "warn" or "error"
+	Format string // "json" (default) or "logfmt"
+	Dir    string // Directory for bidding.log; empty logs to stderr
+}
+
+// authentication holds the settings used to issue and verify API tokens.
+type authentication struct {
+	Secret        string   // HMAC key used to sign tokens (required)
+	TokenTTL      Duration // Token lifetime (default 24h)
+	AdminPassword string   // Password for admin logins; empty disables them
+}
+
+// scheduling controls the background job that closes and awards projects
+// whose bidding window has ended.
+type scheduling struct {
+	Enabled  bool     // Run the scheduler in this instance
+	Interval Duration // Time between scans (default 10s)
+	LeaseTTL Duration // How long an instance holds a project while closing it (default 30s)
+}
+
+// eventStreams tunes the hub behind the project event streams.
+type eventStreams struct {
+	History   int      // Events kept for clients resuming with Last-Event-ID (default 1000)
+	Buffer    int      // Events queued per client before it is dropped as too slow (default 64)
+	KeepAlive Duration // Time between keep-alive messages on idle streams (default 15s)
+}
+
+// currencies locates the exchange rates used to convert bids.
+type currencies struct {
+	Rates string // Rate file, see money.LoadRates; empty converts nothing
+}
+
+// migrating controls the schema migrations, see the migrations package.
+type migrating struct {
+	Auto bool // Apply pending migrations at startup (default true)
+}
+
+// idempotencyKeys controls how requests sent with an Idempotency-Key are replayed.
+type idempotencyKeys struct {
+	TTL Duration // How long the response to a key is kept (default 24h)
+}
+
+// Duration is a time.Duration written as a Go duration string, e.g. "30s".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses a duration string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalText formats the duration as a string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
@@ -0,0 +1,451 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/BurntSushi/toml"
+	"github.com/labstack/gommon/bytes"
+)
+
+//
+// Loading
+//
+// Every setting has a key such as "http.readTimeout". Its value comes from,
+// in increasing order of precedence:
+//
+//	1. the built-in default (see Default)
+//	2. the config file, e.g. [http] readTimeout = "30s"
+//	3. the environment, e.g. BIDDING_HTTP_READ_TIMEOUT=30s
+//	4. the command line, e.g. --http.readTimeout=30s
+//
+
+// EnvPrefix starts the name of every environment variable read by Load.
+const EnvPrefix = "BIDDING_"
+
+// DefaultFile is the config file read when --config is not given.
+const DefaultFile = "./config.toml"
+
+// defaultMongoURI is used when neither mongo.uri nor database.server is set.
+const defaultMongoURI = "mongodb://localhost:27017"
+
+// MinSecretLength is the length in bytes of the shortest auth.secret accepted.
+const MinSecretLength = 32
+
+// exampleSecrets are auth.secret values published in examples, never accepted.
+var exampleSecrets = map[string]bool{"change-me": true}
+
+// redacted replaces secrets in PrintConfig output.
+const redacted = "<redacted>"
+
+// setting describes one configurable value.
+type setting struct {
+	key    string                      // section.name, as in the config file
+	value  func(c *Config) interface{} // pointer to the field in c
+	secret bool                        // never printed
+}
+
+// settings lists every value that can be set from the environment or the command
+// line, in the order PrintConfig writes them.
+var settings = []setting{
+	{key: "database.backend", value: func(c *Config) interface{} { return &c.Database.Backend }},
+	{key: "database.server", value: func(c *Config) interface{} { return &c.Database.Server }},
+	{key: "database.port", value: func(c *Config) interface{} { return &c.Database.Port }},
+
+	{key: "DatabaseDetails.BuyersDBName", value: func(c *Config) interface{} { return &c.DatabaseDetails.BuyersDBName }},
+	{key: "DatabaseDetails.SellersDBName", value: func(c *Config) interface{} { return &c.DatabaseDetails.SellersDBName }},
+	{key: "DatabaseDetails.ProjectDBName", value: func(c *Config) interface{} { return &c.DatabaseDetails.ProjectDBName }},
+	{key: "DatabaseDetails.BidsDBName", value: func(c *Config) interface{} { return &c.DatabaseDetails.BidsDBName }},
+	{key: "DatabaseDetails.CollectionName", value: func(c *Config) interface{} { return &c.DatabaseDetails.CollectionName }},
+
+	{key: "mongo.uri", value: func(c *Config) interface{} { return &c.Mongo.URI }},
+	{key: "mongo.username", value: func(c *Config) interface{} { return &c.Mongo.Username }},
+	{key: "mongo.password", value: func(c *Config) interface{} { return &c.Mongo.Password }, secret: true},
+	{key: "mongo.authSource", value: func(c *Config) interface{} { return &c.Mongo.AuthSource }},
+	{key: "mongo.maxPoolSize", value: func(c *Config) interface{} { return &c.Mongo.MaxPoolSize }},
+	{key: "mongo.connectTimeout", value: func(c *Config) interface{} { return &c.Mongo.ConnectTimeout }},
+	{key: "mongo.serverSelectionTimeout", value: func(c *Config) interface{} { return &c.Mongo.ServerSelectionTimeout }},
+	{key: "mongo.socketTimeout", value: func(c *Config) interface{} { return &c.Mongo.SocketTimeout }},
+	{key: "mongo.connectRetryBudget", value: func(c *Config) interface{} { return &c.Mongo.ConnectRetryBudget }},
+	{key: "mongo.connectInitialBackoff", value: func(c *Config) interface{} { return &c.Mongo.ConnectInitialBackoff }},
+	{key: "mongo.connectMaxBackoff", value: func(c *Config) interface{} { return &c.Mongo.ConnectMaxBackoff }},
+
+	{key: "http.addr", value: func(c *Config) interface{} { return &c.HTTP.Addr }},
+	{key: "http.readTimeout", value: func(c *Config) interface{} { return &c.HTTP.ReadTimeout }},
+	{key: "http.writeTimeout", value: func(c *Config) interface{} { return &c.HTTP.WriteTimeout }},
+	{key: "http.bodyLimit", value: func(c *Config) interface{} { return &c.HTTP.BodyLimit }},
+	{key: "http.operationTimeout", value: func(c *Config) interface{} { return &c.HTTP.OperationTimeout }},
+	{key: "http.shutdownTimeout", value: func(c *Config) interface{} { return &c.HTTP.ShutdownTimeout }},
+	{key: "http.readinessTimeout", value: func(c *Config) interface{} { return &c.HTTP.ReadinessTimeout }},
+
+	{key: "logging.level", value: func(c *Config) interface{} { return &c.Logging.Level }},
+	{key: "logging.format", value: func(c *Config) interface{} { return &c.Logging.Format }},
+	{key: "logging.dir", value: func(c *Config) interface{} { return &c.Logging.Dir }},
+
+	{key: "auth.secret", value: func(c *Config) interface{} { return &c.Auth.Secret }, secret: true},
+	{key: "auth.tokenTTL", value: func(c *Config) interface{} { return &c.Auth.TokenTTL }},
+	{key: "auth.adminPassword", value: func(c *Config) interface{} { return &c.Auth.AdminPassword }, secret: true},
+
+	{key: "scheduler.enabled", value: func(c *Config) interface{} { return &c.Scheduler.Enabled }},
+	{key: "scheduler.interval", value: func(c *Config) interface{} { return &c.Scheduler.Interval }},
+	{key: "scheduler.leaseTTL", value: func(c *Config) interface{} { return &c.Scheduler.LeaseTTL }},
+
+	{key: "events.history", value: func(c *Config) interface{} { return &c.Events.History }},
+	{key: "events.buffer", value: func(c *Config) interface{} { return &c.Events.Buffer }},
+	{key: "events.keepAlive", value: func(c *Config) interface{} { return &c.Events.KeepAlive }},
+
+	{key: "currency.rates", value: func(c *Config) interface{} { return &c.Currency.Rates }},
+
+	{key: "migrations.auto", value: func(c *Config) interface{} { return &c.Migrations.Auto }},
+
+	{key: "idempotency.ttl", value: func(c *Config) interface{} { return &c.Idempotency.TTL }},
+}
+
+// Default returns the configuration used for settings that are not set anywhere.
+func Default() Config {
+	return Config{
+		Database: database{Backend: BackendMongo},
+		DatabaseDetails: DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "bider",
+		},
+		Mongo: Mongo{
+			MaxPoolSize:            100,
+			ConnectTimeout:         Duration{10 * time.Second},
+			ServerSelectionTimeout: Duration{10 * time.Second},
+			ConnectRetryBudget:     Duration{time.Minute},
+			ConnectInitialBackoff:  Duration{500 * time.Millisecond},
+			ConnectMaxBackoff:      Duration{10 * time.Second},
+		},
+		HTTP: HTTP{
+			Addr:             ":1234",
+			ReadTimeout:      Duration{30 * time.Second},
+			WriteTimeout:     Duration{30 * time.Second},
+			BodyLimit:        "1M",
+			OperationTimeout: Duration{10 * time.Second},
+			ShutdownTimeout:  Duration{30 * time.Second},
+			ReadinessTimeout: Duration{2 * time.Second},
+		},
+		Logging:     Logging{Level: "info", Format: logging.FormatJSON},
+		Auth:        authentication{TokenTTL: Duration{24 * time.Hour}},
+		Scheduler:   scheduling{Enabled: true, Interval: Duration{10 * time.Second}, LeaseTTL: Duration{30 * time.Second}},
+		Events:      eventStreams{History: 1000, Buffer: 64, KeepAlive: Duration{15 * time.Second}},
+		Migrations:  migrating{Auto: true},
+		Idempotency: idempotencyKeys{TTL: Duration{24 * time.Hour}},
+	}
+}
+
+// Load builds the effective configuration from the defaults, the config file
+// at path (skipped when path is empty), the environment as seen through
+// lookupEnv and the command-line overrides keyed by setting key.
+// The result is validated; the error lists every problem found.
+func Load(path string, lookupEnv func(string) (string, bool), overrides map[string]string) (Config, error) {
+	conf := Default()
+	if path != "" {
+		if _, err := toml.DecodeFile(path, &conf); err != nil {
+			return conf, fmt.Errorf("reading config file %s: %v", path, err)
+		}
+	}
+
+	for _, s := range settings {
+		name := EnvName(s.key)
+		if value, ok := lookupEnv(name); ok {
+			if err := s.set(&conf, value); err != nil {
+				return conf, fmt.Errorf("environment variable %s: %v", name, err)
+			}
+		}
+	}
+	for key, value := range overrides {
+		s, ok := lookup(key)
+		if !ok {
+			return conf, fmt.Errorf("unknown setting %q", key)
+		}
+		if err := s.set(&conf, value); err != nil {
+			return conf, fmt.Errorf("flag --%s: %v", key, err)
+		}
+	}
+
+	if conf.Mongo.URI == "" {
+		conf.Mongo.URI = legacyMongoURI(conf.Database.Server, conf.Database.Port)
+	}
+	return conf, conf.Validate()
+}
+
+// EnvName returns the environment variable that overrides the setting key,
+// e.g. "http.readTimeout" is read from BIDDING_HTTP_READ_TIMEOUT.
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	runes := []rune(key)
+	for i, r := range runes {
+		if r == '.' {
+			b.WriteRune('_')
+			continue
+		}
+		// Start a new word at "readTimeout" -> READ_TIMEOUT and "DBName" -> DB_NAME
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// Flags holds the command-line flags registered by RegisterFlags.
+type Flags struct {
+	ConfigFile  *string // --config: path of the config file
+	PrintConfig *bool   // --print-config: print the effective settings and exit
+
+	fs     *flag.FlagSet
+	values map[string]*string // setting key -> flag value
+}
+
+// RegisterFlags defines --config, --print-config and a --<key> flag for
+// every setting on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		ConfigFile:  fs.String("config", DefaultFile, "path of the config file"),
+		PrintConfig: fs.Bool("print-config", false, "print the effective configuration with secrets redacted and exit"),
+		fs:          fs,
+		values:      map[string]*string{},
+	}
+	for _, s := range settings {
+		f.values[s.key] = fs.String(s.key, "", fmt.Sprintf("overrides %s from the config file and %s", s.key, EnvName(s.key)))
+	}
+	return f
+}
+
+// Overrides returns the settings given on the command line, for Load.
+// Call it after the flag set has been parsed.
+func (f *Flags) Overrides() map[string]string {
+	overrides := map[string]string{}
+	f.fs.Visit(func(fl *flag.Flag) {
+		if value, ok := f.values[fl.Name]; ok {
+			overrides[fl.Name] = *value
+		}
+	})
+	return overrides
+}
+
+// Validate checks the configuration and returns an error describing every
+// invalid setting, or nil.
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Database.Backend {
+	case BackendMongo, BackendMemory:
+	default:
+		add("database.backend must be %q or %q, got %q", BackendMongo, BackendMemory, c.Database.Backend)
+	}
+	details := map[string]string{
+		"DatabaseDetails.BuyersDBName":   c.DatabaseDetails.BuyersDBName,
+		"DatabaseDetails.SellersDBName":  c.DatabaseDetails.SellersDBName,
+		"DatabaseDetails.ProjectDBName":  c.DatabaseDetails.ProjectDBName,
+		"DatabaseDetails.BidsDBName":     c.DatabaseDetails.BidsDBName,
+		"DatabaseDetails.CollectionName": c.DatabaseDetails.CollectionName,
+	}
+	for _, s := range settings {
+		if name, ok := details[s.key]; ok && name == "" {
+			add("%s must not be empty", s.key)
+		}
+	}
+
+	if c.Database.Backend == BackendMongo {
+		if u, err := url.Parse(c.Mongo.URI); err != nil {
+			add("mongo.uri is not a valid URI: %v", err)
+		} else if u.Scheme != "mongodb" && u.Scheme != "mongodb+srv" {
+			add("mongo.uri must start with mongodb:// or mongodb+srv://, got %q", redactURI(c.Mongo.URI))
+		}
+	}
+	if c.Mongo.Password != "" && c.Mongo.Username == "" {
+		add("mongo.password requires mongo.username")
+	}
+	if c.Mongo.AuthSource != "" && c.Mongo.Username == "" {
+		add("mongo.authSource requires mongo.username")
+	}
+	if c.Mongo.MaxPoolSize < 1 {
+		add("mongo.maxPoolSize must be at least 1, got %d", c.Mongo.MaxPoolSize)
+	}
+
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		add("http.addr must be host:port, e.g. \":1234\", got %q", c.HTTP.Addr)
+	}
+	if c.HTTP.BodyLimit != "" {
+		if _, err := bytes.Parse(c.HTTP.BodyLimit); err != nil {
+			add("http.bodyLimit must be a size such as \"512K\" or \"1M\", got %q", c.HTTP.BodyLimit)
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		add("logging.level must be debug, info, warn or error, got %q", c.Logging.Level)
+	}
+	if c.Logging.Format != logging.FormatJSON && c.Logging.Format != logging.FormatLogfmt {
+		add("logging.format must be %q or %q, got %q", logging.FormatJSON, logging.FormatLogfmt, c.Logging.Format)
+	}
+
+	switch {
+	case c.Auth.Secret == "":
+		add("auth.secret must be set")
+	case exampleSecrets[c.Auth.Secret]:
+		add("auth.secret must not be the example value %q", c.Auth.Secret)
+	case len(c.Auth.Secret) < MinSecretLength:
+		add("auth.secret must be at least %d bytes, got %d", MinSecretLength, len(c.Auth.Secret))
+	}
+
+	if c.Events.History < 1 {
+		add("events.history must be at least 1, got %d", c.Events.History)
+	}
+	if c.Events.Buffer < 1 {
+		add("events.buffer must be at least 1, got %d", c.Events.Buffer)
+	}
+
+	durations := map[string]Duration{
+		"mongo.connectTimeout":         c.Mongo.ConnectTimeout,
+		"mongo.serverSelectionTimeout": c.Mongo.ServerSelectionTimeout,
+		"mongo.socketTimeout":          c.Mongo.SocketTimeout,
+		"mongo.connectRetryBudget":     c.Mongo.ConnectRetryBudget,
+		"mongo.connectInitialBackoff":  c.Mongo.ConnectInitialBackoff,
+		"mongo.connectMaxBackoff":      c.Mongo.ConnectMaxBackoff,
+		"http.readTimeout":             c.HTTP.ReadTimeout,
+		"http.writeTimeout":            c.HTTP.WriteTimeout,
+		"http.operationTimeout":        c.HTTP.OperationTimeout,
+		"http.shutdownTimeout":         c.HTTP.ShutdownTimeout,
+		"http.readinessTimeout":        c.HTTP.ReadinessTimeout,
+		"auth.tokenTTL":                c.Auth.TokenTTL,
+		"scheduler.interval":           c.Scheduler.Interval,
+		"scheduler.leaseTTL":           c.Scheduler.LeaseTTL,
+		"events.keepAlive":             c.Events.KeepAlive,
+		"idempotency.ttl":              c.Idempotency.TTL,
+	}
+	for _, s := range settings {
+		if d, ok := durations[s.key]; ok && d.Duration < 0 {
+			add("%s must not be negative, got %s", s.key, d)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// PrintConfig writes the configuration to w in config file format.
+// Passwords, secrets and credentials embedded in mongo.uri are redacted.
+func (c Config) PrintConfig(w io.Writer) error {
+	section := ""
+	for _, s := range settings {
+		dot := strings.Index(s.key, ".")
+		if s.key[:dot] != section {
+			if section != "" {
+				fmt.Fprintln(w)
+			}
+			section = s.key[:dot]
+			fmt.Fprintf(w, "[%s]\n", section)
+		}
+
+		var value string
+		switch v := s.value(&c).(type) {
+		case *string:
+			switch {
+			case s.secret && *v != "":
+				value = strconv.Quote(redacted)
+			case s.key == "mongo.uri":
+				value = strconv.Quote(redactURI(*v))
+			default:
+				value = strconv.Quote(*v)
+			}
+		case *int:
+			value = strconv.Itoa(*v)
+		case *bool:
+			value = strconv.FormatBool(*v)
+		case *Duration:
+			value = strconv.Quote(v.String())
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s\n", s.key[dot+1:], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set parses value into the field of c described by s.
+func (s setting) set(c *Config, value string) error {
+	switch v := s.value(c).(type) {
+	case *string:
+		*v = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*v = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*v = b
+	case *Duration:
+		if err := v.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("%q is not a duration such as \"30s\"", value)
+		}
+	}
+	return nil
+}
+
+// lookup finds the setting with the given key.
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// legacyMongoURI builds a connection string from the older database.server and
+// database.port settings, e.g. "mongodb://localhost" and "27017".
+func legacyMongoURI(server, port string) string {
+	if server == "" {
+		return defaultMongoURI
+	}
+	if !strings.Contains(server, "://") {
+		server = "mongodb://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		// Let Validate report the unparsable value
+		return server
+	}
+	if port != "" && u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u.String()
+}
+
+// redactURI hides the password in a connection string.
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/project"
+
+	"github.com/labstack/echo"
+)
+
+//
+// Authentication & Authorization
+//
+// Every route except registration and POST /v1/auth/token runs behind
+// authenticate. Handlers then check ownership with authorize:
+//
+//   - sellers may only create, change and award projects under their own SellerID
+//   - buyers may only place, change and withdraw bids under their own BuyerID
+//   - buyers and sellers may only change or delete their own account
+//   - admins may do all of the above on behalf of anyone
+//
+
+// bearerPrefix precedes the token in the Authorization header.
+const bearerPrefix = "Bearer "
+
+// tokenRequest is the body of POST /v1/auth/token.
+type tokenRequest struct {
+	Role     auth.Role `json:"role"`
+	ID       string    `json:"id"`
+	Password string    `json:"password"`
+}
+
+// tokenResponse is returned by POST /v1/auth/token.
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// authenticate is middleware that requires a valid bearer token
+// and stores its claims on the context for the handlers.
+func (co *ControllerImpl) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		if !strings.HasPrefix(header, bearerPrefix) {
+			return errorResponse(c, auth.ErrUnauthorized)
+		}
+		claims, err := co.tokenManager.ParseToken(strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			return errorResponse(c, err)
+		}
+		c.Set(auth.ContextKey, claims)
+		return next(c)
+	}
+}
+
+// authenticateStream is authenticate for event streams. Browsers cannot set
+// headers on EventSource and WebSocket requests, so the token may also be
+// given in the access_token query parameter.
+func (co *ControllerImpl) authenticateStream(next echo.HandlerFunc) echo.HandlerFunc {
+	authenticated := co.authenticate(next)
+	return func(c echo.Context) error {
+		req := c.Request()
+		if token := c.QueryParam("access_token"); token != "" && req.Header.Get(echo.HeaderAuthorization) == "" {
+			req.Header.Set(echo.HeaderAuthorization, bearerPrefix+token)
+		}
+		return authenticated(c)
+	}
+}
+
+// authorize checks that the caller is the account with the given role and ID.
+// Admins are always allowed.
+func authorize(c echo.Context, role auth.Role, id string) error {
+	claims := auth.ClaimsFrom(c)
+	if claims == nil {
+		return auth.ErrUnauthorized
+	}
+	if !claims.Is(role, id) {
+		return auth.ErrForbidden
+	}
+	return nil
+}
+
+// callerID returns id, or the caller's own ID when id is empty and the
+// caller has the given role. It lets buyers and sellers omit their own ID.
+func callerID(c echo.Context, role auth.Role, id string) string {
+	if claims := auth.ClaimsFrom(c); id == "" && claims != nil && claims.Role == role {
+		return claims.Subject
+	}
+	return id
+}
+
+// authorizeProjectOwner checks that the caller is the seller owning projectID.
+func (co *ControllerImpl) authorizeProjectOwner(c echo.Context, projectID string) error {
+	ctx := c.Request().Context()
+	if claims := auth.ClaimsFrom(c); claims != nil && claims.IsAdmin() {
+		return nil
+	}
+	projectDetails, err := co.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	return authorize(c, auth.RoleSeller, projectDetails.SellerID)
+}
+
+// hashPassword replaces a plain-text password with its bcrypt hash.
+func hashPassword(password, hash *string) error {
+	if *password == "" {
+		return nil
+	}
+	h, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	*hash, *password = h, ""
+	return nil
+}
+
+// PostToken handles POST /v1/auth/token.
+// Exchanges an account ID and password for a signed token.
+func (co *ControllerImpl) PostToken(c echo.Context) error {
+	logger(c).Debug("post-token-started")
+	defer logger(c).Debug("post-token-completed")
+
+	ctx := c.Request().Context()
+
+	var req tokenRequest
+	if err := bindBody(c, &req); err != nil {
+		return badRequest(c, err)
+	}
+
+	subject := req.ID
+	var err error
+	switch req.Role {
+	case auth.RoleBuyer:
+		var buyer project.Buyer
+		if buyer, err = co.projectManager.GetBuyer(ctx, req.ID); err == nil {
+			err = auth.CheckPassword(buyer.PasswordHash, req.Password)
+		}
+	case auth.RoleSeller:
+		var seller project.Seller
+		if seller, err = co.projectManager.GetSeller(ctx, req.ID); err == nil {
+			err = auth.CheckPassword(seller.PasswordHash, req.Password)
+		}
+	case auth.RoleAdmin:
+		subject = auth.AdminSubject
+		err = co.tokenManager.CheckAdminPassword(req.Password)
+	default:
+		return errorResponse(c, auth.ErrInvalidRole)
+	}
+	if err == project.ErrBuyerNotFound || err == project.ErrSellerNotFound {
+		// Don't reveal which accounts exist.
+		err = auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		logger(c).Warn("post-token-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+
+	token, expiresAt, err := co.tokenManager.IssueToken(req.Role, subject)
+	if err != nil {
+		logger(c).Warn("issue-token-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, tokenResponse{
+		Token:     token,
+		TokenType: strings.TrimSpace(bearerPrefix),
+		ExpiresAt: expiresAt,
+	})
+}
@@ -1,14 +1,22 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
 	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
 	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/validation"
 
-	"github.com/golang/glog"
 	"github.com/labstack/echo"
 )
 
@@ -21,61 +29,200 @@ type Controller interface {
 	AttachHandlers(lister *echo.Echo)   // Attach all routes to Echo
 	CreateSeller(c echo.Context) error  // POST /create-seller
 	CreateBuyer(c echo.Context) error   // POST /create-buyer
+	GetProjects(c echo.Context) error   // GET /get-projects
+
+	UpdateProjectStatus(c echo.Context) error // PUT /update-project-status
+
+	// v1 resource API, see v1.go
+	PostToken(c echo.Context) error      // POST /v1/auth/token
+	ListProjects(c echo.Context) error   // GET /v1/projects
+	PostProject(c echo.Context) error    // POST /v1/projects
+	GetProject(c echo.Context) error     // GET /v1/projects/:id
+	PatchProject(c echo.Context) error   // PATCH /v1/projects/:id
+	DeleteProject(c echo.Context) error  // DELETE /v1/projects/:id
+	GetBids(c echo.Context) error        // GET /v1/projects/:id/bids
+	PostBid(c echo.Context) error        // POST /v1/projects/:id/bids
+	GetBid(c echo.Context) error         // GET /v1/projects/:id/bids/:bidID
+	PatchBid(c echo.Context) error       // PATCH /v1/projects/:id/bids/:bidID
+	DeleteBid(c echo.Context) error      // DELETE /v1/projects/:id/bids/:bidID
+	RetractBid(c echo.Context) error     // POST /v1/projects/:id/bids/:bidID/retraction
+	RevealBid(c echo.Context) error      // POST /v1/projects/:id/bids/:bidID/reveal
+	GetProxyBid(c echo.Context) error    // GET /v1/projects/:id/proxies/:buyerID
+	PutProxyBid(c echo.Context) error    // PUT /v1/projects/:id/proxies/:buyerID
+	DeleteProxyBid(c echo.Context) error // DELETE /v1/projects/:id/proxies/:buyerID
+	GetBuyers(c echo.Context) error      // GET /v1/buyers
+	PostBuyer(c echo.Context) error      // POST /v1/buyers
+	GetBuyer(c echo.Context) error       // GET /v1/buyers/:id
+	PatchBuyer(c echo.Context) error     // PATCH /v1/buyers/:id
+	DeleteBuyer(c echo.Context) error    // DELETE /v1/buyers/:id
+	GetSellers(c echo.Context) error     // GET /v1/sellers
+	PostSeller(c echo.Context) error     // POST /v1/sellers
+	GetSeller(c echo.Context) error      // GET /v1/sellers/:id
+	PatchSeller(c echo.Context) error    // PATCH /v1/sellers/:id
+	DeleteSeller(c echo.Context) error   // DELETE /v1/sellers/:id
+
+	// Event streams, see stream.go
+	StreamEvents(c echo.Context) error   // GET /v1/projects/:id/events
+	StreamEventsWS(c echo.Context) error // GET /v1/projects/:id/events/ws
 }
 
+// DefaultOperationTimeout bounds how long a request may spend on database
+// work when no other timeout is configured.
+const DefaultOperationTimeout = 10 * time.Second
+
 // ControllerImpl is the concrete implementation of Controller.
-// It uses BidManager for bid operations and ProjectManager for project/seller/buyer persistence.
+// It uses BidManager for bid operations, ProjectManager for project/seller/buyer persistence,
+// TokenManager to authenticate callers and Subscriber to stream project events.
 type ControllerImpl struct {
-	bidManager     bidManager.BidManager
-	projectManager project.ProjectManager
+	bidManager       bidManager.BidManager
+	projectManager   project.ProjectManager
+	tokenManager     auth.TokenManager
+	operationTimeout time.Duration     // Deadline for the work done by one request
+	subscriber       events.Subscriber // Source of streamed events; nil disables the streams
+	keepAlive        time.Duration     // Time between keep-alive messages on idle streams
+	idempotency      idempotency.Store // Responses of requests with an Idempotency-Key; nil ignores the header
+}
+
+// Options configures a Controller.
+type Options struct {
+	OperationTimeout time.Duration     // Deadline for one request's database work; zero uses DefaultOperationTimeout
+	Subscriber       events.Subscriber // Source of streamed events; nil disables the streams
+	KeepAlive        time.Duration     // Time between keep-alives on idle streams; zero uses DefaultKeepAlive
+	Idempotency      idempotency.Store // Replays retried requests, see idempotent; nil ignores the header
 }
 
 // NewController initializes a new Controller with the required dependencies.
-func NewController(bidManager bidManager.BidManager, projectDetails project.ProjectManager) Controller {
+func NewController(bidManager bidManager.BidManager, projectDetails project.ProjectManager,
+	tokenManager auth.TokenManager, opts Options) Controller {
+	if opts.OperationTimeout <= 0 {
+		opts.OperationTimeout = DefaultOperationTimeout
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = DefaultKeepAlive
+	}
 	return &ControllerImpl{
 		bidManager,
 		projectDetails,
+		tokenManager,
+		opts.OperationTimeout,
+		opts.Subscriber,
+		opts.KeepAlive,
+		opts.Idempotency,
 	}
 }
 
 // AttachHandlers registers all HTTP endpoints with Echo.
+// Registration is open; every other route requires a bearer token.
+// Every route runs under the operation deadline, see withDeadline, and logs
+// the IDs in its path, see withLogFields. Creation and bid routes replay
+// retries sent with an Idempotency-Key, see idempotent.
 func (co *ControllerImpl) AttachHandlers(lister *echo.Echo) {
-	lister.POST("/create-project", co.CreateProject)
-	lister.POST("/create-seller", co.CreateSeller)
-	lister.POST("/create-buyer", co.CreateBuyer)
-	lister.PUT("/update-bid", co.UpdateBID)
-	lister.GET("/get-projects", co.GetProjects)
-	lister.POST("/compute-bid", co.ComputeBID)
+	open := []echo.MiddlewareFunc{co.withDeadline, withLogFields}
+	authed := []echo.MiddlewareFunc{co.withDeadline, withLogFields, co.authenticate}
+	openCreate := append(open, co.idempotent)
+	authedCreate := append(authed, co.idempotent)
+
+	lister.POST("/create-project", co.CreateProject, authedCreate...)
+	lister.POST("/create-seller", co.CreateSeller, openCreate...)
+	lister.POST("/create-buyer", co.CreateBuyer, openCreate...)
+	lister.PUT("/update-bid", co.UpdateBID, authedCreate...)
+	lister.GET("/get-projects", co.GetProjects, authed...)
+	lister.POST("/compute-bid", co.ComputeBID, authed...)
+	lister.PUT("/update-project-status", co.UpdateProjectStatus, authed...)
+
+	co.attachV1Handlers(lister)
+}
+
+// withDeadline is middleware that bounds the request's context by the
+// operation timeout. Handlers pass c.Request().Context() to the managers, so
+// database calls stop when the client goes away or the deadline passes.
+func (co *ControllerImpl) withDeadline(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), co.operationTimeout)
+		defer cancel()
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// withLogFields is middleware that adds the project, bid, buyer or seller
+// IDs named by the request's path, or the legacy projectID query parameter,
+// to the request's logger.
+func withLogFields(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var fields []interface{}
+		switch {
+		case strings.HasPrefix(c.Path(), "/v1/buyers/"):
+			fields = append(fields, logging.KeyBuyerID, c.Param("id"))
+		case strings.HasPrefix(c.Path(), "/v1/sellers/"):
+			fields = append(fields, logging.KeySellerID, c.Param("id"))
+		default:
+			if projectID := projectIDParam(c); projectID != "" {
+				fields = append(fields, logging.KeyProjectID, projectID)
+			}
+		}
+		if bidID := c.Param("bidID"); bidID != "" {
+			fields = append(fields, logging.KeyBidID, bidID)
+		}
+		if len(fields) > 0 {
+			annotate(c, fields...)
+		}
+		return next(c)
+	}
+}
+
+// annotate adds key/value pairs to the logger of c's request and returns
+// the request's new context.
+func annotate(c echo.Context, fields ...interface{}) context.Context {
+	ctx := logging.With(c.Request().Context(), fields...)
+	c.SetRequest(c.Request().WithContext(ctx))
+	return ctx
+}
+
+// logger returns the logger of c's request.
+func logger(c echo.Context) *slog.Logger {
+	return logging.FromContext(c.Request().Context())
 }
 
 // UpdateBID handles PUT /update-bid.
 // Reads a bid from request body and updates it for a given project.
 func (co *ControllerImpl) UpdateBID(c echo.Context) error {
-	glog.Info("update-bid")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("update-bid-started")
+	defer logger(c).Debug("update-bid-completed")
 
-	projectID := c.QueryParam("projectID")
+	ctx := c.Request().Context()
+
+	projectID := projectIDParam(c)
 
 	// Parse request body into a Bid object
 	var bid project.BID
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
-		glog.Error("read-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("read-error", logging.Err(err))
+		return badRequest(c, err)
 	}
 
 	err = json.Unmarshal(body, &bid)
 	if err != nil {
-		glog.Error("unmarshal-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("unmarshal-error", logging.Err(err))
+		return badRequest(c, err)
+	}
+
+	// Buyers may only bid as themselves
+	bid.BuyerID = callerID(c, auth.RoleBuyer, bid.BuyerID)
+	ctx = annotate(c, logging.KeyBidID, bid.ID, logging.KeyBuyerID, bid.BuyerID)
+	if err := authorize(c, auth.RoleBuyer, bid.BuyerID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.validateBid(ctx, projectID, bid); err != nil {
+		return errorResponse(c, err)
 	}
 
 	// Delegate bid update to BidManager
-	err = co.bidManager.DoBID(projectID, bid)
+	err = co.bidManager.DoBID(ctx, projectID, bid, 0)
 	if err != nil {
-		glog.Error("update-bid-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("update-bid-error", logging.Err(err))
+		return errorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, nil)
@@ -84,122 +231,199 @@ func (co *ControllerImpl) UpdateBID(c echo.Context) error {
 // CreateSeller handles POST /create-seller.
 // Reads a seller from request body and inserts it into the database.
 func (co *ControllerImpl) CreateSeller(c echo.Context) error {
-	glog.Info("create-seller")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("create-seller-started")
+	defer logger(c).Debug("create-seller-completed")
+
+	ctx := c.Request().Context()
 
 	var seller project.Seller
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
-		glog.Error("read-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("read-error", logging.Err(err))
+		return badRequest(c, err)
 	}
 
 	err = json.Unmarshal(body, &seller)
 	if err != nil {
-		glog.Error("unmarshal-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("unmarshal-error", logging.Err(err))
+		return badRequest(c, err)
+	}
+	assignID(&seller.ID)
+	ctx = annotate(c, logging.KeySellerID, seller.ID)
+	if err := validation.Struct(seller); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&seller.Password, &seller.PasswordHash); err != nil {
+		return errorResponse(c, err)
 	}
 
 	// Insert seller using ProjectManager
-	err = co.projectManager.CreateSeller(seller)
+	err = co.projectManager.CreateSeller(ctx, seller)
 	if err != nil {
-		glog.Error("create-seller-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("create-seller-error", logging.Err(err))
+		return errorResponse(c, err)
 	}
 
+	setLocation(c, "sellers", seller.ID)
 	return c.JSON(http.StatusCreated, nil)
 }
 
 // CreateBuyer handles POST /create-buyer.
 // Reads a buyer from request body and inserts it into the database.
 func (co *ControllerImpl) CreateBuyer(c echo.Context) error {
-	glog.Info("create-buyer")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("create-buyer-started")
+	defer logger(c).Debug("create-buyer-completed")
+
+	ctx := c.Request().Context()
 
 	var buyer project.Buyer
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
-		glog.Error("read-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("read-error", logging.Err(err))
+		return badRequest(c, err)
 	}
 
 	err = json.Unmarshal(body, &buyer)
 	if err != nil {
-		glog.Error("unmarshal-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("unmarshal-error", logging.Err(err))
+		return badRequest(c, err)
+	}
+	assignID(&buyer.ID)
+	ctx = annotate(c, logging.KeyBuyerID, buyer.ID)
+	if err := validation.Struct(buyer); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&buyer.Password, &buyer.PasswordHash); err != nil {
+		return errorResponse(c, err)
 	}
 
 	// Insert buyer using ProjectManager
-	err = co.projectManager.CreateBuyer(buyer)
+	err = co.projectManager.CreateBuyer(ctx, buyer)
 	if err != nil {
-		glog.Error("create-buyer-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("create-buyer-error", logging.Err(err))
+		return errorResponse(c, err)
 	}
 
+	setLocation(c, "buyers", buyer.ID)
 	return c.JSON(http.StatusCreated, nil)
 }
 
 // CreateProject handles POST /create-project.
 // Reads a project from request body and saves it in the database.
 func (co *ControllerImpl) CreateProject(c echo.Context) error {
-	glog.Info("create-project")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("create-project-started")
+	defer logger(c).Debug("create-project-completed")
+
+	ctx := c.Request().Context()
 
 	var projectDetails project.ProjectDetails
 	body, err := ioutil.ReadAll(c.Request().Body)
 	if err != nil {
-		glog.Error("read-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("read-error", logging.Err(err))
+		return badRequest(c, err)
 	}
 
 	err = json.Unmarshal(body, &projectDetails)
 	if err != nil {
-		glog.Error("unmarshal-error", err)
-		return c.JSON(http.StatusBadRequest, err)
+		logger(c).Warn("unmarshal-error", logging.Err(err))
+		return badRequest(c, err)
+	}
+
+	assignID(&projectDetails.ID)
+
+	// Sellers may only create projects they own
+	projectDetails.SellerID = callerID(c, auth.RoleSeller, projectDetails.SellerID)
+	ctx = annotate(c, logging.KeyProjectID, projectDetails.ID, logging.KeySellerID, projectDetails.SellerID)
+	if err := authorize(c, auth.RoleSeller, projectDetails.SellerID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.validateProject(ctx, projectDetails); err != nil {
+		return errorResponse(c, err)
 	}
 
 	// Insert project using ProjectManager
-	err = co.projectManager.CreateProject(projectDetails)
+	err = co.projectManager.CreateProject(ctx, projectDetails)
 	if err != nil {
-		glog.Error("create-project-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("create-project-error", logging.Err(err))
+		return errorResponse(c, err)
 	}
 
+	setLocation(c, "projects", projectDetails.ID)
 	return c.JSON(http.StatusCreated, nil)
 }
 
 // GetProjects handles GET /get-projects.
 // Fetches and returns all projects from the database.
 func (co *ControllerImpl) GetProjects(c echo.Context) error {
-	glog.Info("get-project")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("get-project-started")
+	defer logger(c).Debug("get-project-completed")
+
+	ctx := c.Request().Context()
 
-	projectDetails, err := co.projectManager.GetProjects()
+	projectDetails, err := co.projectManager.GetProjects(ctx)
 	if err != nil {
-		glog.Error("get-user-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("get-user-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	for i, p := range projectDetails {
+		projectDetails[i] = visibleProject(c, p)
 	}
 	return c.JSON(http.StatusOK, projectDetails)
 }
 
 // ComputeBID handles POST /compute-bid.
-// Determines the winning buyer (lowest bid) for a given project.
+// Runs the project's auction and returns the winner and clearing price.
 func (co *ControllerImpl) ComputeBID(c echo.Context) error {
-	glog.Info("compute-bid")
-	glog.InfoDepth(1, "started")
-	defer glog.InfoDepth(1, "completed")
+	logger(c).Debug("compute-bid-started")
+	defer logger(c).Debug("compute-bid-completed")
+
+	ctx := c.Request().Context()
+
+	projectID := projectIDParam(c)
+	if err := requireProjectID(projectID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.authorizeProjectOwner(c, projectID); err != nil {
+		return errorResponse(c, err)
+	}
+	version, err := co.checkIfMatch(c, projectID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	// Delegate to BidManager to run the auction
+	result, err := co.bidManager.ComputeBID(ctx, projectID, version)
+	if err != nil {
+		logger(c).Warn("compute-bid-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// UpdateProjectStatus handles PUT /update-project-status.
+// Moves a project to the lifecycle state given in the "status" query param.
+func (co *ControllerImpl) UpdateProjectStatus(c echo.Context) error {
+	logger(c).Debug("update-project-status-started")
+	defer logger(c).Debug("update-project-status-completed")
+
+	ctx := c.Request().Context()
 
 	projectID := c.QueryParam("projectID")
+	if err := requireProjectID(projectID); err != nil {
+		return errorResponse(c, err)
+	}
+	status := project.Status(c.QueryParam("status"))
+	if !status.Valid() {
+		return errorResponse(c, project.ErrInvalidStatus)
+	}
+	if err := co.authorizeProjectOwner(c, projectID); err != nil {
+		return errorResponse(c, err)
+	}
 
-	// Delegate to BidManager to compute winning buyer
-	bidWinner, err := co.bidManager.ComputeBID(projectID)
+	err := co.projectManager.UpdateProjectStatus(ctx, projectID, status)
 	if err != nil {
-		glog.Error("get-user-error", err)
-		return c.JSON(http.StatusInternalServerError, err)
+		logger(c).Warn("update-project-status-error", logging.Err(err))
+		return errorResponse(c, err)
 	}
-	return c.JSON(http.StatusOK, bidWinner)
+	return c.JSON(http.StatusOK, nil)
 }
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/21keshav/IBackendApplication/resources/project"
+
+	"github.com/labstack/echo"
+)
+
+//
+// Conditional Requests
+//
+// Projects are served with their version as a strong ETag, e.g. ETag: "7".
+// If-None-Match on GET returns 304; If-Match on writes returns 412 unless it
+// names the current ETag.
+//
+
+// Conditional request headers.
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// etag returns the ETag of a project version.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// matchesETag reports whether header, a list of ETags or "*", names the
+// ETag of version. Weak ETags are only matched when weak is set, as
+// If-None-Match does and If-Match does not.
+func matchesETag(header string, version int, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch fails with project.ErrVersionMismatch when c's request has an
+// If-Match header that does not name the project's current ETag. Otherwise it
+// returns the version the request's write should be conditional on, or zero
+// when there is no If-Match or it is "*".
+func (co *ControllerImpl) checkIfMatch(c echo.Context, projectID string) (int, error) {
+	header := c.Request().Header.Get(HeaderIfMatch)
+	if header == "" {
+		return 0, nil
+	}
+	projectDetails, err := co.projectManager.GetProject(c.Request().Context(), projectID)
+	if err != nil {
+		return 0, err
+	}
+	if !matchesETag(header, projectDetails.Version, false) {
+		return 0, project.ErrVersionMismatch
+	}
+	if strings.TrimSpace(header) == "*" {
+		return 0, nil
+	}
+	return projectDetails.Version, nil
+}
+
+// notModified reports whether c's GET request has an If-None-Match header
+// naming the ETag of version.
+func notModified(c echo.Context, version int) bool {
+	method := c.Request().Method
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	header := c.Request().Header.Get(HeaderIfNoneMatch)
+	return header != "" && matchesETag(header, version, true)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
+
+	"github.com/labstack/echo"
+)
+
+//
+// Idempotent Requests
+//
+// Creation and bid routes replay the stored response to a retry with the
+// same Idempotency-Key, method, URL and body. Keys are scoped by caller.
+//
+
+// Idempotency headers.
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotent-Replayed"
+)
+
+// anonymousScope owns the keys sent to routes that need no token, e.g. registration.
+const anonymousScope = "anonymous"
+
+// idempotent is middleware that replays the stored response to a request
+// whose Idempotency-Key was seen before, and stores the response otherwise.
+// It runs after authenticate, if at all, to scope keys by caller.
+func (co *ControllerImpl) idempotent(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(HeaderIdempotencyKey)
+		if key == "" || co.idempotency == nil {
+			return next(c)
+		}
+		req := c.Request()
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return badRequest(c, err)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		scope := anonymousScope
+		if claims := auth.ClaimsFrom(c); claims != nil {
+			scope = string(claims.Role) + ":" + claims.Subject
+		}
+		key = scope + " " + key
+		token, stored, err := co.idempotency.Begin(req.Context(), key, fingerprint(req, body))
+		if err != nil {
+			return errorResponse(c, err)
+		}
+		if stored != nil {
+			logger(c).Info("idempotent-replay", "status", stored.Status)
+			return replay(c, *stored)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+		err = next(c)
+
+		// Record the outcome even if the request's own deadline has passed
+		ctx, cancel := context.WithTimeout(logging.NewContext(context.Background(), logger(c)), co.operationTimeout)
+		defer cancel()
+		status := c.Response().Status
+		if err != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+			if releaseErr := co.idempotency.Release(ctx, key, token); releaseErr != nil {
+				logger(c).Warn("idempotency-release-error", logging.Err(releaseErr))
+			}
+			return err
+		}
+		header := c.Response().Header()
+		response := idempotency.Response{
+			Status:      status,
+			ContentType: header.Get(echo.HeaderContentType),
+			Location:    header.Get(echo.HeaderLocation),
+			ETag:        header.Get(HeaderETag),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := co.idempotency.Complete(ctx, key, token, response); err != nil {
+			logger(c).Warn("idempotency-complete-error", logging.Err(err))
+		}
+		return nil
+	}
+}
+
+// fingerprint identifies a request by its method, URL and body.
+func fingerprint(req *http.Request, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// replay writes a stored response.
+func replay(c echo.Context, stored idempotency.Response) error {
+	header := c.Response().Header()
+	header.Set(HeaderIdempotencyReplayed, "true")
+	if stored.Location != "" {
+		header.Set(echo.HeaderLocation, stored.Location)
+	}
+	if stored.ETag != "" {
+		header.Set(HeaderETag, stored.ETag)
+	}
+	if stored.ContentType == "" {
+		return c.NoContent(stored.Status)
+	}
+	return c.Blob(stored.Status, stored.ContentType, stored.Body)
+}
+
+// responseRecorder copies the body written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
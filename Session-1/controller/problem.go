@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/validation"
+
+	"github.com/labstack/echo"
+)
+
+//
+// Error Responses
+//
+// Every error is returned as an RFC 7807 problem details body:
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Unprocessable Entity",
+//	  "status": 422,
+//	  "detail": "ammount: must be at least 1",
+//	  "instance": "/v1/projects/p1/bids",
+//	  "code": "validation_failed",
+//	  "errors": [{"field": "ammount", "code": "too_small", "message": "must be at least 1"}]
+//	}
+//
+// "code" is a stable, machine-readable identifier of the problem;
+// "errors" lists the failing fields of validation problems.
+//
+
+// MIMEApplicationProblemJSON is the content type of problem details bodies.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem codes that do not come from a domain error.
+const (
+	CodeValidationFailed = "validation_failed"
+	CodeMalformedBody    = "malformed_body"
+	CodeInternal         = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Errors   validation.Errors `json:"errors,omitempty"`
+}
+
+// problemKind is the HTTP status and problem code a domain error maps to.
+type problemKind struct {
+	status int
+	code   string
+}
+
+// knownErrors maps domain errors to responses.
+// Malformed stream positions, listing parameters and idempotency keys become 400, missing or bad
+// credentials 401, ownership violations 403, missing resources 404, taken IDs, lifecycle conflicts
+// and retries racing their original request 409, stale If-Match versions 412, bad input or timing
+// and reused idempotency keys 422, requests that ran out of time 504, and anything unrecognised is
+// treated as an internal error.
+var knownErrors = map[error]problemKind{
+	errInvalidLastEventID:      {http.StatusBadRequest, "invalid_last_event_id"},
+	errInvalidListQuery:        {http.StatusBadRequest, "invalid_query"},
+	project.ErrInvalidSort:     {http.StatusBadRequest, "invalid_sort"},
+	project.ErrInvalidField:    {http.StatusBadRequest, "invalid_field"},
+	project.ErrInvalidCursor:   {http.StatusBadRequest, "invalid_cursor"},
+	project.ErrInvalidPageSize: {http.StatusBadRequest, "invalid_limit"},
+	idempotency.ErrKeyTooLong:  {http.StatusBadRequest, "invalid_idempotency_key"},
+
+	auth.ErrUnauthorized:       {http.StatusUnauthorized, "unauthorized"},
+	auth.ErrInvalidCredentials: {http.StatusUnauthorized, "invalid_credentials"},
+	auth.ErrForbidden:          {http.StatusForbidden, "forbidden"},
+
+	project.ErrVersionMismatch: {http.StatusPreconditionFailed, "precondition_failed"},
+
+	project.ErrProjectNotFound: {http.StatusNotFound, "project_not_found"},
+	project.ErrBuyerNotFound:   {http.StatusNotFound, "buyer_not_found"},
+	project.ErrSellerNotFound:  {http.StatusNotFound, "seller_not_found"},
+	project.ErrBidNotFound:     {http.StatusNotFound, "bid_not_found"},
+	project.ErrProxyNotFound:   {http.StatusNotFound, "proxy_not_found"},
+
+	project.ErrProjectExists:     {http.StatusConflict, "project_exists"},
+	project.ErrBuyerExists:       {http.StatusConflict, "buyer_exists"},
+	project.ErrSellerExists:      {http.StatusConflict, "seller_exists"},
+	project.ErrBidExists:         {http.StatusConflict, "bid_exists"},
+	project.ErrProjectNotOpen:    {http.StatusConflict, "project_not_open"},
+	project.ErrInvalidTransition: {http.StatusConflict, "invalid_transition"},
+	project.ErrProjectReadOnly:   {http.StatusConflict, "project_read_only"},
+	project.ErrTermsLocked:       {http.StatusConflict, "terms_locked"},
+	project.ErrBidConflict:       {http.StatusConflict, "bid_conflict"},
+	project.ErrEndDateMoved:      {http.StatusConflict, "end_date_conflict"},
+	project.ErrBidRetracted:      {http.StatusConflict, "bid_retracted"},
+	project.ErrBidTaken:          {http.StatusConflict, "bid_id_taken"},
+	project.ErrBidRevealed:       {http.StatusConflict, "bid_revealed"},
+	project.ErrRevealPending:     {http.StatusConflict, "reveal_pending"},
+	idempotency.ErrInProgress:    {http.StatusConflict, "idempotency_key_in_progress"},
+
+	project.ErrBiddingNotStarted:    {http.StatusUnprocessableEntity, "bidding_not_started"},
+	project.ErrBiddingWindowEnded:   {http.StatusUnprocessableEntity, "bidding_window_ended"},
+	project.ErrInvalidStatus:        {http.StatusUnprocessableEntity, "invalid_status"},
+	project.ErrInvalidWindow:        {http.StatusUnprocessableEntity, "invalid_window"},
+	project.ErrInvalidBidID:         {http.StatusUnprocessableEntity, "invalid_bid_id"},
+	project.ErrNotSealed:            {http.StatusUnprocessableEntity, "not_sealed"},
+	project.ErrRevealNotStarted:     {http.StatusUnprocessableEntity, "reveal_not_started"},
+	project.ErrRevealEnded:          {http.StatusUnprocessableEntity, "reveal_ended"},
+	project.ErrRevealMismatch:       {http.StatusUnprocessableEntity, "reveal_mismatch"},
+	project.ErrInvalidRevealWindow:  {http.StatusUnprocessableEntity, "invalid_reveal_window"},
+	project.ErrSealedPriceRules:     {http.StatusUnprocessableEntity, "sealed_price_rules"},
+	project.ErrInvalidSoftClose:     {http.StatusUnprocessableEntity, "invalid_soft_close"},
+	project.ErrInvalidHardClose:     {http.StatusUnprocessableEntity, "invalid_hard_close"},
+	project.ErrCurrencyMismatch:     {http.StatusUnprocessableEntity, "currency_mismatch"},
+	project.ErrCurrencyRequired:     {http.StatusUnprocessableEntity, "currency_required"},
+	money.ErrUnknownCurrency:        {http.StatusUnprocessableEntity, "unknown_currency"},
+	money.ErrNoRate:                 {http.StatusUnprocessableEntity, "no_exchange_rate"},
+	bidManager.ErrNoBids:            {http.StatusUnprocessableEntity, "no_bids"},
+	bidManager.ErrBidNotImproved:    {http.StatusUnprocessableEntity, "bid_not_improved"},
+	bidManager.ErrProxyNotSupported: {http.StatusUnprocessableEntity, "proxy_not_supported"},
+	bidManager.ErrStartPriceNotMet:  {http.StatusUnprocessableEntity, "start_price_not_met"},
+	bidManager.ErrIncrementNotMet:   {http.StatusUnprocessableEntity, "increment_not_met"},
+	bidManager.ErrUnknownStrategy:   {http.StatusUnprocessableEntity, "unknown_strategy"},
+	auth.ErrInvalidRole:             {http.StatusUnprocessableEntity, "invalid_role"},
+	idempotency.ErrKeyReused:        {http.StatusUnprocessableEntity, "idempotency_key_reused"},
+
+	context.DeadlineExceeded: {http.StatusGatewayTimeout, "timeout"},
+	context.Canceled:         {http.StatusServiceUnavailable, "request_cancelled"},
+}
+
+// errorStatus maps an error to its HTTP status code.
+func errorStatus(err error) int {
+	return problemFor(err).Status
+}
+
+// problemFor builds the problem details describing err, which may wrap one
+// of knownErrors. Internal errors are not described to the client.
+func problemFor(err error) Problem {
+	p := Problem{Type: "about:blank", Status: http.StatusInternalServerError,
+		Detail: "internal server error", Code: CodeInternal}
+
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		p.Status, p.Code, p.Detail, p.Errors = http.StatusUnprocessableEntity, CodeValidationFailed, errs.Error(), errs
+	} else if kind, ok := knownKind(err); ok {
+		p.Status, p.Code, p.Detail = kind.status, kind.code, err.Error()
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// knownKind returns the kind of the outermost error in err's chain found in
+// knownErrors, falling back to errors.Is for errors that match others.
+func knownKind(err error) (problemKind, bool) {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if kind, ok := knownErrors[e]; ok {
+			return kind, true
+		}
+	}
+	for known, kind := range knownErrors {
+		if errors.Is(err, known) {
+			return kind, true
+		}
+	}
+	return problemKind{}, false
+}
+
+// errorResponse writes err as problem details with the status code chosen by errorStatus.
+func errorResponse(c echo.Context, err error) error {
+	p := problemFor(err)
+	if p.Status == http.StatusInternalServerError {
+		logger(c).Error("internal-error", logging.Err(err))
+	}
+	if p.Status == http.StatusUnauthorized {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, strings.TrimSpace(bearerPrefix))
+	}
+	return writeProblem(c, p)
+}
+
+// badRequest writes a 400 response for a body that could not be decoded.
+func badRequest(c echo.Context, err error) error {
+	return writeProblem(c, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+		Code:   CodeMalformedBody,
+	})
+}
+
+func writeProblem(c echo.Context, p Problem) error {
+	p.Instance = c.Request().URL.Path
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.Blob(p.Status, MIMEApplicationProblemJSON, body)
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
+
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+)
+
+//
+// Event Streams
+//
+// GET /v1/projects/:id/events streams a project's events as Server-Sent
+// Events, one per bid placed or retracted, change of the leading bid,
+// extension and close of the auction:
+//
+//	id: 1760684452976001
+//	event: bid.placed
+//	data: {"id":1760684452976001,"type":"bid.placed","project_id":"p1",...}
+//
+// GET /v1/projects/:id/events/ws sends the same JSON objects as WebSocket
+// text messages.
+//
+// A client that reconnects with the ID of the last event it saw, in the
+// Last-Event-ID header or the lastEventId query parameter, first receives
+// the events it missed. Clients that cannot keep up are disconnected and
+// may resume the same way. Idle streams carry a keep-alive comment (SSE) or
+// ping (WebSocket) every keepAlive.
+//
+// Browsers cannot set headers on EventSource and WebSocket requests, so
+// these routes also accept the bearer token in the access_token query parameter.
+//
+
+// DefaultKeepAlive is the time between keep-alive messages on idle streams
+// when no other interval is configured.
+const DefaultKeepAlive = 15 * time.Second
+
+// errInvalidLastEventID is returned for a Last-Event-ID that is not an event ID.
+var errInvalidLastEventID = errors.New("Last-Event-ID must be the id of an event")
+
+// StreamEvents handles GET /v1/projects/:id/events.
+func (co *ControllerImpl) StreamEvents(c echo.Context) error {
+	logger(c).Debug("stream-events-started")
+	defer logger(c).Debug("stream-events-completed")
+
+	sub, err := co.subscribe(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	defer sub.Close()
+
+	w := c.Response()
+	clearDeadlines(w.Writer)
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop proxies such as nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	keepAlive := time.NewTicker(co.keepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				logger(c).Info("event-stream-ended", logging.Err(sub.Err()))
+				return nil
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				logger(c).Warn("event-stream-write-error", logging.Err(err))
+				return nil
+			}
+		}
+		w.Flush()
+	}
+}
+
+// StreamEventsWS handles GET /v1/projects/:id/events/ws.
+func (co *ControllerImpl) StreamEventsWS(c echo.Context) error {
+	logger(c).Debug("stream-events-ws-started")
+	defer logger(c).Debug("stream-events-ws-completed")
+
+	sub, err := co.subscribe(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	defer sub.Close()
+
+	clearDeadlines(c.Response().Writer)
+	server := websocket.Server{
+		// Callers authenticate with a token rather than cookies, so any origin may connect
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   func(ws *websocket.Conn) { co.sendEvents(c, ws, sub) },
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// sendEvents writes sub's events to ws until either side ends the stream.
+func (co *ControllerImpl) sendEvents(c echo.Context, ws *websocket.Conn, sub *events.Subscription) {
+	// Reading handles pings and notices when the client goes away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		io.Copy(ioutil.Discard, ws)
+	}()
+
+	keepAlive := time.NewTicker(co.keepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-gone:
+			return
+		case <-keepAlive.C:
+			ws.PayloadType = websocket.PingFrame
+			_, err := ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				logger(c).Info("event-stream-ended", logging.Err(sub.Err()))
+				ws.Close()
+				return
+			}
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				logger(c).Warn("event-stream-write-error", logging.Err(err))
+				return
+			}
+		}
+	}
+}
+
+// subscribe checks that the project exists and subscribes to its events,
+// starting after the event the client last saw, if any.
+func (co *ControllerImpl) subscribe(c echo.Context) (*events.Subscription, error) {
+	projectID := c.Param("id")
+	lastEventID, err := lastEventID(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), co.operationTimeout)
+	defer cancel()
+	if _, err := co.projectManager.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return co.subscriber.Subscribe(projectID, lastEventID), nil
+}
+
+// lastEventID reads the Last-Event-ID header or lastEventId query parameter.
+// Zero means the client has seen no events.
+func lastEventID(c echo.Context) (uint64, error) {
+	value := c.Request().Header.Get("Last-Event-ID")
+	if value == "" {
+		value = c.QueryParam("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errInvalidLastEventID
+	}
+	return id, nil
+}
+
+// writeServerSentEvent writes event in the text/event-stream format.
+// Events without an ID, such as stream.reset, leave the client's last ID as it was.
+func writeServerSentEvent(w io.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// clearDeadlines lifts the server's read and write timeouts from the
+// connection, which would otherwise cut every stream short.
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,748 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/validation"
+
+	"github.com/labstack/echo"
+)
+
+//
+// v1 Resource API
+//
+// Resource-oriented routes that live alongside the original verb-style ones:
+//
+//	/v1/projects[/:id[/bids[/:bidID[/retraction]]]]
+//	/v1/buyers[/:id]
+//	/v1/sellers[/:id]
+//
+// POST returns 201 with the created resource and its path in the Location
+// header, GET and PATCH return 200 with the resource, DELETE returns 204.
+// Unknown resources return 404. Projects carry an ETag and take If-Match and
+// If-None-Match, see etag.go. Created resources without an "id" get one
+// from the server (see project.NewID); an "id" already in use returns 409.
+// Creating resources and placing or changing bids can be retried safely
+// with an Idempotency-Key header, see idempotency.go.
+//
+// Apart from POST /v1/auth/token and registering buyers and sellers,
+// every route requires a bearer token, see auth.go.
+//
+// Project event streams are served under /v1/projects/:id/events when the
+// controller has a Subscriber, see stream.go.
+//
+
+// attachV1Handlers registers the v1 resource routes.
+func (co *ControllerImpl) attachV1Handlers(lister *echo.Echo) {
+	v1 := lister.Group("/v1")
+	open := []echo.MiddlewareFunc{co.withDeadline, withLogFields}
+	authed := []echo.MiddlewareFunc{co.withDeadline, withLogFields, co.authenticate}
+	openCreate := append(open, co.idempotent)
+	authedCreate := append(authed, co.idempotent)
+
+	v1.POST("/auth/token", co.PostToken, open...)
+
+	v1.GET("/projects", co.ListProjects, authed...)
+	v1.POST("/projects", co.PostProject, authedCreate...)
+	v1.GET("/projects/:id", co.GetProject, authed...)
+	v1.PATCH("/projects/:id", co.PatchProject, authed...)
+	v1.DELETE("/projects/:id", co.DeleteProject, authed...)
+	v1.POST("/projects/:id/award", co.ComputeBID, authed...)
+
+	v1.GET("/projects/:id/bids", co.GetBids, authed...)
+	v1.POST("/projects/:id/bids", co.PostBid, authedCreate...)
+	v1.GET("/projects/:id/bids/:bidID", co.GetBid, authed...)
+	v1.PATCH("/projects/:id/bids/:bidID", co.PatchBid, authedCreate...)
+	v1.DELETE("/projects/:id/bids/:bidID", co.DeleteBid, authed...)
+	v1.POST("/projects/:id/bids/:bidID/retraction", co.RetractBid, authed...)
+	v1.POST("/projects/:id/bids/:bidID/reveal", co.RevealBid, authed...)
+	v1.GET("/projects/:id/proxies/:buyerID", co.GetProxyBid, authed...)
+	v1.PUT("/projects/:id/proxies/:buyerID", co.PutProxyBid, authed...)
+	v1.DELETE("/projects/:id/proxies/:buyerID", co.DeleteProxyBid, authed...)
+
+	if co.subscriber != nil {
+		// Streams outlive the operation deadline
+		streaming := []echo.MiddlewareFunc{withLogFields, co.authenticateStream}
+		v1.GET("/projects/:id/events", co.StreamEvents, streaming...)
+		v1.GET("/projects/:id/events/ws", co.StreamEventsWS, streaming...)
+	}
+
+	v1.GET("/buyers", co.GetBuyers, authed...)
+	v1.POST("/buyers", co.PostBuyer, openCreate...)
+	v1.GET("/buyers/:id", co.GetBuyer, authed...)
+	v1.PATCH("/buyers/:id", co.PatchBuyer, authed...)
+	v1.DELETE("/buyers/:id", co.DeleteBuyer, authed...)
+
+	v1.GET("/sellers", co.GetSellers, authed...)
+	v1.POST("/sellers", co.PostSeller, openCreate...)
+	v1.GET("/sellers/:id", co.GetSeller, authed...)
+	v1.PATCH("/sellers/:id", co.PatchSeller, authed...)
+	v1.DELETE("/sellers/:id", co.DeleteSeller, authed...)
+}
+
+// projectIDParam returns the project ID from the :id path parameter,
+// falling back to the legacy projectID query parameter.
+func projectIDParam(c echo.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return c.QueryParam("projectID")
+}
+
+// assignID gives a resource created without an ID a new one.
+func assignID(id *string) {
+	if *id == "" {
+		*id = project.NewID()
+	}
+}
+
+// setLocation sets the Location header to the v1 path made of segments,
+// e.g. /v1/projects/p1/bids/b1.
+func setLocation(c echo.Context, segments ...string) {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	c.Response().Header().Set(echo.HeaderLocation, "/v1/"+strings.Join(escaped, "/"))
+}
+
+// bindBody decodes the JSON request body into v.
+func bindBody(c echo.Context, v interface{}) error {
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		logger(c).Warn("read-error", logging.Err(err))
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		logger(c).Warn("unmarshal-error", logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// validStrategy checks a requested auction strategy, allowing it to be omitted.
+func validStrategy(name string) error {
+	_, err := bidManager.StrategyFor(name)
+	return err
+}
+
+//
+// Projects
+//
+
+// PostProject handles POST /v1/projects.
+func (co *ControllerImpl) PostProject(c echo.Context) error {
+	ctx := c.Request().Context()
+	var projectDetails project.ProjectDetails
+	if err := bindBody(c, &projectDetails); err != nil {
+		return badRequest(c, err)
+	}
+	assignID(&projectDetails.ID)
+	projectDetails.SellerID = callerID(c, auth.RoleSeller, projectDetails.SellerID)
+	ctx = annotate(c, logging.KeyProjectID, projectDetails.ID, logging.KeySellerID, projectDetails.SellerID)
+	if err := authorize(c, auth.RoleSeller, projectDetails.SellerID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.validateProject(ctx, projectDetails); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.CreateProject(ctx, projectDetails); err != nil {
+		logger(c).Warn("create-project-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	setLocation(c, "projects", projectDetails.ID)
+	return co.respondProject(c, http.StatusCreated, projectDetails.ID)
+}
+
+// errInvalidListQuery is returned for GET /v1/projects query parameters that cannot be parsed.
+var errInvalidListQuery = errors.New("status must be a project status, limit a number and end_after and end_before RFC 3339 times")
+
+// ListProjects handles GET /v1/projects.
+// Returns a page of projects filtered by the seller_id, status, tag,
+// end_after and end_before query parameters, ordered by sort and reduced to
+// the comma-separated fields. A Link header with rel="next" points at the
+// next page, if there is one.
+func (co *ControllerImpl) ListProjects(c echo.Context) error {
+	ctx := c.Request().Context()
+	query, err := projectQuery(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	page, err := co.projectManager.ListProjects(ctx, query)
+	if err != nil {
+		logger(c).Warn("list-projects-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	for i, p := range page.Projects {
+		page.Projects[i] = visibleProject(c, p)
+	}
+	if page.NextCursor != "" {
+		next := *c.Request().URL
+		params := next.Query()
+		params.Set("cursor", page.NextCursor)
+		next.RawQuery = params.Encode()
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+	return c.JSON(http.StatusOK, page.Projects)
+}
+
+// projectQuery reads the query parameters of GET /v1/projects.
+func projectQuery(c echo.Context) (project.ProjectQuery, error) {
+	query := project.ProjectQuery{
+		SellerID: c.QueryParam("seller_id"),
+		Status:   project.Status(c.QueryParam("status")),
+		Tag:      c.QueryParam("tag"),
+		Sort:     c.QueryParam("sort"),
+		Cursor:   c.QueryParam("cursor"),
+	}
+	if query.Status != "" && !query.Status.Valid() {
+		return query, errInvalidListQuery
+	}
+	if fields := c.QueryParam("fields"); fields != "" {
+		query.Fields = strings.Split(fields, ",")
+	}
+	var err error
+	if limit := c.QueryParam("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, errInvalidListQuery
+		}
+	}
+	if after := c.QueryParam("end_after"); after != "" {
+		if query.EndAfter, err = time.Parse(time.RFC3339, after); err != nil {
+			return query, errInvalidListQuery
+		}
+	}
+	if before := c.QueryParam("end_before"); before != "" {
+		if query.EndBefore, err = time.Parse(time.RFC3339, before); err != nil {
+			return query, errInvalidListQuery
+		}
+	}
+	return query, nil
+}
+
+// GetProject handles GET /v1/projects/:id.
+func (co *ControllerImpl) GetProject(c echo.Context) error {
+	return co.respondProject(c, http.StatusOK, c.Param("id"))
+}
+
+// PatchProject handles PATCH /v1/projects/:id.
+// Updates details, strategy and bidding window; a "status" field moves
+// the project through its lifecycle.
+func (co *ControllerImpl) PatchProject(c echo.Context) error {
+	ctx := c.Request().Context()
+	projectID := c.Param("id")
+	if err := co.authorizeProjectOwner(c, projectID); err != nil {
+		return errorResponse(c, err)
+	}
+
+	var changes project.ProjectDetails
+	if err := bindBody(c, &changes); err != nil {
+		return badRequest(c, err)
+	}
+	if err := validateProjectChanges(changes); err != nil {
+		return errorResponse(c, err)
+	}
+	version, err := co.checkIfMatch(c, projectID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	changes.Version = version // Makes the edit conditional on the version; never written
+	if err := co.projectManager.UpdateProjectDetails(ctx, projectID, changes); err != nil {
+		logger(c).Warn("patch-project-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondProject(c, http.StatusOK, projectID)
+}
+
+// DeleteProject handles DELETE /v1/projects/:id.
+func (co *ControllerImpl) DeleteProject(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := co.authorizeProjectOwner(c, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+	version, err := co.checkIfMatch(c, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.DeleteProject(ctx, c.Param("id"), version); err != nil {
+		logger(c).Warn("delete-project-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (co *ControllerImpl) respondProject(c echo.Context, code int, projectID string) error {
+	ctx := c.Request().Context()
+	projectDetails, err := co.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	c.Response().Header().Set(HeaderETag, etag(projectDetails.Version))
+	if notModified(c, projectDetails.Version) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(code, visibleProject(c, projectDetails))
+}
+
+// visibleProject hides a project's hidden reserve price from everyone but
+// its seller and admins.
+func visibleProject(c echo.Context, projectDetails project.ProjectDetails) project.ProjectDetails {
+	if projectDetails.ReserveHidden && authorize(c, auth.RoleSeller, projectDetails.SellerID) != nil {
+		return projectDetails.Public()
+	}
+	return projectDetails
+}
+
+//
+// Bids
+//
+
+// GetBids handles GET /v1/projects/:id/bids.
+func (co *ControllerImpl) GetBids(c echo.Context) error {
+	ctx := c.Request().Context()
+	projectDetails, err := co.projectManager.GetProject(ctx, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	bids, err := co.projectManager.GetBids(ctx, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	for i, bid := range bids {
+		bids[i] = visibleBid(c, projectDetails, bid)
+	}
+	return c.JSON(http.StatusOK, bids)
+}
+
+// PostBid handles POST /v1/projects/:id/bids.
+// Places a new bid; existing bids are amended with PatchBid.
+func (co *ControllerImpl) PostBid(c echo.Context) error {
+	ctx := c.Request().Context()
+	var bid project.BID
+	if err := bindBody(c, &bid); err != nil {
+		return badRequest(c, err)
+	}
+	projectID := c.Param("id")
+	if bid.ID == "" {
+		bid.ID = project.NewID()
+	} else if _, err := co.projectManager.GetBid(ctx, projectID, bid.ID); err == nil {
+		return errorResponse(c, project.ErrBidExists)
+	}
+	bid.BuyerID = callerID(c, auth.RoleBuyer, bid.BuyerID)
+	ctx = annotate(c, logging.KeyBidID, bid.ID, logging.KeyBuyerID, bid.BuyerID)
+	if err := authorize(c, auth.RoleBuyer, bid.BuyerID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.validateBid(ctx, projectID, bid); err != nil {
+		return errorResponse(c, err)
+	}
+	setLocation(c, "projects", projectID, "bids", bid.ID)
+	return co.placeBid(c, http.StatusCreated, projectID, bid)
+}
+
+// GetBid handles GET /v1/projects/:id/bids/:bidID.
+func (co *ControllerImpl) GetBid(c echo.Context) error {
+	return co.respondBid(c, http.StatusOK, c.Param("id"), c.Param("bidID"))
+}
+
+// PatchBid handles PATCH /v1/projects/:id/bids/:bidID.
+// Amends the bid's amount, or commitment on sealed projects, adding a revision
+// to its history; the bid's buyer cannot change.
+func (co *ControllerImpl) PatchBid(c echo.Context) error {
+	projectID, bidID := c.Param("id"), c.Param("bidID")
+
+	bid, err := co.ownBid(c, projectID, bidID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	buyerID := bid.BuyerID
+	if err := bindBody(c, &bid); err != nil {
+		return badRequest(c, err)
+	}
+	bid.ID, bid.BuyerID = bidID, buyerID
+	if err := co.checkBidFields(c.Request().Context(), projectID, bid); err != nil {
+		return errorResponse(c, err)
+	}
+	return co.placeBid(c, http.StatusOK, projectID, bid)
+}
+
+// retraction is the body of POST /v1/projects/:id/bids/:bidID/retraction.
+type retraction struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// RetractBid handles POST /v1/projects/:id/bids/:bidID/retraction.
+// The bid stays visible with status "retracted" and no longer takes part in the auction.
+func (co *ControllerImpl) RetractBid(c echo.Context) error {
+	var body retraction
+	if err := bindBody(c, &body); err != nil {
+		return badRequest(c, err)
+	}
+	if err := co.retractBid(c, c.Param("id"), c.Param("bidID"), body.Reason); err != nil {
+		return errorResponse(c, err)
+	}
+	return co.respondBid(c, http.StatusOK, c.Param("id"), c.Param("bidID"))
+}
+
+// reveal is the body of POST /v1/projects/:id/bids/:bidID/reveal.
+// A short salt would let anyone find the amount by trying every candidate.
+type reveal struct {
+	Amount int    `json:"amount" validate:"min=1"` // Minor units, as committed; also read as "ammount"
+	Salt   string `json:"salt" validate:"min=16,max=200"`
+}
+
+// UnmarshalJSON reads the amount under "amount" or the legacy "ammount".
+func (r *reveal) UnmarshalJSON(data []byte) error {
+	var body struct {
+		Amount json.RawMessage `json:"amount"`
+		Legacy json.RawMessage `json:"ammount"`
+		Salt   string          `json:"salt"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	amount, _, err := money.DecodeAmount(body.Amount, body.Legacy, "")
+	if err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+	r.Amount, r.Salt = amount, body.Salt
+	return nil
+}
+
+// RevealBid handles POST /v1/projects/:id/bids/:bidID/reveal.
+// Opens a sealed bid during the project's reveal phase, see project/sealed.go.
+func (co *ControllerImpl) RevealBid(c echo.Context) error {
+	projectID, bidID := c.Param("id"), c.Param("bidID")
+	var body reveal
+	if err := bindBody(c, &body); err != nil {
+		return badRequest(c, err)
+	}
+	if _, err := co.ownBid(c, projectID, bidID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := validation.Struct(body); err != nil {
+		return errorResponse(c, err)
+	}
+	ctx := c.Request().Context()
+	if err := co.bidManager.RevealBid(ctx, projectID, bidID, body.Amount, body.Salt); err != nil {
+		logger(c).Warn("reveal-bid-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondBid(c, http.StatusOK, projectID, bidID)
+}
+
+// DeleteBid handles DELETE /v1/projects/:id/bids/:bidID?reason=...
+// Bids are never removed; this retracts the bid like RetractBid.
+func (co *ControllerImpl) DeleteBid(c echo.Context) error {
+	if err := co.retractBid(c, c.Param("id"), c.Param("bidID"), c.QueryParam("reason")); err != nil {
+		return errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (co *ControllerImpl) retractBid(c echo.Context, projectID, bidID, reason string) error {
+	if _, err := co.ownBid(c, projectID, bidID); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if err := validation.Struct(retraction{Reason: reason}); err != nil {
+		return err
+	}
+	if err := co.bidManager.RetractBid(ctx, projectID, bidID, reason); err != nil {
+		logger(c).Warn("retract-bid-error", logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+func (co *ControllerImpl) placeBid(c echo.Context, code int, projectID string, bid project.BID) error {
+	ctx := c.Request().Context()
+	version, err := co.checkIfMatch(c, projectID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.bidManager.DoBID(ctx, projectID, bid, version); err != nil {
+		logger(c).Warn("place-bid-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondBid(c, code, projectID, bid.ID)
+}
+
+// ownBid returns a bid after checking that the caller is the buyer who placed it.
+func (co *ControllerImpl) ownBid(c echo.Context, projectID, bidID string) (project.BID, error) {
+	ctx := c.Request().Context()
+	bid, err := co.projectManager.GetBid(ctx, projectID, bidID)
+	if err != nil {
+		return project.BID{}, err
+	}
+	annotate(c, logging.KeyBuyerID, bid.BuyerID)
+	return bid, authorize(c, auth.RoleBuyer, bid.BuyerID)
+}
+
+func (co *ControllerImpl) respondBid(c echo.Context, code int, projectID, bidID string) error {
+	ctx := c.Request().Context()
+	projectDetails, err := co.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	bid, err := co.projectManager.GetBid(ctx, projectID, bidID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(code, visibleBid(c, projectDetails, bid))
+}
+
+// visibleBid hides the amounts of a bid on a sealed project from everyone
+// but its buyer until the project is awarded.
+func visibleBid(c echo.Context, projectDetails project.ProjectDetails, bid project.BID) project.BID {
+	if projectDetails.AmountsSecret() && authorize(c, auth.RoleBuyer, bid.BuyerID) != nil {
+		return bid.WithoutAmounts()
+	}
+	return bid
+}
+
+//
+// Proxy Bids
+//
+// A buyer's proxy bid is only visible to the buyer.
+//
+
+// GetProxyBid handles GET /v1/projects/:id/proxies/:buyerID.
+func (co *ControllerImpl) GetProxyBid(c echo.Context) error {
+	return co.respondProxyBid(c, http.StatusOK, c.Param("id"), c.Param("buyerID"))
+}
+
+// PutProxyBid handles PUT /v1/projects/:id/proxies/:buyerID.
+// Sets the buyer's maximum and the bid raised on their behalf, body {"bid_id": "b1", "maximum": 500}.
+func (co *ControllerImpl) PutProxyBid(c echo.Context) error {
+	projectID, buyerID := c.Param("id"), c.Param("buyerID")
+	ctx := annotate(c, logging.KeyBuyerID, buyerID)
+	if err := authorize(c, auth.RoleBuyer, buyerID); err != nil {
+		return errorResponse(c, err)
+	}
+	var proxy project.ProxyBid
+	if err := bindBody(c, &proxy); err != nil {
+		return badRequest(c, err)
+	}
+	proxy.BuyerID = buyerID
+	if err := merge(validation.Struct(proxy), co.checkBuyer(ctx, "buyer_id", buyerID)); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.bidManager.SetProxyBid(ctx, projectID, proxy); err != nil {
+		logger(c).Warn("set-proxy-bid-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondProxyBid(c, http.StatusOK, projectID, buyerID)
+}
+
+// DeleteProxyBid handles DELETE /v1/projects/:id/proxies/:buyerID.
+// The bids the proxy placed stay.
+func (co *ControllerImpl) DeleteProxyBid(c echo.Context) error {
+	buyerID := c.Param("buyerID")
+	if err := authorize(c, auth.RoleBuyer, buyerID); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.bidManager.CancelProxyBid(c.Request().Context(), c.Param("id"), buyerID); err != nil {
+		logger(c).Warn("cancel-proxy-bid-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (co *ControllerImpl) respondProxyBid(c echo.Context, code int, projectID, buyerID string) error {
+	if err := authorize(c, auth.RoleBuyer, buyerID); err != nil {
+		return errorResponse(c, err)
+	}
+	proxy, err := co.projectManager.GetProxyBid(c.Request().Context(), projectID, buyerID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(code, proxy)
+}
+
+//
+// Buyers
+//
+
+// GetBuyers handles GET /v1/buyers.
+func (co *ControllerImpl) GetBuyers(c echo.Context) error {
+	ctx := c.Request().Context()
+	buyers, err := co.projectManager.GetBuyers(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, buyers)
+}
+
+// PostBuyer handles POST /v1/buyers.
+func (co *ControllerImpl) PostBuyer(c echo.Context) error {
+	ctx := c.Request().Context()
+	var buyer project.Buyer
+	if err := bindBody(c, &buyer); err != nil {
+		return badRequest(c, err)
+	}
+	assignID(&buyer.ID)
+	ctx = annotate(c, logging.KeyBuyerID, buyer.ID)
+	if err := validation.Struct(buyer); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&buyer.Password, &buyer.PasswordHash); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.CreateBuyer(ctx, buyer); err != nil {
+		logger(c).Warn("create-buyer-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	setLocation(c, "buyers", buyer.ID)
+	return co.respondBuyer(c, http.StatusCreated, buyer.ID)
+}
+
+// GetBuyer handles GET /v1/buyers/:id.
+func (co *ControllerImpl) GetBuyer(c echo.Context) error {
+	return co.respondBuyer(c, http.StatusOK, c.Param("id"))
+}
+
+// PatchBuyer handles PATCH /v1/buyers/:id.
+func (co *ControllerImpl) PatchBuyer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := authorize(c, auth.RoleBuyer, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+	var changes project.Buyer
+	if err := bindBody(c, &changes); err != nil {
+		return badRequest(c, err)
+	}
+	if err := validation.Partial(changes); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&changes.Password, &changes.PasswordHash); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.UpdateBuyer(ctx, c.Param("id"), changes); err != nil {
+		logger(c).Warn("patch-buyer-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondBuyer(c, http.StatusOK, c.Param("id"))
+}
+
+// DeleteBuyer handles DELETE /v1/buyers/:id.
+func (co *ControllerImpl) DeleteBuyer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := authorize(c, auth.RoleBuyer, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.DeleteBuyer(ctx, c.Param("id")); err != nil {
+		logger(c).Warn("delete-buyer-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (co *ControllerImpl) respondBuyer(c echo.Context, code int, buyerID string) error {
+	ctx := c.Request().Context()
+	buyer, err := co.projectManager.GetBuyer(ctx, buyerID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(code, buyer)
+}
+
+//
+// Sellers
+//
+
+// GetSellers handles GET /v1/sellers.
+func (co *ControllerImpl) GetSellers(c echo.Context) error {
+	ctx := c.Request().Context()
+	sellers, err := co.projectManager.GetSellers(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, sellers)
+}
+
+// PostSeller handles POST /v1/sellers.
+func (co *ControllerImpl) PostSeller(c echo.Context) error {
+	ctx := c.Request().Context()
+	var seller project.Seller
+	if err := bindBody(c, &seller); err != nil {
+		return badRequest(c, err)
+	}
+	assignID(&seller.ID)
+	ctx = annotate(c, logging.KeySellerID, seller.ID)
+	if err := validation.Struct(seller); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&seller.Password, &seller.PasswordHash); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.CreateSeller(ctx, seller); err != nil {
+		logger(c).Warn("create-seller-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	setLocation(c, "sellers", seller.ID)
+	return co.respondSeller(c, http.StatusCreated, seller.ID)
+}
+
+// GetSeller handles GET /v1/sellers/:id.
+func (co *ControllerImpl) GetSeller(c echo.Context) error {
+	return co.respondSeller(c, http.StatusOK, c.Param("id"))
+}
+
+// PatchSeller handles PATCH /v1/sellers/:id.
+func (co *ControllerImpl) PatchSeller(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := authorize(c, auth.RoleSeller, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+	var changes project.Seller
+	if err := bindBody(c, &changes); err != nil {
+		return badRequest(c, err)
+	}
+	if err := validation.Partial(changes); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := hashPassword(&changes.Password, &changes.PasswordHash); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.UpdateSeller(ctx, c.Param("id"), changes); err != nil {
+		logger(c).Warn("patch-seller-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return co.respondSeller(c, http.StatusOK, c.Param("id"))
+}
+
+// DeleteSeller handles DELETE /v1/sellers/:id.
+func (co *ControllerImpl) DeleteSeller(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := authorize(c, auth.RoleSeller, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := co.projectManager.DeleteSeller(ctx, c.Param("id")); err != nil {
+		logger(c).Warn("delete-seller-error", logging.Err(err))
+		return errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (co *ControllerImpl) respondSeller(c echo.Context, code int, sellerID string) error {
+	ctx := c.Request().Context()
+	seller, err := co.projectManager.GetSeller(ctx, sellerID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return c.JSON(code, seller)
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/validation"
+)
+
+//
+// Request Validation
+//
+// Field rules are declared with `validate` tags on the resource types (see the
+// validation package). The checks here add what tags cannot express:
+// registered strategies and currencies, references to buyers and sellers that
+// must exist and the commitments that replace amounts on sealed projects.
+// All failures are collected and reported together as validation.Errors.
+//
+
+// validateProject checks a new project, its strategy and that its seller exists.
+func (co *ControllerImpl) validateProject(ctx context.Context, projectDetails project.ProjectDetails) error {
+	return merge(
+		validation.Struct(projectDetails),
+		checkStrategy(projectDetails.Strategy),
+		checkCurrency("currency", projectDetails.Currency),
+		co.checkSeller(ctx, "seller_id", projectDetails.SellerID),
+	)
+}
+
+// validateProjectChanges checks the fields present in a partial project update.
+func validateProjectChanges(changes project.ProjectDetails) error {
+	return merge(
+		validation.Partial(changes),
+		checkStrategy(changes.Strategy),
+	)
+}
+
+// validateBid checks a bid, the project it is placed on and that its buyer exists.
+func (co *ControllerImpl) validateBid(ctx context.Context, projectID string, bid project.BID) error {
+	return merge(
+		requireProjectID(projectID),
+		co.checkBidFields(ctx, projectID, bid),
+		checkCurrency("currency", bid.Currency),
+		co.checkBuyer(ctx, "buyer_id", bid.BuyerID),
+	)
+}
+
+// sealedBid holds the fields a bid on a sealed project is checked against.
+type sealedBid struct {
+	ID         string `json:"id" validate:"required,key,max=64"`
+	BuyerID    string `json:"buyer_id" validate:"required"`
+	Commitment string `json:"commitment" validate:"required"`
+}
+
+// checkBidFields applies the bid's field rules: bids on sealed projects
+// carry a commitment and must not disclose their amount, all others carry
+// an amount and no commitment.
+func (co *ControllerImpl) checkBidFields(ctx context.Context, projectID string, bid project.BID) error {
+	if projectID == "" {
+		return validation.Struct(bid)
+	}
+	projectDetails, err := co.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if !projectDetails.Sealed {
+		if bid.Commitment != "" {
+			return merge(validation.Struct(bid),
+				validation.NewError("commitment", validation.CodeNotAllowed, "is only used on sealed projects"))
+		}
+		return validation.Struct(bid)
+	}
+
+	var amount, commitment error
+	if bid.Amount != 0 {
+		amount = validation.NewError("amount", validation.CodeNotAllowed, "must stay secret until the bid is revealed")
+	}
+	if bid.Commitment != "" && !project.ValidCommitment(bid.Commitment) {
+		commitment = validation.NewError("commitment", validation.CodeNotAllowed, "must be a hex-encoded SHA-256 digest")
+	}
+	return merge(
+		validation.Struct(sealedBid{ID: bid.ID, BuyerID: bid.BuyerID, Commitment: bid.Commitment}),
+		amount,
+		commitment,
+	)
+}
+
+// requireProjectID reports a missing projectID on the legacy query-param routes.
+func requireProjectID(projectID string) error {
+	if projectID == "" {
+		return validation.NewError("projectID", validation.CodeRequired, "is required")
+	}
+	return nil
+}
+
+// checkStrategy reports a strategy that is not registered. An empty name uses the default.
+func checkStrategy(name string) error {
+	if validStrategy(name) != nil {
+		return validation.NewError("strategy", validation.CodeNotAllowed, bidManager.ErrUnknownStrategy.Error())
+	}
+	return nil
+}
+
+// checkCurrency reports field when code is not a known ISO-4217 currency.
+// An empty code uses the project's currency.
+func checkCurrency(field, code string) error {
+	if code != "" && !money.Known(code) {
+		return validation.NewError(field, validation.CodeNotAllowed, money.ErrUnknownCurrency.Error())
+	}
+	return nil
+}
+
+// checkSeller reports field when sellerID does not name a registered seller.
+// An empty ID is left to the "required" rule.
+func (co *ControllerImpl) checkSeller(ctx context.Context, field, sellerID string) error {
+	if sellerID == "" {
+		return nil
+	}
+	_, err := co.projectManager.GetSeller(ctx, sellerID)
+	if err == project.ErrSellerNotFound {
+		return validation.NewError(field, validation.CodeNotFound, "seller does not exist")
+	}
+	return err
+}
+
+// checkBuyer reports field when buyerID does not name a registered buyer.
+// An empty ID is left to the "required" rule.
+func (co *ControllerImpl) checkBuyer(ctx context.Context, field, buyerID string) error {
+	if buyerID == "" {
+		return nil
+	}
+	_, err := co.projectManager.GetBuyer(ctx, buyerID)
+	if err == project.ErrBuyerNotFound {
+		return validation.NewError(field, validation.CodeNotFound, "buyer does not exist")
+	}
+	return err
+}
+
+// merge combines validation results into one validation.Errors.
+// Any other error is returned as is, since it means a check could not run.
+func merge(results ...error) error {
+	var all validation.Errors
+	for _, err := range results {
+		switch errs := err.(type) {
+		case nil:
+		case validation.Errors:
+			all = append(all, errs...)
+		default:
+			return err
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return all
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+)
+
+//
+// Domain Events
+//
+// Components announce things that happened, such as a project being awarded,
+// by publishing an Event. Publishers decide where events go; LogPublisher
+// simply writes them to the application log and Hub fans them out to the
+// clients streaming a project's events, see hub.go.
+//
+
+// Event types.
+const (
+	BidPlaced       = "bid.placed"       // Data is the bidManager.BidActivity; placed or amended
+	BidRetracted    = "bid.retracted"    // Data is the bidManager.BidActivity
+	BidRevealed     = "bid.revealed"     // Data is the bidManager.BidActivity, without the amount
+	LeaderChanged   = "leader.changed"   // Data is the leading bidManager.BidActivity, null when no bids are left
+	AuctionExtended = "auction.extended" // Data is the project's new end date
+	ProjectAwarded  = "project.awarded"  // Data is the bidManager.AuctionResult
+	ProjectClosed   = "project.closed"   // Bidding ended without a winner; Data is the bidManager.AuctionResult when the reserve was not met
+	StreamReset     = "stream.reset"     // Sent by Hub when events after Last-Event-ID are no longer kept
+)
+
+// Event is something that happened to a project.
+// ID is assigned by Hub; it increases with every event the hub publishes.
+type Event struct {
+	ID        uint64      `json:"id,omitempty"`
+	Type      string      `json:"type"`
+	ProjectID string      `json:"project_id"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Publisher delivers events. Publish must not block for long.
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Discard is a Publisher that drops every event.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(Event) {}
+
+// MultiPublisher delivers every event to each of its publishers in turn.
+type MultiPublisher []Publisher
+
+// NewMultiPublisher returns a Publisher that delivers events to all of publishers.
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return MultiPublisher(publishers)
+}
+
+// Publish delivers event to every publisher.
+func (mp MultiPublisher) Publish(event Event) {
+	for _, p := range mp {
+		p.Publish(event)
+	}
+}
+
+// LogPublisher writes every event to the log, one line with the event's fields.
+type LogPublisher struct{}
+
+// NewLogPublisher returns a Publisher that logs events.
+func NewLogPublisher() Publisher {
+	return &LogPublisher{}
+}
+
+// Publish logs event.
+func (lp *LogPublisher) Publish(event Event) {
+	logging.Default().Info("event",
+		"type", event.Type,
+		logging.KeyProjectID, event.ProjectID,
+		"time", event.Time,
+		"data", event.Data,
+	)
+}
@@ -0,0 +1,204 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+//
+// Event Hub
+//
+// Hub is an in-process pub/sub hub: it numbers every event it is given and
+// passes it on to the subscribers of the event's project. It never blocks a
+// publisher. Each subscriber has a bounded queue; one that falls behind is
+// dropped with ErrSlowConsumer and may subscribe again from the last event
+// it saw.
+//
+// The most recent events are kept so that a subscriber can resume after a
+// lost connection. IDs start from the time the hub was created, so IDs
+// handed out by an earlier process are never mistaken for newer ones. When
+// the events after the given ID are no longer kept, the subscriber gets a
+// StreamReset event instead and should reload the project's state.
+//
+// Events published by other replicas never reach this hub.
+//
+
+// Defaults used for zero HubConfig fields.
+const (
+	DefaultHistory = 1000
+	DefaultBuffer  = 64
+)
+
+// Subscription errors reported by Subscription.Err.
+var (
+	ErrSlowConsumer = errors.New("subscriber fell behind and was dropped")
+	ErrHubClosed    = errors.New("event hub is closed")
+)
+
+// HubConfig tunes a Hub.
+type HubConfig struct {
+	History int // Events kept for resuming, across all projects
+	Buffer  int // Events queued per subscriber before it is dropped as too slow
+}
+
+// Subscriber hands out subscriptions to the events of a project.
+type Subscriber interface {
+	// Subscribe starts delivering the events of projectID. When lastEventID
+	// is not zero, the kept events published after it are delivered first.
+	Subscribe(projectID string, lastEventID uint64) *Subscription
+}
+
+// Hub is a Publisher and Subscriber that fans events out to subscribers.
+type Hub struct {
+	mu      sync.Mutex
+	history []Event // Ring of the most recent events
+	next    int     // Index in history of the next event
+	count   int     // Number of events in history
+	lastID  uint64  // ID of the last event published
+	evicted uint64  // ID of the newest event no longer in history
+	buffer  int
+	subs    map[string]map[*Subscription]struct{} // By project ID
+	closed  bool
+}
+
+// NewHub creates an empty Hub.
+func NewHub(conf HubConfig) *Hub {
+	if conf.History <= 0 {
+		conf.History = DefaultHistory
+	}
+	if conf.Buffer <= 0 {
+		conf.Buffer = DefaultBuffer
+	}
+	start := uint64(time.Now().UnixMicro())
+	return &Hub{
+		history: make([]Event, conf.History),
+		lastID:  start,
+		evicted: start,
+		buffer:  conf.Buffer,
+		subs:    map[string]map[*Subscription]struct{}{},
+	}
+}
+
+// Publish numbers event, keeps it for resuming subscribers and queues it for
+// the current subscribers of its project. Subscribers whose queue is full
+// are dropped.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	h.lastID++
+	event.ID = h.lastID
+	if h.count == len(h.history) {
+		h.evicted = h.history[h.next].ID
+	} else {
+		h.count++
+	}
+	h.history[h.next] = event
+	h.next = (h.next + 1) % len(h.history)
+
+	for sub := range h.subs[event.ProjectID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.drop(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// Subscribe starts delivering the events of projectID, see Subscriber.
+func (h *Hub) Subscribe(projectID string, lastEventID uint64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	switch {
+	case lastEventID == 0:
+	case lastEventID < h.evicted || lastEventID > h.lastID:
+		replay = append(replay, Event{Type: StreamReset, ProjectID: projectID, Time: time.Now()})
+	default:
+		for i := 0; i < h.count; i++ {
+			event := h.history[(h.next-h.count+i+len(h.history))%len(h.history)]
+			if event.ProjectID == projectID && event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sub := &Subscription{
+		hub:       h,
+		projectID: projectID,
+		events:    make(chan Event, len(replay)+h.buffer),
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	if h.closed {
+		sub.err = ErrHubClosed
+		close(sub.events)
+		return sub
+	}
+	if h.subs[projectID] == nil {
+		h.subs[projectID] = map[*Subscription]struct{}{}
+	}
+	h.subs[projectID][sub] = struct{}{}
+	return sub
+}
+
+// Close ends every subscription with ErrHubClosed. Later events are dropped.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.drop(sub, ErrHubClosed)
+		}
+	}
+}
+
+// drop removes sub and closes its channel. h.mu must be held.
+func (h *Hub) drop(sub *Subscription, err error) {
+	subs := h.subs[sub.projectID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.projectID)
+	}
+	sub.err = err
+	close(sub.events)
+}
+
+// Subscription receives the events of one project.
+type Subscription struct {
+	hub       *Hub
+	projectID string
+	events    chan Event
+	err       error // Why events was closed; guarded by hub.mu
+}
+
+// Events returns the channel the events are delivered on.
+// It is closed when the subscription ends; Err tells why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns ErrSlowConsumer or ErrHubClosed once the hub has ended the
+// subscription, and nil otherwise.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s, nil)
+}
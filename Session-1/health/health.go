@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/labstack/echo"
+)
+
+//
+// Liveness and Readiness
+//
+// GET /healthz answers as long as the process is serving requests; it never
+// touches dependencies, so a slow database does not get the instance killed.
+// GET /readyz runs every registered Check with a timeout and reports each
+// dependency, so load balancers stop routing to an instance that cannot work.
+//
+
+// Status values reported for the service and each dependency.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// DefaultTimeout bounds each readiness check when no timeout is configured.
+const DefaultTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable; it must honour ctx.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of one dependency check.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the body of /healthz and /readyz.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// HealthChecker serves the liveness and readiness endpoints.
+type HealthChecker interface {
+	// Ready runs all checks concurrently and returns the combined report.
+	Ready(ctx context.Context) Report
+
+	// AttachHandlers registers GET /healthz and GET /readyz.
+	AttachHandlers(lister *echo.Echo)
+}
+
+// HealthCheckerImpl is the concrete implementation of HealthChecker.
+type HealthCheckerImpl struct {
+	checks  map[string]Check // Dependency name -> check
+	timeout time.Duration    // Deadline for a single check
+}
+
+// NewHealthChecker returns a HealthChecker running checks, keyed by
+// dependency name, each bounded by timeout.
+func NewHealthChecker(timeout time.Duration, checks map[string]Check) HealthChecker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &HealthCheckerImpl{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Ready runs every check under its own timeout. The report is ok only if
+// all checks pass.
+func (hc *HealthCheckerImpl) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range hc.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := hc.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+// run executes a single check, treating a missed deadline as a failure.
+func (hc *HealthCheckerImpl) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+	if err := check(ctx); err != nil {
+		return CheckResult{Status: StatusUnavailable, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusOK}
+}
+
+// AttachHandlers registers the health endpoints. They need no authentication.
+func (hc *HealthCheckerImpl) AttachHandlers(lister *echo.Echo) {
+	lister.GET("/healthz", hc.Live)
+	lister.GET("/readyz", hc.Readiness)
+}
+
+// Live handles GET /healthz.
+// Returns 200 while the process can serve requests.
+func (hc *HealthCheckerImpl) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readiness handles GET /readyz.
+// Returns 200 when every dependency is usable and 503 otherwise,
+// with the status of each dependency in the body.
+func (hc *HealthCheckerImpl) Readiness(c echo.Context) error {
+	report := hc.Ready(c.Request().Context())
+	if report.Status != StatusOK {
+		logging.FromContext(c.Request().Context()).Warn("not-ready", "checks", report.Checks)
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
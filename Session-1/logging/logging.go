@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+//
+// Structured Logging
+//
+// Every log line is a message plus key/value fields, written as JSON or
+// logfmt. The logger travels in the context: middleware stores one tagged
+// with the request ID, handlers add the IDs they work on with With, and
+// managers and the MongoClient log through FromContext, so every line of a
+// request can be found by its request_id.
+//
+
+// Supported output formats.
+const (
+	FormatJSON   = "json"   // One JSON object per line (default)
+	FormatLogfmt = "logfmt" // key=value pairs
+)
+
+// Names of the fields shared across packages.
+const (
+	KeyRequestID = "request_id"
+	KeyProjectID = "project_id"
+	KeyBuyerID   = "buyer_id"
+	KeySellerID  = "seller_id"
+	KeyBidID     = "bid_id"
+	KeyError     = "error"
+)
+
+// New returns a logger writing lines in format to w, dropping those below level.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatJSON, "":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatLogfmt:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, want %q or %q", format, FormatJSON, FormatLogfmt)
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error".
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lvl.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return lvl, fmt.Errorf("unknown log level %q, want debug, info, warn or error", level)
+	}
+	return lvl, nil
+}
+
+// SetDefault makes logger the one used when a context carries none.
+func SetDefault(logger *slog.Logger) {
+	slog.SetDefault(logger)
+}
+
+// Default returns the logger used outside of requests.
+func Default() *slog.Logger {
+	return slog.Default()
+}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return Default()
+}
+
+// With returns a copy of ctx whose logger adds the given key/value pairs
+// to every line, e.g. With(ctx, KeyProjectID, id).
+func With(ctx context.Context, args ...interface{}) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+// Err is the field for an error.
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
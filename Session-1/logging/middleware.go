@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// maxRequestIDLength bounds request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// Middleware gives every request a logger tagged with its request ID and
+// writes one access line per request once it has been handled.
+// The ID is taken from the X-Request-ID header when it is usable, or
+// generated, and is echoed back in the X-Request-ID response header.
+func Middleware(base *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = NewRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			logger := base.With(KeyRequestID, id)
+			c.SetRequest(req.WithContext(NewContext(req.Context(), logger)))
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(req.Context(), level, "request",
+				slog.String("method", req.Method),
+				slog.String("uri", redactURI(req.RequestURI)),
+				slog.String("route", c.Path()),
+				slog.Int("status", status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes_out", c.Response().Size),
+				slog.String("remote_ip", c.RealIP()),
+			)
+			return nil
+		}
+	}
+}
+
+// NewRequestID returns a random 128-bit ID in hex.
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// redactURI hides the value of an access_token query parameter, which event
+// streams accept in place of the Authorization header.
+func redactURI(uri string) string {
+	u, err := url.ParseRequestURI(uri)
+	if err != nil || !u.Query().Has("access_token") {
+		return uri
+	}
+	query := u.Query()
+	query.Set("access_token", "REDACTED")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// validRequestID accepts short IDs made of letters, digits and -_.:
+// so clients cannot inject arbitrary text into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
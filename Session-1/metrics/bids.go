@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+// Result label values for bids, retractions and auctions.
+const (
+	ResultAccepted = "accepted"
+	ResultRejected = "rejected"
+	ResultAwarded  = "awarded"
+	ResultUnsold   = "unsold"
+	ResultFailed   = "failed"
+)
+
+// InstrumentedBidManager is a bidManager.BidManager that counts the bids,
+// retractions and auctions handled by the manager it wraps.
+type InstrumentedBidManager struct {
+	bidManager.BidManager
+	metrics *Metrics
+}
+
+// InstrumentBidManager wraps bm so its outcomes are recorded in m.
+func InstrumentBidManager(bm bidManager.BidManager, m *Metrics) bidManager.BidManager {
+	return &InstrumentedBidManager{bm, m}
+}
+
+// DoBID counts the bid and records its amount when it is accepted.
+// Sealed bids carry no amount and are only counted.
+func (ib *InstrumentedBidManager) DoBID(ctx context.Context, projectID string, bid project.BID, version int) error {
+	err := ib.BidManager.DoBID(ctx, projectID, bid, version)
+	if err != nil {
+		ib.metrics.Bids.WithLabelValues(ResultRejected).Inc()
+		return err
+	}
+	ib.metrics.Bids.WithLabelValues(ResultAccepted).Inc()
+	if bid.Amount > 0 {
+		ib.metrics.BidAmounts.WithLabelValues().Observe(float64(bid.Amount))
+	}
+	return nil
+}
+
+// RetractBid counts the retraction.
+func (ib *InstrumentedBidManager) RetractBid(ctx context.Context, projectID, bidID, reason string) error {
+	err := ib.BidManager.RetractBid(ctx, projectID, bidID, reason)
+	result := ResultAccepted
+	if err != nil {
+		result = ResultRejected
+	}
+	ib.metrics.Retracts.WithLabelValues(result).Inc()
+	return err
+}
+
+// ComputeBID counts the auction under the strategy that priced it.
+// Failed auctions have no strategy label value; auctions whose bids missed
+// the reserve price are counted as unsold.
+func (ib *InstrumentedBidManager) ComputeBID(ctx context.Context, projectID string, version int) (bidManager.AuctionResult, error) {
+	result, err := ib.BidManager.ComputeBID(ctx, projectID, version)
+	if err != nil {
+		ib.metrics.Auctions.WithLabelValues(result.Strategy, ResultFailed).Inc()
+		return result, err
+	}
+	outcome := ResultAwarded
+	if result.Outcome == bidManager.OutcomeReserveNotMet {
+		outcome = ResultUnsold
+	}
+	ib.metrics.Auctions.WithLabelValues(result.Strategy, outcome).Inc()
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+//
+// Application Metrics
+//
+// Metrics holds every series the service exports on GET /metrics.
+// HTTP traffic is measured by Middleware; bidding and MongoDB operations by
+// wrapping the BidManager and MongoClient (see InstrumentBidManager and
+// InstrumentMongoClient), so the wrapped code stays unaware of metrics.
+//
+
+// UnmatchedRoute is the route label of requests that matched no route,
+// so scanners probing random URLs cannot create unbounded series.
+const UnmatchedRoute = "unmatched"
+
+// BidAmountBuckets are histogram buckets for bid amounts.
+var BidAmountBuckets = []float64{10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000, 500000, 1000000}
+
+// Metrics is the set of series exported by the service.
+type Metrics struct {
+	registry *Registry
+
+	HTTPRequests *CounterVec   // By method, route and status
+	HTTPDuration *HistogramVec // By method and route
+
+	Bids       *CounterVec   // Bid placements by result
+	BidAmounts *HistogramVec // Amounts of accepted bids
+	Retracts   *CounterVec   // Bid retractions by result
+	Auctions   *CounterVec   // Auctions computed by strategy and result
+
+	MongoDuration *HistogramVec // MongoClient calls by operation
+	MongoErrors   *CounterVec   // Failed MongoClient calls by operation
+}
+
+// New registers the service's metrics in a fresh Registry.
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		registry: r,
+
+		HTTPRequests: r.NewCounterVec("bidding_http_requests_total",
+			"HTTP requests handled, by method, route and status code.", "method", "route", "status"),
+		HTTPDuration: r.NewHistogramVec("bidding_http_request_duration_seconds",
+			"Time spent handling HTTP requests.", DefaultDurationBuckets, "method", "route"),
+
+		Bids: r.NewCounterVec("bidding_bids_total",
+			"Bids placed or amended, by result (accepted or rejected).", "result"),
+		BidAmounts: r.NewHistogramVec("bidding_bid_amount",
+			"Amounts of accepted bids.", BidAmountBuckets),
+		Retracts: r.NewCounterVec("bidding_bid_retractions_total",
+			"Bid retractions, by result (accepted or rejected).", "result"),
+		Auctions: r.NewCounterVec("bidding_auctions_computed_total",
+			"Auctions computed, by strategy and result (awarded or failed).", "strategy", "result"),
+
+		MongoDuration: r.NewHistogramVec("bidding_mongo_operation_duration_seconds",
+			"Time spent in MongoDB operations, by operation.", DefaultDurationBuckets, "operation"),
+		MongoErrors: r.NewCounterVec("bidding_mongo_operation_errors_total",
+			"MongoDB operations that failed, by operation.", "operation"),
+	}
+}
+
+// Registry returns the registry the metrics are written from.
+func (m *Metrics) Registry() *Registry {
+	return m.registry
+}
+
+// AttachHandlers registers GET /metrics. It needs no authentication.
+func (m *Metrics) AttachHandlers(lister *echo.Echo) {
+	lister.GET("/metrics", m.Handler)
+}
+
+// Handler handles GET /metrics.
+// Writes every series in the Prometheus text format.
+func (m *Metrics) Handler(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, ContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	_, err := m.registry.WriteTo(c.Response())
+	return err
+}
+
+// Middleware counts and times every request by its route pattern, e.g.
+// "/v1/projects/:id", rather than its URL. Errors are passed to the error
+// handler first so the recorded status is the one sent to the client.
+func (m *Metrics) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		route := c.Path()
+		if err == echo.ErrNotFound || err == echo.ErrMethodNotAllowed {
+			route = UnmatchedRoute
+		}
+		if err != nil {
+			c.Error(err)
+		}
+
+		method := c.Request().Method
+		status := strconv.Itoa(c.Response().Status)
+		m.HTTPRequests.WithLabelValues(method, route, status).Inc()
+		m.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
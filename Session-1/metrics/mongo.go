@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InstrumentedMongoClient is a util.MongoClient that times every database
+// operation of the client it wraps and counts the ones that fail.
+// A lookup that finds no document is not counted as a failure.
+type InstrumentedMongoClient struct {
+	util.MongoClient
+	metrics *Metrics
+}
+
+// InstrumentMongoClient wraps client so its operations are recorded in m.
+func InstrumentMongoClient(client util.MongoClient, m *Metrics) util.MongoClient {
+	return &InstrumentedMongoClient{client, m}
+}
+
+// observe records one operation that started at start and ended with err.
+func (ic *InstrumentedMongoClient) observe(operation string, start time.Time, err error) {
+	ic.metrics.MongoDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil && err != mongo.ErrNoDocuments {
+		ic.metrics.MongoErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// InsertData records the "insert" operation.
+func (ic *InstrumentedMongoClient) InsertData(ctx context.Context, dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error) {
+	start := time.Now()
+	result, err := ic.MongoClient.InsertData(ctx, dbName, collectionName, data)
+	ic.observe("insert", start, err)
+	return result, err
+}
+
+// UpdateOne records the "update" operation.
+func (ic *InstrumentedMongoClient) UpdateOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	start := time.Now()
+	result, err := ic.MongoClient.UpdateOne(ctx, dbName, collectionName, filter, update)
+	ic.observe("update", start, err)
+	return result, err
+}
+
+// UpsertOne records the "upsert" operation.
+func (ic *InstrumentedMongoClient) UpsertOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	start := time.Now()
+	result, err := ic.MongoClient.UpsertOne(ctx, dbName, collectionName, filter, update)
+	ic.observe("upsert", start, err)
+	return result, err
+}
+
+// DeleteOne records the "delete" operation.
+func (ic *InstrumentedMongoClient) DeleteOne(ctx context.Context, dbName, collectionName string, filter interface{}) (*mongo.DeleteResult, error) {
+	start := time.Now()
+	result, err := ic.MongoClient.DeleteOne(ctx, dbName, collectionName, filter)
+	ic.observe("delete", start, err)
+	return result, err
+}
+
+// FindObject records the "find_one" operation.
+func (ic *InstrumentedMongoClient) FindObject(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	start := time.Now()
+	err := ic.MongoClient.FindObject(ctx, dbName, collectionName, filter, result)
+	ic.observe("find_one", start, err)
+	return err
+}
+
+// FindObjects records the "find" operation.
+func (ic *InstrumentedMongoClient) FindObjects(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	start := time.Now()
+	err := ic.MongoClient.FindObjects(ctx, dbName, collectionName, filter, result)
+	ic.observe("find", start, err)
+	return err
+}
+
+// FindAllObjects records the "find_all" operation.
+func (ic *InstrumentedMongoClient) FindAllObjects(ctx context.Context, dbName, collectionName string, result interface{}, limit int64) error {
+	start := time.Now()
+	err := ic.MongoClient.FindAllObjects(ctx, dbName, collectionName, result, limit)
+	ic.observe("find_all", start, err)
+	return err
+}
+
+// FindObjectsWithOptions records the "find" operation.
+func (ic *InstrumentedMongoClient) FindObjectsWithOptions(ctx context.Context, dbName, collectionName string, filter interface{}, opts util.FindOptions, result interface{}) error {
+	start := time.Now()
+	err := ic.MongoClient.FindObjectsWithOptions(ctx, dbName, collectionName, filter, opts, result)
+	ic.observe("find", start, err)
+	return err
+}
+
+// CreateIndex records the "create_index" operation.
+func (ic *InstrumentedMongoClient) CreateIndex(ctx context.Context, dbName, collectionName string, index util.Index) error {
+	start := time.Now()
+	err := ic.MongoClient.CreateIndex(ctx, dbName, collectionName, index)
+	ic.observe("create_index", start, err)
+	return err
+}
+
+// Ping records the "ping" operation.
+func (ic *InstrumentedMongoClient) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := ic.MongoClient.Ping(ctx)
+	ic.observe("ping", start, err)
+	return err
+}
@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//
+// Registry
+//
+// A small implementation of counters and histograms written in the
+// Prometheus text exposition format (version 0.0.4), so the service can be
+// scraped without depending on the Prometheus client library.
+//
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultDurationBuckets are histogram buckets, in seconds, for latencies.
+var DefaultDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is a family of series that can write itself out.
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and writes them in registration order.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounterVec registers a counter family partitioned by labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{family: newFamily(name, help, labels)}
+	r.register(cv)
+	return cv
+}
+
+// NewHistogramVec registers a histogram family with the given upper bounds,
+// partitioned by labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	hv := &HistogramVec{family: newFamily(name, help, labels), buckets: bounds}
+	r.register(hv)
+	return hv
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes every registered family in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	err := buf.Flush()
+	return cw.n, err
+}
+
+// family is the state shared by counter and histogram vectors.
+type family struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]interface{} // Encoded label values -> *Counter or *Histogram
+}
+
+func newFamily(name, help string, labels []string) family {
+	return family{name: name, help: help, labels: labels, series: map[string]interface{}{}}
+}
+
+// get returns the series for values, creating it with create if needed.
+func (f *family) get(values []string, create func() interface{}) interface{} {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+	}
+	return s
+}
+
+// entry is one series of a family together with its encoded label values.
+type entry struct {
+	key    string
+	series interface{}
+}
+
+// sorted returns the series ordered by label values.
+func (f *family) sorted() []entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := make([]entry, 0, len(f.series))
+	for key, series := range f.series {
+		entries = append(entries, entry{key, series})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+func (f *family) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, kind)
+}
+
+// labelPairs formats the series key plus any extra name/value pairs as {a="x",...}.
+func (f *family) labelPairs(key string, extra ...string) string {
+	var pairs []string
+	if len(f.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, f.labels[i]+`="`+escapeLabel(value)+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec is a family of monotonically increasing counters.
+type CounterVec struct {
+	family
+}
+
+// Counter is one series of a CounterVec.
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// WithLabelValues returns the counter for the given label values, in the
+// order the labels were registered.
+func (cv *CounterVec) WithLabelValues(values ...string) *Counter {
+	return cv.get(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+// Inc adds one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+// Value returns the current count.
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (cv *CounterVec) write(w *bufio.Writer) {
+	cv.header(w, "counter")
+	for _, e := range cv.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", cv.name, cv.labelPairs(e.key), formatFloat(e.series.(*Counter).Value()))
+	}
+}
+
+// HistogramVec is a family of histograms sharing the same buckets.
+type HistogramVec struct {
+	family
+	buckets []float64 // Sorted upper bounds, without +Inf
+}
+
+// Histogram is one series of a HistogramVec.
+type Histogram struct {
+	buckets []float64 // Upper bounds shared with the family
+
+	mu     sync.Mutex
+	counts []uint64 // Observations per bucket, not cumulative; last is +Inf
+	count  uint64
+	sum    float64
+}
+
+// WithLabelValues returns the histogram for the given label values, in the
+// order the labels were registered.
+func (hv *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return hv.get(values, func() interface{} {
+		return &Histogram{buckets: hv.buckets, counts: make([]uint64, len(hv.buckets)+1)}
+	}).(*Histogram)
+}
+
+// Observe records v in the first bucket whose upper bound is at least v.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the total of all observed values.
+func (h *Histogram) Sum() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+func (hv *HistogramVec) write(w *bufio.Writer) {
+	hv.header(w, "histogram")
+	for _, e := range hv.sorted() {
+		h := e.series.(*Histogram)
+		h.mu.Lock()
+		var cumulative uint64
+		for i, n := range h.counts {
+			bound := math.Inf(1)
+			if i < len(hv.buckets) {
+				bound = hv.buckets[i]
+			}
+			cumulative += n
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, hv.labelPairs(e.key, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.name, hv.labelPairs(e.key), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.name, hv.labelPairs(e.key), h.count)
+		h.mu.Unlock()
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
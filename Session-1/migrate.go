@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/migrations"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
+)
+
+//
+// Admin Commands
+//
+// Arguments left after the flags name a command run instead of the server:
+//
+//	IBackendApplication [flags] migrate status
+//	IBackendApplication [flags] migrate up [--dry-run]
+//
+// Commands use the same configuration as the server and exit when done.
+//
+
+const migrateUsage = "usage: IBackendApplication [flags] migrate status|up [--dry-run]"
+
+// runCommand runs the command named by args and returns the exit code.
+func runCommand(conf config.Config, args []string) int {
+	if args[0] != "migrate" {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	mongoClient, err := connectDatabase(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to MongoDB:", err)
+		return 1
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	locker := scheduler.NewLocker(mongoClient, conf.DatabaseDetails.ProjectDBName, leaseOwner())
+	migrator := migrations.NewMigrator(mongoClient, conf.DatabaseDetails, locker, migrations.Options{})
+	return runMigrate(context.Background(), migrator, args[1:], os.Stdout, os.Stderr)
+}
+
+// runMigrate runs "migrate status" or "migrate up" with args and returns the exit code.
+func runMigrate(ctx context.Context, migrator migrations.Migrator, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(stderr, "could not read migrations:", err)
+			return 1
+		}
+		printStatuses(stdout, statuses)
+		return 0
+
+	case "up":
+		fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		dryRun := fs.Bool("dry-run", false, "list the pending migrations without applying them")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		statuses, err := migrator.Up(ctx, *dryRun)
+		printStatuses(stdout, statuses)
+		if err != nil {
+			logging.FromContext(ctx).Error("migrate-up-failed", logging.Err(err))
+			fmt.Fprintln(stderr, "migration failed:", err)
+			return 1
+		}
+		if len(statuses) == 0 {
+			fmt.Fprintln(stdout, "nothing to migrate")
+		}
+		return 0
+	}
+	fmt.Fprintln(stderr, migrateUsage)
+	return 2
+}
+
+// printStatuses writes statuses as a table.
+func printStatuses(w io.Writer, statuses []migrations.Status) {
+	if len(statuses) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tDESCRIPTION")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied() {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, state, appliedAt, s.Description)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// Schema Migrations
+//
+// A migration is a numbered step that brings the database forward: building
+// indexes, reshaping or backfilling documents. Steps run in version order and
+// each is recorded in the schema_migrations collection of ProjectDBName once
+// it has succeeded:
+//
+//	{"_id": 2, "description": "...", "applied_at": ISODate("...")}
+//
+// Only one instance migrates at a time. Up holds the "schema-migrations"
+// lease (see scheduler.Locker) while it runs, so replicas starting together
+// wait for the first one and then find nothing left to do. A step that fails,
+// or whose instance dies before recording it, runs again next time, so every
+// step must be safe to repeat.
+//
+
+// Collection is the collection of ProjectDBName recording applied migrations.
+const Collection = "schema_migrations"
+
+const (
+	leaseName    = "schema-migrations"
+	leaseTTL     = 10 * time.Minute // Renewed before every step
+	waitInterval = time.Second      // Between attempts to take the lease
+)
+
+// Migration is one versioned step.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db Database) error
+}
+
+// Database is the database a migration works on.
+type Database struct {
+	Client util.MongoClient
+	Names  config.DatabaseDetails
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version     int       `json:"version" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"applied_at,omitempty" bson:"applied_at"` // Zero while pending
+}
+
+// Applied reports whether the migration has run.
+func (s Status) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// Migrator applies migrations and reports on them.
+type Migrator interface {
+	// Status lists every known migration in version order, followed by any
+	// applied by a newer version of the application.
+	Status(ctx context.Context) ([]Status, error)
+
+	// Up applies the pending migrations in order and returns them. With
+	// dryRun it only returns them. It stops at the first failure, returning
+	// the migrations applied before it.
+	Up(ctx context.Context, dryRun bool) ([]Status, error)
+}
+
+// MigratorImpl is a Migrator recording migrations in MongoDB.
+type MigratorImpl struct {
+	db         Database
+	locker     scheduler.Locker
+	migrations []Migration
+	now        func() time.Time
+}
+
+// Options configures a Migrator.
+type Options struct {
+	Migrations []Migration      // Migrations to apply; nil applies All
+	Now        func() time.Time // Clock; nil uses time.Now
+}
+
+// NewMigrator creates a Migrator, holding a lease from locker while it runs.
+func NewMigrator(mongoClient util.MongoClient, names config.DatabaseDetails, locker scheduler.Locker,
+	opts Options) Migrator {
+	if opts.Migrations == nil {
+		opts.Migrations = All
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	sorted := append([]Migration(nil), opts.Migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &MigratorImpl{
+		db:         Database{Client: mongoClient, Names: names},
+		locker:     locker,
+		migrations: sorted,
+		now:        opts.Now,
+	}
+}
+
+// Status lists the migrations with the time each was applied.
+func (mi *MigratorImpl) Status(ctx context.Context) ([]Status, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("migrations-status")
+	defer log.Debug("migrations-status-completed")
+
+	var records []Status
+	err := mi.db.Client.FindObjectsWithOptions(ctx, mi.db.Names.ProjectDBName, Collection, nil,
+		util.FindOptions{Sort: bson.D{{Key: "_id", Value: 1}}}, &records)
+	if err != nil {
+		log.Error("mongo error reading migrations", logging.Err(err))
+		return nil, err
+	}
+	applied := make(map[int]Status, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	statuses := make([]Status, 0, len(mi.migrations))
+	for _, m := range mi.migrations {
+		status := Status{Version: m.Version, Description: m.Description}
+		if record, ok := applied[m.Version]; ok {
+			status.AppliedAt = record.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range records {
+		if _, unknown := applied[record.Version]; unknown {
+			statuses = append(statuses, record)
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations while holding the migrations lease.
+func (mi *MigratorImpl) Up(ctx context.Context, dryRun bool) ([]Status, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("migrations-up")
+	defer log.Debug("migrations-up-completed")
+
+	if dryRun {
+		pending, err := mi.pending(ctx)
+		if err != nil {
+			return nil, err
+		}
+		statuses := make([]Status, len(pending))
+		for i, m := range pending {
+			statuses[i] = Status{Version: m.Version, Description: m.Description}
+		}
+		return statuses, nil
+	}
+	if err := mi.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer mi.locker.Release(ctx, leaseName)
+
+	// Read what is pending only now, another instance may have just migrated
+	pending, err := mi.pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := []Status{}
+	for _, m := range pending {
+		if _, err := mi.locker.Acquire(ctx, leaseName, leaseTTL, mi.now()); err != nil {
+			return applied, err
+		}
+		log.Info("migration-started", "version", m.Version, "description", m.Description)
+		if err := m.Up(ctx, mi.db); err != nil {
+			log.Error("migration-failed", "version", m.Version, logging.Err(err))
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		status := Status{Version: m.Version, Description: m.Description, AppliedAt: mi.now().UTC()}
+		if _, err := mi.db.Client.InsertData(ctx, mi.db.Names.ProjectDBName, Collection, status); err != nil {
+			log.Error("mongo error recording migration", "version", m.Version, logging.Err(err))
+			return applied, err
+		}
+		log.Info("migration-applied", "version", m.Version)
+		applied = append(applied, status)
+	}
+	return applied, nil
+}
+
+// pending returns the known migrations that have not been applied, in order.
+func (mi *MigratorImpl) pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := mi.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for i, m := range mi.migrations {
+		if !statuses[i].Applied() {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// lock waits until this instance holds the migrations lease or ctx is done.
+func (mi *MigratorImpl) lock(ctx context.Context) error {
+	for {
+		acquired, err := mi.locker.Acquire(ctx, leaseName, leaseTTL, mi.now())
+		if err != nil || acquired {
+			return err
+		}
+		logging.FromContext(ctx).Info("waiting-for-migrations-lease")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
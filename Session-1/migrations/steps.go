@@ -0,0 +1,195 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// Migration Steps
+//
+// New steps are appended with the next version. Applied steps are never
+// edited or renumbered: fix a mistake with a new step instead.
+//
+
+// All lists the migrations of this version of the application.
+var All = []Migration{
+	{Version: 1, Description: "unique indexes on ids", Up: uniqueIndexes},
+	{Version: 2, Description: "secondary indexes for listings and lookups", Up: secondaryIndexes},
+	{Version: 3, Description: "backfill the status of projects created before statuses", Up: backfillStatus},
+	{Version: 4, Description: "backfill the bid count of projects created before bid counts", Up: backfillBidCount},
+	{Version: 5, Description: "expire idempotency keys", Up: expireIdempotencyKeys},
+	{Version: 6, Description: "backfill the version of projects created before versions", Up: backfillVersion},
+	{Version: 7, Description: "unset bid counts taken back to zero", Up: unsetZeroBidCounts},
+}
+
+// collection locates a collection.
+type collection struct {
+	db, name string
+}
+
+// uniqueIndexes stops two buyers, sellers, projects, bids of one project or
+// proxies of one buyer on one project sharing an ID. It fails while
+// duplicates exist; remove them and run it again.
+func uniqueIndexes(ctx context.Context, db Database) error {
+	names := db.Names
+	return createIndexes(ctx, db.Client, map[collection][]bson.D{
+		{names.BuyersDBName, names.CollectionName}:  {keys("id")},
+		{names.SellersDBName, names.CollectionName}: {keys("id")},
+		{names.ProjectDBName, names.CollectionName}: {keys("id")},
+		{names.BidsDBName, names.CollectionName}:    {keys("project_id", "id")},
+		{names.BidsDBName, project.ProxyCollection}: {keys("project_id", "buyer_id")},
+	}, true)
+}
+
+// secondaryIndexes supports listing projects by seller, status and end date
+// (see project.ListProjects) and finding the bids of a buyer.
+func secondaryIndexes(ctx context.Context, db Database) error {
+	names := db.Names
+	return createIndexes(ctx, db.Client, map[collection][]bson.D{
+		{names.ProjectDBName, names.CollectionName}: {
+			keys("seller_id"), keys("status", "end_date"), keys("tags"),
+		},
+		{names.BidsDBName, names.CollectionName}: {keys("buyer_id")},
+	}, false)
+}
+
+// backfillStatus stores the status such projects were already treated as
+// having, so that filtering by status finds them.
+func backfillStatus(ctx context.Context, db Database) error {
+	names := db.Names
+	missing := bson.M{"status": bson.M{"$exists": false}}
+	var projects []project.ProjectDetails
+	err := db.Client.FindObjectsWithOptions(ctx, names.ProjectDBName, names.CollectionName, missing,
+		util.FindOptions{Projection: bson.M{"id": 1}}, &projects)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		filter := bson.M{"id": p.ID, "status": bson.M{"$exists": false}}
+		_, err := db.Client.UpdateOne(ctx, names.ProjectDBName, names.CollectionName,
+			filter, bson.M{"$set": bson.M{"status": project.StatusOpen}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillBidCount counts the bids of projects without a bid count, so that
+// sorting by bid count orders them correctly.
+func backfillBidCount(ctx context.Context, db Database) error {
+	names := db.Names
+	var bids []project.BID
+	err := db.Client.FindObjectsWithOptions(ctx, names.BidsDBName, names.CollectionName, nil,
+		util.FindOptions{Projection: bson.M{"project_id": 1}}, &bids)
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, bid := range bids {
+		counts[bid.ProjectID]++
+	}
+	for projectID, count := range counts {
+		// Projects counting their bids already are left alone
+		filter := bson.M{"id": projectID, "bid_count": bson.M{"$exists": false}}
+		_, err := db.Client.UpdateOne(ctx, names.ProjectDBName, names.CollectionName,
+			filter, bson.M{"$set": bson.M{"bid_count": count}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillVersion gives such projects the version new projects start at, so
+// that they get an ETag like every other project.
+func backfillVersion(ctx context.Context, db Database) error {
+	names := db.Names
+	missing := bson.M{"version": bson.M{"$exists": false}}
+	var projects []project.ProjectDetails
+	err := db.Client.FindObjectsWithOptions(ctx, names.ProjectDBName, names.CollectionName, missing,
+		util.FindOptions{Projection: bson.M{"id": 1}}, &projects)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		// Projects written to meanwhile got a version from that write
+		filter := bson.M{"id": p.ID, "version": bson.M{"$exists": false}}
+		_, err := db.Client.UpdateOne(ctx, names.ProjectDBName, names.CollectionName,
+			filter, bson.M{"$set": bson.M{"version": 1}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsetZeroBidCounts removes the bid counts failed bids used to leave at
+// zero, so that sorting by bid count orders those projects with the ones that
+// never had a bid.
+func unsetZeroBidCounts(ctx context.Context, db Database) error {
+	names := db.Names
+	zero := bson.M{"bid_count": 0}
+	var projects []project.ProjectDetails
+	err := db.Client.FindObjectsWithOptions(ctx, names.ProjectDBName, names.CollectionName, zero,
+		util.FindOptions{Projection: bson.M{"id": 1}}, &projects)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		// Projects bid on meanwhile keep their count
+		filter := bson.M{"id": p.ID, "bid_count": 0}
+		_, err := db.Client.UpdateOne(ctx, names.ProjectDBName, names.CollectionName,
+			filter, bson.M{"$unset": bson.M{"bid_count": ""}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireIdempotencyKeys lets MongoDB remove idempotency records once they
+// expire, see the idempotency package.
+func expireIdempotencyKeys(ctx context.Context, db Database) error {
+	index := util.Index{Name: indexName(keys("expires_at")), Keys: keys("expires_at"), Expire: true}
+	return db.Client.CreateIndex(ctx, db.Names.ProjectDBName, idempotency.Collection, index)
+}
+
+// createIndexes creates the indexes of each collection with MongoDB's
+// default names, e.g. "status_1_end_date_1".
+func createIndexes(ctx context.Context, client util.MongoClient, indexes map[collection][]bson.D, unique bool) error {
+	for coll, all := range indexes {
+		for _, k := range all {
+			index := util.Index{Name: indexName(k), Keys: k, Unique: unique}
+			if err := client.CreateIndex(ctx, coll.db, coll.name, index); err != nil {
+				return fmt.Errorf("index %s on %s.%s: %w", index.Name, coll.db, coll.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// keys returns ascending index keys on fields.
+func keys(fields ...string) bson.D {
+	k := make(bson.D, len(fields))
+	for i, field := range fields {
+		k[i] = bson.E{Key: field, Value: 1}
+	}
+	return k
+}
+
+// indexName returns the name MongoDB gives an index on k by default.
+func indexName(k bson.D) string {
+	parts := make([]string, len(k))
+	for i, e := range k {
+		parts[i] = fmt.Sprintf("%s_%v", e.Key, e.Value)
+	}
+	return strings.Join(parts, "_")
+}
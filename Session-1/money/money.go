@@ -0,0 +1,135 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//
+// Money
+//
+// Amounts are exact integers in the minor unit of their currency: 1250 USD
+// is $12.50 and 1250 JPY is ¥1250. Currencies are ISO-4217 codes; exponents
+// lists the ones the system knows with the number of decimals of their
+// minor unit.
+//
+// Clients send an amount either as an integer of minor units, 1250, or as a
+// decimal string in major units, "12.50", which needs a currency and must
+// not have more decimals than it allows.
+//
+
+// Errors returned when an amount or currency cannot be used.
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("amount must be an integer of minor units or a decimal string")
+	ErrTooPrecise      = errors.New("amount has more decimals than its currency allows")
+	ErrNoCurrency      = errors.New("decimal amounts need a currency")
+)
+
+// exponents maps the known currencies to the decimals of their minor unit.
+var exponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "DKK": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "INR": 2, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "NOK": 2, "NZD": 2, "PLN": 2, "SEK": 2, "SGD": 2, "USD": 2,
+	"ZAR": 2,
+}
+
+// Money is an amount in minor units of a currency.
+type Money struct {
+	Amount   int    `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// Known reports whether code is a currency the system knows.
+func Known(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
+
+// Exponent returns the number of decimals of the currency's minor unit.
+func Exponent(code string) (int, error) {
+	exp, ok := exponents[code]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return exp, nil
+}
+
+// String formats m in major units, e.g. "12.50 USD".
+func (m Money) String() string {
+	exp, err := Exponent(m.Currency)
+	if err != nil || exp == 0 {
+		return strings.TrimSpace(strconv.Itoa(m.Amount) + " " + m.Currency)
+	}
+	sign, n := "", m.Amount
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	digits := strconv.Itoa(n)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	cut := len(digits) - exp
+	return sign + digits[:cut] + "." + digits[cut:] + " " + m.Currency
+}
+
+// ParseDecimal converts a decimal string in major units of currency, such
+// as "12.5", to minor units. The conversion is exact or fails.
+func ParseDecimal(s, currency string) (int, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || !digitsOnly(whole) || !digitsOnly(frac) {
+		return 0, ErrInvalidAmount
+	}
+	if len(frac) > exp {
+		return 0, ErrTooPrecise
+	}
+	n, err := strconv.Atoi(whole + frac + strings.Repeat("0", exp-len(frac)))
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	return n, nil
+}
+
+// DecodeAmount reads an amount sent under the "amount" key, or the legacy
+// "ammount" key when amount is empty. Decimal strings are read in currency.
+// It reports whether either key held a value.
+func DecodeAmount(amount, legacy json.RawMessage, currency string) (int, bool, error) {
+	raw := amount
+	if len(raw) == 0 {
+		raw = legacy
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, false, nil
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, true, ErrInvalidAmount
+		}
+		if currency == "" {
+			return 0, true, ErrNoCurrency
+		}
+		n, err := ParseDecimal(s, currency)
+		return n, true, err
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, true, ErrInvalidAmount
+	}
+	return n, true, nil
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+//
+// Exchange Rates
+//
+// A Converter turns an amount in one currency into another. RateTable is
+// the Converter read from a local rate file:
+//
+//	# 1 EUR buys 1.0850 USD
+//	[rates]
+//	"EUR/USD" = "1.0850"
+//	"GBP/USD" = "1.2700"
+//
+// Rates are decimal strings so they are exact. A pair also converts the
+// other way with the inverse rate. Results are rounded half up to the minor
+// unit of the target currency.
+//
+
+// ErrNoRate is returned when no rate converts between two currencies.
+var ErrNoRate = errors.New("no exchange rate between the currencies")
+
+// Converter converts amounts between currencies.
+type Converter interface {
+	// Convert returns m in the currency to. Converting to m's own currency returns m.
+	Convert(m Money, to string) (Money, error)
+}
+
+// NoRates converts nothing but a currency to itself.
+var NoRates Converter = RateTable{}
+
+type pair struct{ from, to string }
+
+// RateTable is a Converter holding fixed exchange rates.
+type RateTable map[pair]*big.Rat
+
+// NewRateTable builds a RateTable from rates such as {"EUR/USD": "1.0850"}.
+func NewRateTable(rates map[string]string) (RateTable, error) {
+	table := RateTable{}
+	for key, value := range rates {
+		from, to, ok := strings.Cut(key, "/")
+		if !ok || !Known(from) || !Known(to) || from == to {
+			return nil, fmt.Errorf("rate %q: want a pair of known currencies such as \"EUR/USD\"", key)
+		}
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("rate %q: %q is not a positive decimal", key, value)
+		}
+		table[pair{from, to}] = rate
+	}
+	return table, nil
+}
+
+// LoadRates reads a rate file, see the top of this file for its format.
+func LoadRates(path string) (RateTable, error) {
+	var file struct {
+		Rates map[string]string `toml:"rates"`
+	}
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return nil, fmt.Errorf("reading rates: %w", err)
+	}
+	return NewRateTable(file.Rates)
+}
+
+// Convert converts m to the currency to.
+func (t RateTable) Convert(m Money, to string) (Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+	fromExp, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toExp, err := Exponent(to)
+	if err != nil {
+		return Money{}, err
+	}
+	rate, ok := t[pair{m.Currency, to}]
+	if !ok {
+		inverse, ok := t[pair{to, m.Currency}]
+		if !ok {
+			return Money{}, ErrNoRate
+		}
+		rate = new(big.Rat).Inv(inverse)
+	}
+
+	// minor units of m -> major units -> major units of to -> minor units of to
+	r := new(big.Rat).SetInt64(int64(m.Amount))
+	r.Mul(r, rate)
+	r.Mul(r, new(big.Rat).SetFrac(pow10(toExp), pow10(fromExp)))
+	return Money{Amount: roundHalfUp(r), Currency: to}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundHalfUp rounds a non-negative r to the nearest integer, halves up.
+func roundHalfUp(r *big.Rat) int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return int(q.Int64())
+}
@@ -2,81 +2,566 @@ package bidManager
 
 import (
 	"context"
-	"math"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/money"
 	"github.com/21keshav/IBackendApplication/resources/project"
-	"github.com/golang/glog"
 )
 
+// ErrNoBids is returned by ComputeBID when a project has no bids to award.
+var ErrNoBids = errors.New("project has no bids")
+
+// ErrBidNotImproved is returned when a project's amendment policy only allows
+// improving a bid and the new amount does not rank better than the current one.
+var ErrBidNotImproved = errors.New("amendment must improve the bid")
+
+// maxBidAttempts bounds how often a bid change is retried after losing a
+// race with a concurrent change to the same bid.
+const maxBidAttempts = 3
+
 // BidManager defines the contract for bid-related operations.
 // It encapsulates the ability to place bids and compute the winning bid.
+// Database calls run under the ctx passed to each method.
 type BidManager interface {
-	// ComputeBID determines the buyer with the lowest bid for a given project.
-	ComputeBID(projectID string) (project.Buyer, error)
+	// ComputeBID runs the project's auction strategy and returns the winner
+	// together with the price they pay.
+	// The project is marked awarded so no further bids are accepted.
+	// Unless version is zero, the project must still have it or
+	// project.ErrVersionMismatch is returned.
+	ComputeBID(ctx context.Context, projectID string, version int) (AuctionResult, error)
+
+	// DoBID places a new bid for a given project, or amends the buyer's bid
+	// with the same ID. Every change is kept in the bid's revision history.
+	// Bids are rejected unless the project is open and inside its bidding window.
+	// Unless version is zero, the project must still have it or
+	// project.ErrVersionMismatch is returned.
+	DoBID(ctx context.Context, projectID string, bid project.BID, version int) error
+
+	// RetractBid withdraws a bid for the given reason. Retraction is final.
+	RetractBid(ctx context.Context, projectID, bidID, reason string) error
+
+	// SetProxyBid registers a buyer's hidden maximum on an ascending auction
+	// and raises their bid right away if they are outbid, see proxy.go.
+	SetProxyBid(ctx context.Context, projectID string, proxy project.ProxyBid) error
+
+	// CancelProxyBid stops raising the buyer's bid. Bids already placed stay.
+	CancelProxyBid(ctx context.Context, projectID, buyerID string) error
+
+	// RevealBid opens a bid on a sealed project during its reveal phase.
+	// amount and salt must match the commitment the bid was placed with.
+	RevealBid(ctx context.Context, projectID, bidID string, amount int, salt string) error
+}
 
-	// DoBID places a new bid for a given project.
-	DoBID(projectID string, bid project.BID) error
+// BidActivity is the Data of the bid.placed, bid.retracted, bid.revealed
+// and leader.changed events. Amount is left out while a sealed project's
+// amounts are secret; like a bid's, it is also written as "ammount".
+type BidActivity struct {
+	BidID    string `json:"bid_id"`
+	BuyerID  string `json:"buyer_id"`
+	Amount   int    `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Revision int    `json:"revision"`
+	Action   string `json:"action,omitempty"` // placed, amended, retracted or revealed; empty for leaders
+	Auto     bool   `json:"auto,omitempty"`   // Placed by the buyer's proxy bid
 }
 
 // BidManagerManagerImpl is the concrete implementation of the BidManager interface.
 // It relies on ProjectManager to interact with projects and buyers stored in the database.
 type BidManagerManagerImpl struct {
 	projectManager project.ProjectManager // Handles project & buyer persistence
-	ctx            context.Context        // Context for database operations
+	now            func() time.Time       // Clock used for bidding-window checks and award times
+	publisher      events.Publisher       // Receives bid, leader and award events
+	rates          money.Converter        // Converts bids to the project's currency, see currency.go
+}
+
+// Options configures a BidManager.
+type Options struct {
+	Now       func() time.Time // Clock; nil uses time.Now
+	Publisher events.Publisher // Receives bid, leader and award events; nil discards them
+	Rates     money.Converter  // Converts bids in other currencies; nil converts none
 }
 
 // NewBidManager initializes and returns a new BidManager instance.
 // It wires together the BidManager with a ProjectManager dependency.
-func NewBidManager(projectManager project.ProjectManager, ctx context.Context) BidManager {
+func NewBidManager(projectManager project.ProjectManager, opts Options) BidManager {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Publisher == nil {
+		opts.Publisher = events.Discard
+	}
+	if opts.Rates == nil {
+		opts.Rates = money.NoRates
+	}
 	return &BidManagerManagerImpl{
 		projectManager,
-		ctx,
+		opts.Now,
+		opts.Publisher,
+		opts.Rates,
+	}
+}
+
+// DoBID places or amends a bid after checking that the project is
+// currently accepting bids and that the bid, in the project's currency,
+// follows the project's price rules and amendment policy. Late bids extend
+// projects with a soft close in the same write, see softclose.go. Bids on sealed projects record their
+// commitment instead of an amount, and any amendment is allowed.
+func (bd *BidManagerManagerImpl) DoBID(ctx context.Context, projectID string, bid project.BID, version int) error {
+	log := logging.FromContext(ctx)
+	log.Debug("Do-bid-projects")
+	defer log.Debug("do-bid-completed")
+
+	currentProject, err := bd.acceptingProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if version != 0 && currentProject.Version != version {
+		return project.ErrVersionMismatch
+	}
+	if bid, err = bd.inProjectCurrency(currentProject, bid); err != nil {
+		log.Info("bid-rejected", logging.Err(err))
+		return err
+	}
+	if err := bd.checkPriceRules(ctx, projectID, currentProject, bid); err != nil {
+		log.Info("bid-rejected", logging.Err(err))
+		return err
+	}
+	leader := bd.leader(ctx, currentProject)
+	if err := bd.place(ctx, projectID, &currentProject, bid, false, version); err != nil {
+		return err
+	}
+	bd.runProxies(ctx, projectID, currentProject)
+	bd.publishLeader(ctx, currentProject, leader)
+	bd.buyNow(ctx, projectID, currentProject)
+	return nil
+}
+
+// place adds a placed or amended revision with bid's amount to the bid with
+// bid's ID, retrying lost races, and publishes it. auto marks revisions made
+// by a proxy bid; version is passed on to AddBidRevision. A late bid extends
+// currentProject in the same write, see softclose.go.
+func (bd *BidManagerManagerImpl) place(ctx context.Context, projectID string, currentProject *project.ProjectDetails,
+	bid project.BID, auto bool, version int) error {
+	var (
+		rev  project.BidRevision
+		cond project.BidCondition
+	)
+	add := func(bid project.BID) error {
+		cond = softClose(*currentProject, rev.At, version)
+		err := bd.projectManager.AddBidRevision(ctx, projectID, bid, rev, cond)
+		if err != project.ErrEndDateMoved {
+			return err
+		}
+		// Another late bid moved the end date first; extend from the new one
+		latest, err := bd.acceptingProject(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		*currentProject = latest
+		return project.ErrBidConflict
+	}
+	err := bd.retry(func() error {
+		rev = project.BidRevision{Revision: 1, Action: project.ActionPlaced, Amount: bid.Amount,
+			Currency: bid.Currency, Original: bid.Original, Auto: auto, At: bd.now()}
+		if currentProject.Sealed {
+			rev.Amount, rev.Commitment = 0, strings.ToLower(bid.Commitment)
+		}
+
+		current, err := bd.projectManager.GetBid(ctx, projectID, bid.ID)
+		switch err {
+		case project.ErrBidNotFound:
+			return add(bid)
+		case nil:
+		default:
+			return err
+		}
+
+		// Amending: only the buyer who placed the bid may change it
+		if current.BuyerID != bid.BuyerID {
+			return project.ErrBidTaken
+		}
+		if current.Status == project.BidRetracted {
+			return project.ErrBidRetracted
+		}
+		if !currentProject.Sealed {
+			if err := checkAmendment(*currentProject, current.Amount, bid.Amount); err != nil {
+				return err
+			}
+		}
+		rev.Revision, rev.Action = current.Revision+1, project.ActionAmended
+		return add(current)
+	})
+	if err != nil {
+		return err
 	}
+	bd.publish(events.BidPlaced, projectID, activity(bid, rev))
+	if !cond.ExtendTo.IsZero() {
+		bd.extended(ctx, projectID, currentProject, cond.ExtendTo)
+	}
+	return nil
 }
 
-// DoBID inserts or updates a bid for a project by delegating
-// the operation to the ProjectManager.
-func (bd *BidManagerManagerImpl) DoBID(projectID string, bid project.BID) error {
-	glog.Info("Do-bid-projects")
-	defer glog.Info("do-bid-completed")
+// RetractBid records a final retraction revision on a bid, keeping its amount.
+// A proxy bid raising the bid is cancelled with it.
+func (bd *BidManagerManagerImpl) RetractBid(ctx context.Context, projectID, bidID, reason string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("retract-bid")
+	defer log.Debug("retract-bid-completed")
+
+	currentProject, err := bd.acceptingProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	leader := bd.leader(ctx, currentProject)
+	var (
+		retracted project.BID
+		rev       project.BidRevision
+	)
+	err = bd.retry(func() error {
+		current, err := bd.projectManager.GetBid(ctx, projectID, bidID)
+		if err != nil {
+			return err
+		}
+		if current.Status == project.BidRetracted {
+			return project.ErrBidRetracted
+		}
+		retracted, rev = current, project.BidRevision{
+			Revision: current.Revision + 1,
+			Action:   project.ActionRetracted,
+			Amount:   current.Amount,
+			Reason:   reason,
+			At:       bd.now(),
+		}
+		return bd.projectManager.AddBidRevision(ctx, projectID, current, rev, project.BidCondition{})
+	})
+	if err != nil {
+		return err
+	}
+	bd.publish(events.BidRetracted, projectID, activity(retracted, rev))
+	bd.dropProxy(ctx, projectID, retracted)
+	bd.publishLeader(ctx, currentProject, leader)
+	return nil
+}
 
-	// Delegate updating the project with the new bid to ProjectManager
-	err := bd.projectManager.UpdateProject(projectID, bid)
+// RevealBid records a revealed revision carrying the bid's amount once
+// amount and salt are checked against its commitment.
+func (bd *BidManagerManagerImpl) RevealBid(ctx context.Context, projectID, bidID string, amount int, salt string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("reveal-bid")
+	defer log.Debug("reveal-bid-completed")
+
+	currentProject, err := bd.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if err := currentProject.AcceptingReveals(bd.now()); err != nil {
+		log.Info("reveal-rejected", logging.Err(err))
+		return err
+	}
+	var (
+		revealed project.BID
+		rev      project.BidRevision
+	)
+	err = bd.retry(func() error {
+		current, err := bd.projectManager.GetBid(ctx, projectID, bidID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case current.Status == project.BidRetracted:
+			return project.ErrBidRetracted
+		case current.Revealed:
+			return project.ErrBidRevealed
+		case !current.Opens(projectID, amount, salt):
+			return project.ErrRevealMismatch
+		}
+		revealed, rev = current, project.BidRevision{
+			Revision: current.Revision + 1,
+			Action:   project.ActionRevealed,
+			Amount:   amount,
+			At:       bd.now(),
+		}
+		return bd.projectManager.AddBidRevision(ctx, projectID, current, rev, project.BidCondition{})
+	})
+	if err != nil {
+		return err
+	}
+	revealedActivity := activity(revealed, rev)
+	revealedActivity.Amount = 0
+	bd.publish(events.BidRevealed, projectID, revealedActivity)
+	return nil
+}
+
+// acceptingProject returns the project if it is accepting bids right now.
+func (bd *BidManagerManagerImpl) acceptingProject(ctx context.Context, projectID string) (project.ProjectDetails, error) {
+	currentProject, err := bd.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return currentProject, err
+	}
+	if err := currentProject.AcceptingBids(bd.now()); err != nil {
+		logging.FromContext(ctx).Info("bid-rejected", logging.Err(err))
+		return currentProject, err
+	}
+	return currentProject, nil
+}
+
+// retry runs change until it stops failing with ErrBidConflict, at most maxBidAttempts times.
+func (bd *BidManagerManagerImpl) retry(change func() error) error {
+	var err error
+	for attempt := 0; attempt < maxBidAttempts; attempt++ {
+		if err = change(); err != project.ErrBidConflict {
+			return err
+		}
+	}
 	return err
 }
 
-// ComputeBID determines the winning buyer for a given project.
-// The winning buyer is the one who has placed the minimum bid.
+// checkAmendment applies the project's amendment policy to a change of a
+// bid's amount from previous to amount.
+func checkAmendment(currentProject project.ProjectDetails, previous, amount int) error {
+	if currentProject.AmendmentPolicy != project.AmendImproveOnly {
+		return nil
+	}
+	strategy, err := StrategyFor(currentProject.Strategy)
+	if err != nil {
+		return err
+	}
+	if !improves(strategy, previous, amount) {
+		return ErrBidNotImproved
+	}
+	return nil
+}
+
+// ComputeBID determines the winning buyer for a given project
+// using the auction strategy the project was created with.
 //
 // Steps:
-// 1. Retrieve the project details from ProjectManager.
-// 2. Iterate over all bids to find the lowest bid amount.
-// 3. Fetch and return the buyer associated with that minimum bid.
-func (bd *BidManagerManagerImpl) ComputeBID(projectID string) (project.Buyer, error) {
-	glog.Info("compute-projects")
-	defer glog.Info("compute-completed")
-
-	// Step 1: Get the project details
-	currentProject, err := bd.projectManager.GetProject(projectID)
+//  1. Retrieve the project and the latest active bid of each buyer;
+//     on sealed projects only revealed bids count, once the reveal phase is over.
+//  2. Rank the bids with the project's strategy and price the winner;
+//     without a bid reaching the reserve price the project is closed unawarded.
+//  3. Fetch the buyer associated with the winning bid; a buyer deleted since
+//     bidding does not stop the award, which is made from the bid.
+//  4. Move the project, open or closed, to awarded and record the award, in one write.
+func (bd *BidManagerManagerImpl) ComputeBID(ctx context.Context, projectID string, version int) (AuctionResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("compute-projects")
+	defer log.Debug("compute-completed")
+
+	// Step 1: Get the project details and the bids that take part
+	currentProject, err := bd.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	if version != 0 && currentProject.Version != version {
+		return AuctionResult{}, project.ErrVersionMismatch
+	}
+	status := currentProject.CurrentStatus()
+	if status != project.StatusOpen && status != project.StatusClosed {
+		return AuctionResult{}, project.ErrInvalidTransition
+	}
+	if currentProject.Sealed && !currentProject.RevealOver(bd.now()) {
+		return AuctionResult{}, project.ErrRevealPending
+	}
+	allBids, err := bd.projectManager.GetBids(ctx, projectID)
 	if err != nil {
-		return project.Buyer{}, err
+		return AuctionResult{}, err
+	}
+	bids := latestActiveBids(allBids)
+	if currentProject.Sealed {
+		bids = revealedBids(allBids)
+	}
+	if len(bids) == 0 {
+		return AuctionResult{}, ErrNoBids
+	}
+
+	// Step 2: Rank the bids and work out the clearing price
+	strategy, err := StrategyFor(currentProject.Strategy)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	ranked := strategy.Rank(bids)
+	reserve := currentProject.ReservePrice
+	if reserve > 0 && !reaches(strategy, ranked[0].Amount, reserve) {
+		return bd.closeUnawarded(ctx, projectID, status, version, AuctionResult{
+			ProjectID:  projectID,
+			Strategy:   strategy.Name(),
+			Outcome:    OutcomeReserveNotMet,
+			Currency:   currentProject.Currency,
+			RankedBids: ranked,
+		})
+	}
+	result := AuctionResult{
+		ProjectID:     projectID,
+		Strategy:      strategy.Name(),
+		Outcome:       OutcomeAwarded,
+		WinningBid:    ranked[0],
+		ClearingPrice: strategy.ClearingPrice(ranked),
+		Currency:      currentProject.Currency,
+		RankedBids:    ranked,
+	}
+	if reserve > 0 && !reaches(strategy, result.ClearingPrice, reserve) {
+		// The winner never pays less than the reserve, e.g. under second-price
+		result.ClearingPrice = reserve
 	}
 
-	// Step 2: Find the minimum bid
-	min := math.MaxInt64
-	var minBid project.BID
-	for _, bid := range currentProject.BIDS {
-		if bid.Amount < min {
-			min = bid.Amount
-			minBid = bid
+	// Step 3: Fetch the buyer corresponding to the winning bid
+	result.Winner, err = bd.projectManager.GetBuyer(ctx, result.WinningBid.BuyerID)
+	if err == project.ErrBuyerNotFound {
+		log.Warn("winning-buyer-not-found", logging.KeyBuyerID, result.WinningBid.BuyerID)
+		result.Winner, err = project.Buyer{ID: result.WinningBid.BuyerID}, nil
+	}
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// Step 4: Stop further bidding by moving the project to awarded
+	award := project.Award{
+		BuyerID:  result.WinningBid.BuyerID,
+		BidID:    result.WinningBid.ID,
+		Price:    result.ClearingPrice,
+		Strategy: result.Strategy,
+		Currency: result.Currency,
+		ClosedAt: bd.now(),
+	}
+	if err := bd.projectManager.AwardProject(ctx, projectID, award, version); err != nil {
+		return AuctionResult{}, err
+	}
+	result.ClosedAt = award.ClosedAt
+	bd.publish(events.ProjectAwarded, projectID, result)
+
+	return result, nil
+}
+
+// closeUnawarded closes the project without a winner, if it has version
+// unless that is zero, and publishes result.
+func (bd *BidManagerManagerImpl) closeUnawarded(ctx context.Context, projectID string, status project.Status,
+	version int, result AuctionResult) (AuctionResult, error) {
+	if status == project.StatusOpen {
+		closed := project.ProjectDetails{Status: project.StatusClosed, Version: version}
+		if err := bd.projectManager.UpdateProjectDetails(ctx, projectID, closed); err != nil {
+			return AuctionResult{}, err
 		}
 	}
+	result.ClosedAt = bd.now()
+	bd.publish(events.ProjectClosed, projectID, result)
+	return result, nil
+}
 
-	// Step 3: Fetch the buyer corresponding to the lowest bid
-	buyer, err := bd.projectManager.GetBuyer(minBid.BuyerID)
+// leader returns the bid currently leading currentProject's auction, or nil
+// when there is none. It is only looked up when events are published, and
+// never on sealed projects; failures are logged and treated as no leader.
+func (bd *BidManagerManagerImpl) leader(ctx context.Context, currentProject project.ProjectDetails) *BidActivity {
+	if bd.publisher == events.Discard || currentProject.Sealed {
+		return nil
+	}
+	strategy, err := StrategyFor(currentProject.Strategy)
+	if err != nil {
+		return nil
+	}
+	allBids, err := bd.projectManager.GetBids(ctx, currentProject.ID)
 	if err != nil {
-		return project.Buyer{}, err
+		logging.FromContext(ctx).Warn("leader-lookup-error", logging.Err(err))
+		return nil
+	}
+	bids := latestActiveBids(allBids)
+	if len(bids) == 0 {
+		return nil
+	}
+	best := strategy.Rank(bids)[0]
+	return &BidActivity{BidID: best.ID, BuyerID: best.BuyerID, Amount: best.Amount, Currency: best.Currency, Revision: best.Revision}
+}
+
+// publishLeader publishes a leader.changed event if the leading bid, or its
+// amount, differs from previous. Concurrent bids may make it miss or repeat a
+// change, but every event carries the leader at the time it was published.
+func (bd *BidManagerManagerImpl) publishLeader(ctx context.Context, currentProject project.ProjectDetails, previous *BidActivity) {
+	if bd.publisher == events.Discard {
+		return
+	}
+	current := bd.leader(ctx, currentProject)
+	if current == nil && previous == nil || current != nil && previous != nil && *current == *previous {
+		return
+	}
+	bd.publish(events.LeaderChanged, currentProject.ID, current)
+}
+
+func (bd *BidManagerManagerImpl) publish(eventType, projectID string, data interface{}) {
+	bd.publisher.Publish(events.Event{
+		Type:      eventType,
+		ProjectID: projectID,
+		Time:      bd.now(),
+		Data:      data,
+	})
+}
+
+// activity describes the change rev made to bid.
+func activity(bid project.BID, rev project.BidRevision) BidActivity {
+	return BidActivity{
+		BidID:    bid.ID,
+		BuyerID:  bid.BuyerID,
+		Amount:   rev.Amount,
+		Currency: bid.Currency,
+		Revision: rev.Revision,
+		Action:   rev.Action,
+		Auto:     rev.Auto,
 	}
+}
+
+// latestActiveBids keeps each buyer's most recently changed bid, ignoring
+// retracted ones. Ties are broken by revision and then bid ID.
+func latestActiveBids(bids []project.BID) []project.BID {
+	latest := map[string]project.BID{}
+	for _, bid := range bids {
+		if bid.Status == project.BidRetracted {
+			continue
+		}
+		current, ok := latest[bid.BuyerID]
+		if !ok || newer(bid, current) {
+			latest[bid.BuyerID] = bid
+		}
+	}
+	active := make([]project.BID, 0, len(latest))
+	for _, bid := range latest {
+		active = append(active, bid)
+	}
+	return active
+}
 
-	return buyer, nil
+// revealedBids is latestActiveBids for sealed projects. A buyer's bids are
+// ordered by when they were last committed, so the order of reveals does not
+// matter, and the latest one only takes part if it has been revealed.
+func revealedBids(bids []project.BID) []project.BID {
+	byID := map[string]project.BID{}
+	committed := make([]project.BID, 0, len(bids))
+	for _, bid := range bids {
+		byID[bid.ID] = bid
+		for _, rev := range bid.Revisions {
+			if rev.Action != project.ActionRevealed {
+				bid.UpdatedAt = rev.At
+			}
+		}
+		committed = append(committed, bid)
+	}
+	revealed := []project.BID{}
+	for _, bid := range latestActiveBids(committed) {
+		if bid.Revealed {
+			revealed = append(revealed, byID[bid.ID])
+		}
+	}
+	return revealed
+}
+
+func newer(a, b project.BID) bool {
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	if a.Revision != b.Revision {
+		return a.Revision > b.Revision
+	}
+	return a.ID > b.ID
 }
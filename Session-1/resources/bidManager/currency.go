@@ -0,0 +1,43 @@
+package bidManager
+
+import (
+	"encoding/json"
+
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Currencies
+//
+// DoBID converts a bid before any other check; sealed bids cannot be converted.
+//
+
+// inProjectCurrency returns bid with its amount in the project's currency.
+func (bd *BidManagerManagerImpl) inProjectCurrency(currentProject project.ProjectDetails, bid project.BID) (project.BID, error) {
+	if bid.Currency == "" || bid.Currency == currentProject.Currency {
+		bid.Currency, bid.Original = currentProject.Currency, nil
+		return bid, nil
+	}
+	if currentProject.CurrencyPolicy != project.CurrencyConvert || currentProject.Sealed {
+		return bid, project.ErrCurrencyMismatch
+	}
+	original := money.Money{Amount: bid.Amount, Currency: bid.Currency}
+	converted, err := bd.rates.Convert(original, currentProject.Currency)
+	if err != nil {
+		return bid, err
+	}
+	bid.Amount, bid.Currency, bid.Original = converted.Amount, converted.Currency, &original
+	return bid, nil
+}
+
+// activityFields is BidActivity without its JSON methods.
+type activityFields BidActivity
+
+// MarshalJSON writes the activity with its amount under "amount" and "ammount".
+func (a BidActivity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		activityFields
+		Legacy int `json:"ammount,omitempty"`
+	}{activityFields(a), a.Amount})
+}
@@ -0,0 +1,238 @@
+package bidManager
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Proxy Bidding
+//
+// On ascending auctions (where the highest bid wins) a buyer may register a
+// hidden maximum instead of bidding by hand. After every bid and every
+// proxy change the contest is resolved again:
+//
+//  1. Each buyer's value is the larger of their standing bid and their
+//     proxy maximum.
+//  2. The buyer with the highest value leads. Equal values go to the buyer
+//     who committed to it first (the time the bid was placed or the proxy
+//     was set), then to the lower buyer ID.
+//  3. A leading proxy bids the runner-up's value plus the project's
+//     increment, capped at its maximum, and never less than it already bid
+//     or the project's starting price.
+//  4. Every other proxy bids its full maximum, unless that would only tie
+//     the leading bid, which keeps the lead anyway, or stays below the
+//     starting price.
+//
+// Proxy bids follow the price rules like manual bids and are marked "auto".
+//
+
+// ErrProxyNotSupported is returned for proxy bids on sealed projects or on
+// auctions where the lowest bid wins.
+var ErrProxyNotSupported = errors.New("proxy bids are only supported on open ascending auctions")
+
+// raise is a bid a proxy has to place.
+type raise struct {
+	proxy  project.ProxyBid
+	amount int
+}
+
+// contender is a buyer's standing in a proxy contest.
+type contender struct {
+	buyerID string
+	current int       // Amount of the buyer's standing bid, 0 if none
+	value   int       // The most the buyer is committed to
+	since   time.Time // When the buyer committed to value
+	proxy   *project.ProxyBid
+}
+
+// SetProxyBid stores the buyer's proxy bid and resolves the project's contest.
+func (bd *BidManagerManagerImpl) SetProxyBid(ctx context.Context, projectID string, proxy project.ProxyBid) error {
+	log := logging.FromContext(ctx)
+	log.Debug("set-proxy-bid")
+	defer log.Debug("set-proxy-bid-completed")
+
+	currentProject, err := bd.acceptingProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if !supportsProxies(currentProject) {
+		return ErrProxyNotSupported
+	}
+	bid, err := bd.projectManager.GetBid(ctx, projectID, proxy.BidID)
+	switch {
+	case err == project.ErrBidNotFound:
+	case err != nil:
+		return err
+	case bid.BuyerID != proxy.BuyerID:
+		return project.ErrBidTaken
+	case bid.Status == project.BidRetracted:
+		return project.ErrBidRetracted
+	}
+	// A maximum no bid could be placed at is refused now rather than at every raise
+	maximum := project.BID{ID: proxy.BidID, BuyerID: proxy.BuyerID, Amount: proxy.Maximum}
+	if err := bd.checkPriceRules(ctx, projectID, currentProject, maximum); err != nil {
+		log.Info("proxy-bid-rejected", logging.Err(err))
+		return err
+	}
+
+	now := bd.now()
+	proxy.ProjectID, proxy.CreatedAt, proxy.UpdatedAt = projectID, now, now
+	if err := bd.projectManager.SetProxyBid(ctx, proxy); err != nil {
+		return err
+	}
+	leader := bd.leader(ctx, currentProject)
+	bd.runProxies(ctx, projectID, currentProject)
+	bd.publishLeader(ctx, currentProject, leader)
+	bd.buyNow(ctx, projectID, currentProject)
+	return nil
+}
+
+// CancelProxyBid removes the buyer's proxy bid.
+func (bd *BidManagerManagerImpl) CancelProxyBid(ctx context.Context, projectID, buyerID string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("cancel-proxy-bid")
+	defer log.Debug("cancel-proxy-bid-completed")
+
+	return bd.projectManager.DeleteProxyBid(ctx, projectID, buyerID)
+}
+
+// runProxies places the bids the project's proxies call for, lowest first,
+// each subject to the project's price rules like a manual bid. The bid that
+// triggered it has already been accepted, so failures are only logged.
+func (bd *BidManagerManagerImpl) runProxies(ctx context.Context, projectID string, currentProject project.ProjectDetails) {
+	if !supportsProxies(currentProject) {
+		return
+	}
+	log := logging.FromContext(ctx)
+	proxies, err := bd.projectManager.GetProxyBids(ctx, projectID)
+	if err != nil || len(proxies) == 0 {
+		if err != nil {
+			log.Warn("proxy-lookup-error", logging.Err(err))
+		}
+		return
+	}
+	allBids, err := bd.projectManager.GetBids(ctx, projectID)
+	if err != nil {
+		log.Warn("proxy-lookup-error", logging.Err(err))
+		return
+	}
+
+	raises := resolveProxies(currentProject, allBids, proxies)
+	sort.SliceStable(raises, func(i, j int) bool { return raises[i].amount < raises[j].amount })
+	for _, r := range raises {
+		bid := project.BID{ID: r.proxy.BidID, BuyerID: r.proxy.BuyerID, Amount: r.amount, Currency: currentProject.Currency}
+		err := bd.checkPriceRules(ctx, projectID, currentProject, bid)
+		if err == nil {
+			err = bd.place(ctx, projectID, &currentProject, bid, true, 0)
+		}
+		if err != nil {
+			log.Warn("proxy-bid-error", logging.KeyBuyerID, r.proxy.BuyerID, logging.Err(err))
+		}
+	}
+}
+
+// dropProxy cancels the proxy bid raising bid, if any, once bid is retracted.
+func (bd *BidManagerManagerImpl) dropProxy(ctx context.Context, projectID string, bid project.BID) {
+	proxy, err := bd.projectManager.GetProxyBid(ctx, projectID, bid.BuyerID)
+	if err != nil || proxy.BidID != bid.ID {
+		return
+	}
+	if err := bd.projectManager.DeleteProxyBid(ctx, projectID, bid.BuyerID); err != nil && err != project.ErrProxyNotFound {
+		logging.FromContext(ctx).Warn("proxy-cancel-error", logging.Err(err))
+	}
+}
+
+// resolveProxies works out the bids proxies must place, following the
+// rules at the top of this file.
+func resolveProxies(currentProject project.ProjectDetails, allBids []project.BID, proxies []project.ProxyBid) []raise {
+	retracted := map[string]bool{}
+	for _, bid := range allBids {
+		if bid.Status == project.BidRetracted {
+			retracted[bid.ID] = true
+		}
+	}
+
+	byBuyer := map[string]*contender{}
+	for _, bid := range latestActiveBids(allBids) {
+		byBuyer[bid.BuyerID] = &contender{buyerID: bid.BuyerID, current: bid.Amount, value: bid.Amount, since: bid.UpdatedAt}
+	}
+	for i := range proxies {
+		proxy := &proxies[i]
+		if retracted[proxy.BidID] {
+			continue
+		}
+		c, ok := byBuyer[proxy.BuyerID]
+		if !ok {
+			c = &contender{buyerID: proxy.BuyerID}
+			byBuyer[proxy.BuyerID] = c
+		}
+		c.proxy = proxy
+		if proxy.Maximum > c.value {
+			c.value, c.since = proxy.Maximum, proxy.UpdatedAt
+		}
+	}
+
+	contenders := make([]*contender, 0, len(byBuyer))
+	for _, c := range byBuyer {
+		contenders = append(contenders, c)
+	}
+	sort.Slice(contenders, func(i, j int) bool {
+		a, b := contenders[i], contenders[j]
+		if a.value != b.value {
+			return a.value > b.value
+		}
+		if !a.since.Equal(b.since) {
+			return a.since.Before(b.since)
+		}
+		return a.buyerID < b.buyerID
+	})
+
+	if len(contenders) == 0 {
+		return nil // Only proxies whose bids were retracted
+	}
+
+	var raises []raise
+	leader := contenders[0]
+	leading := leader.current
+	if leader.proxy != nil {
+		target := leader.value
+		if len(contenders) > 1 {
+			runnerUp := contenders[1].value
+			target = min(leader.value, runnerUp+currentProject.Increment(runnerUp))
+		} else if leader.current == 0 {
+			target = min(leader.value, currentProject.Increment(0))
+		} else {
+			target = leader.current
+		}
+		if target < currentProject.StartPrice {
+			target = currentProject.StartPrice
+		}
+		if target > leader.current && target <= leader.value {
+			raises = append(raises, raise{*leader.proxy, target})
+			leading = target
+		}
+	}
+	for _, c := range contenders[1:] {
+		if c.proxy != nil && c.proxy.Maximum > c.current && c.proxy.Maximum < leading &&
+			c.proxy.Maximum >= currentProject.StartPrice {
+			raises = append(raises, raise{*c.proxy, c.proxy.Maximum})
+		}
+	}
+	return raises
+}
+
+// supportsProxies reports whether proxy bids may be used on the project:
+// its bids must be public and the highest must win.
+func supportsProxies(currentProject project.ProjectDetails) bool {
+	if currentProject.Sealed {
+		return false
+	}
+	strategy, err := StrategyFor(currentProject.Strategy)
+	return err == nil && improves(strategy, 1, 2)
+}
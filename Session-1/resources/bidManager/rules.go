@@ -0,0 +1,110 @@
+package bidManager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Price Rules
+//
+// DoBID enforces a project's starting price and minimum increment, and
+// ends the auction as soon as the leading bid reaches the buy-it-now price.
+// ComputeBID only awards a bid that reaches the reserve price. See
+// project/rules.go for the rules themselves.
+//
+
+// Errors returned by DoBID for bids that break the project's price rules.
+var (
+	ErrStartPriceNotMet = errors.New("bid does not reach the project's starting price")
+	ErrIncrementNotMet  = errors.New("bid must beat the leading bid by at least the minimum increment")
+)
+
+// Auction outcomes reported in AuctionResult.Outcome.
+const (
+	OutcomeAwarded       = "awarded"         // The winning bid was awarded
+	OutcomeReserveNotMet = "reserve_not_met" // No bid reached the reserve price; the project was closed
+)
+
+// reaches reports whether amount is at least as good as target under strategy.
+func reaches(strategy AuctionStrategy, amount, target int) bool {
+	return amount == target || improves(strategy, target, amount)
+}
+
+// beating returns the amount that beats amount by increment under strategy.
+func beating(strategy AuctionStrategy, amount, increment int) int {
+	if improves(strategy, amount, amount+1) {
+		return amount + increment
+	}
+	return amount - increment
+}
+
+// checkPriceRules applies the project's starting price and minimum
+// increment to bid. Sealed projects have neither.
+func (bd *BidManagerManagerImpl) checkPriceRules(ctx context.Context, projectID string,
+	currentProject project.ProjectDetails, bid project.BID) error {
+	if currentProject.StartPrice == 0 && !currentProject.HasIncrement() {
+		return nil
+	}
+	strategy, err := StrategyFor(currentProject.Strategy)
+	if err != nil {
+		return err
+	}
+	if currentProject.StartPrice > 0 && !reaches(strategy, bid.Amount, currentProject.StartPrice) {
+		return ErrStartPriceNotMet
+	}
+	if !currentProject.HasIncrement() {
+		return nil
+	}
+	best, err := bd.leadingBid(ctx, projectID, strategy)
+	if err != nil || best == nil || best.BuyerID == bid.BuyerID {
+		return err
+	}
+	if !reaches(strategy, bid.Amount, beating(strategy, best.Amount, currentProject.Increment(best.Amount))) {
+		return ErrIncrementNotMet
+	}
+	return nil
+}
+
+// buyNow awards the project once its leading bid reaches the buy-it-now
+// price. The bid that got it there has already been accepted, so failures
+// are only logged.
+func (bd *BidManagerManagerImpl) buyNow(ctx context.Context, projectID string, currentProject project.ProjectDetails) {
+	if currentProject.BuyNowPrice == 0 || currentProject.Sealed {
+		return
+	}
+	log := logging.FromContext(ctx)
+	strategy, err := StrategyFor(currentProject.Strategy)
+	if err != nil {
+		return
+	}
+	best, err := bd.leadingBid(ctx, projectID, strategy)
+	if err != nil {
+		log.Warn("buy-now-lookup-error", logging.Err(err))
+		return
+	}
+	if best == nil || !reaches(strategy, best.Amount, currentProject.BuyNowPrice) {
+		return
+	}
+	log.Info("buy-now-reached", logging.KeyBidID, best.ID)
+	if _, err := bd.ComputeBID(ctx, projectID, 0); err != nil {
+		log.Warn("buy-now-award-error", logging.Err(err))
+	}
+}
+
+// leadingBid returns the project's leading bid under strategy, or nil when there is none.
+func (bd *BidManagerManagerImpl) leadingBid(ctx context.Context, projectID string, strategy AuctionStrategy) (*project.BID, error) {
+	allBids, err := bd.projectManager.GetBids(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	bids := latestActiveBids(allBids)
+	if len(bids) == 0 {
+		return nil, nil
+	}
+	best := strategy.Rank(bids)[0]
+	return &best, nil
+}
@@ -0,0 +1,38 @@
+package bidManager
+
+import (
+	"context"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Soft Close
+//
+// Late bids extend the end date in the same write that stores them, and
+// publish auction.extended.
+//
+
+// softClose returns the condition a bid revised at at is stored under: the
+// version the caller expects and, for a late bid, the extension of the
+// project's end date made in the same write.
+func softClose(currentProject project.ProjectDetails, at time.Time, version int) project.BidCondition {
+	cond := project.BidCondition{Version: version}
+	if end, ok := currentProject.ExtendedEnd(at); ok {
+		cond.EndDate, cond.ExtendTo = currentProject.EndDate, end
+	}
+	return cond
+}
+
+// extended records on currentProject the extension a stored late bid made
+// to end, and announces it.
+func (bd *BidManagerManagerImpl) extended(ctx context.Context, projectID string,
+	currentProject *project.ProjectDetails, end time.Time) {
+	currentProject.EndDate = end
+	currentProject.Extensions++
+	logging.FromContext(ctx).Info("auction-extended", "end_date", end, "extensions", currentProject.Extensions)
+	bd.publish(events.AuctionExtended, projectID, end)
+}
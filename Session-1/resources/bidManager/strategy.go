@@ -0,0 +1,157 @@
+package bidManager
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Auction Strategies
+//
+// A strategy decides how bids are ranked and what the winner pays.
+// Projects pick one by name through ProjectDetails.Strategy; projects
+// without one use the reverse (procurement) auction the system started with.
+//
+
+// Names of the built-in strategies.
+const (
+	StrategyReverse     = "reverse"      // Lowest bid wins and pays its own bid
+	StrategyFirstPrice  = "first-price"  // Highest bid wins and pays its own bid
+	StrategySecondPrice = "second-price" // Highest bid wins and pays the runner-up's bid (Vickrey)
+
+	// Aliases accepted for readability in project definitions.
+	StrategyLowestWins  = "lowest-wins"
+	StrategyHighestWins = "highest-wins"
+
+	DefaultStrategy = StrategyReverse
+)
+
+// ErrUnknownStrategy is returned when a project names a strategy that is not registered.
+var ErrUnknownStrategy = errors.New("unknown auction strategy")
+
+// AuctionStrategy ranks bids and prices the winning one.
+type AuctionStrategy interface {
+	// Name is the identifier projects use to select the strategy.
+	Name() string
+
+	// Rank returns the bids ordered best-first. It must not modify its input.
+	Rank(bids []project.BID) []project.BID
+
+	// ClearingPrice returns the amount the winner pays, given bids ranked by Rank.
+	ClearingPrice(ranked []project.BID) int
+}
+
+// AuctionResult is the outcome of computing a project's auction.
+type AuctionResult struct {
+	ProjectID     string        `json:"project_id"`
+	Strategy      string        `json:"strategy"`
+	Outcome       string        `json:"outcome"` // awarded or reserve_not_met, see rules.go
+	Winner        project.Buyer `json:"winner"`
+	WinningBid    project.BID   `json:"winning_bid"`
+	ClearingPrice int           `json:"clearing_price"`
+	Currency      string        `json:"currency,omitempty"` // Currency of ClearingPrice and the bids
+	RankedBids    []project.BID `json:"ranked_bids"`
+	ClosedAt      time.Time     `json:"closed_at"`
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]AuctionStrategy{}
+)
+
+func init() {
+	RegisterStrategy(reverseStrategy{})
+	RegisterStrategy(firstPriceStrategy{})
+	RegisterStrategy(secondPriceStrategy{})
+
+	strategies[StrategyLowestWins] = reverseStrategy{}
+	strategies[StrategyHighestWins] = firstPriceStrategy{}
+}
+
+// RegisterStrategy makes a strategy available under its Name,
+// replacing any strategy previously registered with that name.
+func RegisterStrategy(strategy AuctionStrategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[strategy.Name()] = strategy
+}
+
+// StrategyFor looks up a strategy by name. An empty name selects DefaultStrategy.
+func StrategyFor(name string) (AuctionStrategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return strategy, nil
+}
+
+// improves reports whether a bid of amount ranks strictly better than one of
+// previous under strategy.
+func improves(strategy AuctionStrategy, previous, amount int) bool {
+	if amount == previous {
+		return false
+	}
+	ranked := strategy.Rank([]project.BID{{ID: "new", Amount: amount}, {ID: "old", Amount: previous}})
+	return ranked[0].ID == "new"
+}
+
+// sortBids returns a copy of bids ordered by amount. Equal amounts are
+// ordered by bid ID so rankings are deterministic.
+func sortBids(bids []project.BID, highestFirst bool) []project.BID {
+	ranked := make([]project.BID, len(bids))
+	copy(ranked, bids)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Amount != ranked[j].Amount {
+			if highestFirst {
+				return ranked[i].Amount > ranked[j].Amount
+			}
+			return ranked[i].Amount < ranked[j].Amount
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked
+}
+
+// winningAmount is the pay-your-bid clearing price.
+func winningAmount(ranked []project.BID) int {
+	if len(ranked) == 0 {
+		return 0
+	}
+	return ranked[0].Amount
+}
+
+// reverseStrategy is a procurement auction: the cheapest offer wins.
+type reverseStrategy struct{}
+
+func (reverseStrategy) Name() string                           { return StrategyReverse }
+func (reverseStrategy) Rank(bids []project.BID) []project.BID  { return sortBids(bids, false) }
+func (reverseStrategy) ClearingPrice(ranked []project.BID) int { return winningAmount(ranked) }
+
+// firstPriceStrategy is a forward auction where the highest bidder pays their bid.
+type firstPriceStrategy struct{}
+
+func (firstPriceStrategy) Name() string                           { return StrategyFirstPrice }
+func (firstPriceStrategy) Rank(bids []project.BID) []project.BID  { return sortBids(bids, true) }
+func (firstPriceStrategy) ClearingPrice(ranked []project.BID) int { return winningAmount(ranked) }
+
+// secondPriceStrategy is a sealed Vickrey auction: the highest bidder wins
+// but pays the runner-up's bid. A sole bidder pays their own bid.
+type secondPriceStrategy struct{}
+
+func (secondPriceStrategy) Name() string                          { return StrategySecondPrice }
+func (secondPriceStrategy) Rank(bids []project.BID) []project.BID { return sortBids(bids, true) }
+func (secondPriceStrategy) ClearingPrice(ranked []project.BID) int {
+	if len(ranked) < 2 {
+		return winningAmount(ranked)
+	}
+	return ranked[1].Amount
+}
@@ -0,0 +1,222 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//
+// Idempotency Keys
+//
+// The first request with a key claims it with a fingerprint and a token, and
+// stores its response under it for retries. Records expire by a TTL index.
+//
+
+// Collection is the collection of ProjectDBName holding idempotency records.
+const Collection = "idempotency_keys"
+
+// DefaultTTL is how long responses are kept when no TTL is configured.
+const DefaultTTL = 24 * time.Hour
+
+// DefaultOperationTimeout is the request deadline assumed when none is
+// configured, matching controller.DefaultOperationTimeout.
+const DefaultOperationTimeout = 10 * time.Second
+
+// abandonMargin is how much longer than its request may take a claimed key
+// waits for its response before another request may claim it, e.g. after the
+// instance handling it died.
+const abandonMargin = 30 * time.Second
+
+// MaxKeyLength is the length of the longest key accepted.
+const MaxKeyLength = 255
+
+// Errors returned by Store.
+var (
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrKeyTooLong = errors.New("idempotency key must be at most 255 characters")
+	ErrNotClaimed = errors.New("idempotency key is no longer claimed")
+)
+
+// Response is a stored response, replayed to retries.
+type Response struct {
+	Status      int    `bson:"status"`
+	ContentType string `bson:"content_type,omitempty"`
+	Location    string `bson:"location,omitempty"`
+	ETag        string `bson:"etag,omitempty"`
+	Body        []byte `bson:"body,omitempty"`
+}
+
+// Record is the stored state of one idempotency key.
+type Record struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Token       string    `bson:"token"`
+	Response    *Response `bson:"response,omitempty"` // Nil while the first request is handled
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// Store keeps the responses of requests sent with an idempotency key.
+type Store interface {
+	// Begin claims key for a request with fingerprint. It returns the claim's
+	// token when the caller should handle the request and then Complete or
+	// Release the key with it, or the stored response of an earlier request
+	// to replay instead.
+	Begin(ctx context.Context, key, fingerprint string) (string, *Response, error)
+
+	// Complete stores the response to the request that claimed key with token.
+	Complete(ctx context.Context, key, token string, response Response) error
+
+	// Release gives up the claim on key made with token without a response,
+	// so that a retry is handled afresh.
+	Release(ctx context.Context, key, token string) error
+}
+
+// Options configures a Store.
+type Options struct {
+	TTL              time.Duration    // How long responses are kept; zero uses DefaultTTL
+	OperationTimeout time.Duration    // Deadline for handling one request; zero uses DefaultOperationTimeout
+	Now              func() time.Time // Clock; nil uses time.Now
+}
+
+// StoreImpl is a Store backed by MongoDB.
+type StoreImpl struct {
+	client       util.MongoClient
+	dbName       string
+	ttl          time.Duration
+	abandonAfter time.Duration // How long a claim may go without a response
+	now          func() time.Time
+}
+
+// NewStore creates a Store keeping responses in dbName.
+func NewStore(client util.MongoClient, dbName string, opts Options) Store {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.OperationTimeout <= 0 {
+		opts.OperationTimeout = DefaultOperationTimeout
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &StoreImpl{
+		client: client,
+		dbName: dbName,
+		ttl:    opts.TTL,
+		// The request and storing its response each get an operation timeout
+		abandonAfter: 2*opts.OperationTimeout + abandonMargin,
+		now:          opts.Now,
+	}
+}
+
+// Begin claims key, or returns the response stored under it.
+func (s *StoreImpl) Begin(ctx context.Context, key, fingerprint string) (string, *Response, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("idempotency-begin")
+	defer log.Debug("idempotency-begin-completed")
+
+	if len(key) > MaxKeyLength {
+		return "", nil, ErrKeyTooLong
+	}
+	token, err := newToken()
+	if err != nil {
+		log.Error("error creating idempotency claim token", logging.Err(err))
+		return "", nil, err
+	}
+	now := s.now()
+	claim := Record{Key: key, Fingerprint: fingerprint, Token: token, CreatedAt: now, ExpiresAt: now.Add(s.ttl)}
+
+	// The second attempt follows a record that expired or was abandoned
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.client.InsertData(ctx, s.dbName, Collection, claim)
+		if err == nil {
+			return token, nil, nil
+		}
+		if !util.IsDuplicateKey(err) {
+			log.Error("mongo error claiming idempotency key", logging.Err(err))
+			return "", nil, err
+		}
+
+		var existing Record
+		err = s.client.FindObject(ctx, s.dbName, Collection, bson.M{"_id": key}, &existing)
+		if err == mongo.ErrNoDocuments {
+			continue // Released meanwhile
+		}
+		if err != nil {
+			log.Error("mongo error finding idempotency key", logging.Err(err))
+			return "", nil, err
+		}
+		// TTL indexes remove expired records within a minute or so, not at once
+		expired := !now.Before(existing.ExpiresAt)
+		abandoned := existing.Response == nil && !now.Before(existing.CreatedAt.Add(s.abandonAfter))
+		if expired || abandoned {
+			_, err := s.client.DeleteOne(ctx, s.dbName, Collection,
+				bson.M{"_id": key, "token": existing.Token})
+			if err != nil {
+				log.Error("mongo error removing idempotency key", logging.Err(err))
+				return "", nil, err
+			}
+			continue
+		}
+		if existing.Fingerprint != fingerprint {
+			return "", nil, ErrKeyReused
+		}
+		if existing.Response == nil {
+			return "", nil, ErrInProgress
+		}
+		return "", existing.Response, nil
+	}
+	return "", nil, ErrInProgress
+}
+
+// Complete stores response under key if it is still claimed with token.
+func (s *StoreImpl) Complete(ctx context.Context, key, token string, response Response) error {
+	log := logging.FromContext(ctx)
+	log.Debug("idempotency-complete")
+	defer log.Debug("idempotency-complete-completed")
+
+	result, err := s.client.UpdateOne(ctx, s.dbName, Collection,
+		bson.M{"_id": key, "token": token, "response": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"response": response}})
+	if err != nil {
+		log.Error("mongo error storing idempotent response", logging.Err(err))
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotClaimed
+	}
+	return nil
+}
+
+// Release removes the unanswered claim on key made with token.
+func (s *StoreImpl) Release(ctx context.Context, key, token string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("idempotency-release")
+	defer log.Debug("idempotency-release-completed")
+
+	_, err := s.client.DeleteOne(ctx, s.dbName, Collection,
+		bson.M{"_id": key, "token": token, "response": bson.M{"$exists": false}})
+	if err != nil {
+		log.Error("mongo error releasing idempotency key", logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// newToken returns a random claim token.
+func newToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,334 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//
+// Bid Storage
+//
+// Bids live in their own collection, one document per bid holding its
+// current state and an append-only "revisions" history.
+//
+
+// BidStatus is the state of a bid.
+type BidStatus string
+
+const (
+	BidActive    BidStatus = "active"    // Taking part in the auction
+	BidRetracted BidStatus = "retracted" // Withdrawn by the buyer; final
+)
+
+// Revision actions recorded in BidRevision.Action.
+const (
+	ActionPlaced    = "placed"
+	ActionAmended   = "amended"
+	ActionRetracted = "retracted"
+	ActionRevealed  = "revealed" // A sealed bid's amount was revealed
+)
+
+// Amendment policies, chosen per project with ProjectDetails.AmendmentPolicy.
+const (
+	AmendAny         = "any"          // Amendments may change the amount freely (default)
+	AmendImproveOnly = "improve-only" // Amendments must rank better under the project's strategy
+)
+
+// Bid errors returned by ProjectManager and BidManager.
+var (
+	ErrBidConflict  = errors.New("bid was changed concurrently, retry")
+	ErrBidRetracted = errors.New("bid has been retracted")
+	ErrBidTaken     = errors.New("bid id is already used by another buyer")
+	ErrBidExists    = errors.New("a bid with this id already exists, amend it with PATCH")
+)
+
+// maxReviseAttempts bounds how often reviseProject rereads a project written concurrently.
+const maxReviseAttempts = 3
+
+// BidCondition is what AddBidRevision requires of the project, and the soft
+// close extension it makes in the same write.
+type BidCondition struct {
+	Version  int       // Unless zero, the version the project must still have
+	EndDate  time.Time // The end date the project must still have to be extended
+	ExtendTo time.Time // Unless zero, the end date an open project is moved to
+}
+
+// BidRevision is one entry in a bid's history.
+type BidRevision struct {
+	Revision   int          `json:"revision" bson:"revision"` // 1 when placed
+	Action     string       `json:"action" bson:"action"`
+	Amount     int          `json:"amount" bson:"ammount"` // Zero on sealed bids until revealed
+	Currency   string       `json:"currency,omitempty" bson:"currency,omitempty"`
+	Original   *money.Money `json:"original,omitempty" bson:"original,omitempty"`     // As bid, before conversion
+	Commitment string       `json:"commitment,omitempty" bson:"commitment,omitempty"` // Sealed projects only
+	Auto       bool         `json:"auto,omitempty" bson:"auto,omitempty"`             // Placed by a proxy bid
+	Reason     string       `json:"reason,omitempty" bson:"reason,omitempty"`         // Retractions only
+	At         time.Time    `json:"at" bson:"at"`
+}
+
+// GetBids returns every bid on a project, including retracted ones, ordered by bid ID.
+func (um *ProjectManagerImpl) GetBids(ctx context.Context, projectID string) ([]BID, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-bids")
+	defer log.Debug("pm-get-bids-completed")
+
+	if _, err := um.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+	bids := []BID{}
+	err := um.MongoClient.FindObjects(ctx, um.DBConfig.BidsDBName,
+		um.DBConfig.CollectionName, bson.M{"project_id": projectID}, &bids)
+	if err != nil {
+		log.Error("mongo error finding bids", logging.Err(err))
+		return nil, err
+	}
+	sort.Slice(bids, func(i, j int) bool { return bids[i].ID < bids[j].ID })
+	return bids, nil
+}
+
+// GetBid fetches a single bid with its history.
+func (um *ProjectManagerImpl) GetBid(ctx context.Context, projectID, bidID string) (BID, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-bid")
+	defer log.Debug("pm-get-bid-completed")
+
+	var bid BID
+	err := um.MongoClient.FindObject(ctx, um.DBConfig.BidsDBName,
+		um.DBConfig.CollectionName, bidFilter(projectID, bidID), &bid)
+	if err == mongo.ErrNoDocuments {
+		return bid, ErrBidNotFound
+	}
+	if err != nil {
+		log.Error("mongo error finding bid", logging.Err(err))
+		return bid, err
+	}
+	return bid, nil
+}
+
+// AddBidRevision appends rev to a bid's history and makes it the bid's current state.
+//
+// rev.Revision must be one more than the stored revision. Revision 1 creates
+// the bid from the ID, buyer and seller of bid. If the bid has moved on in the
+// meantime, or already exists when creating it, ErrBidConflict is returned.
+// Unless cond.Version is zero, the project must still have it or nothing is
+// written and ErrVersionMismatch is returned. Either way the project's version
+// is incremented, and creating a bid also counts it in the project's BidCount.
+// If cond.ExtendTo is set, the same write moves the end date there and counts
+// the extension; unless the project is still open, nothing is written and
+// ErrProjectNotOpen is returned, and unless its end date is still
+// cond.EndDate, ErrEndDateMoved. The project is written first; if the bid then
+// cannot be written, the project's changes are taken back.
+func (um *ProjectManagerImpl) AddBidRevision(ctx context.Context, projectID string, bid BID, rev BidRevision, cond BidCondition) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-add-bid-revision")
+	defer log.Debug("pm-add-bid-revision-completed")
+
+	if !validBidID(bid.ID) {
+		return ErrInvalidBidID
+	}
+	newBid := rev.Revision == 1
+	before, err := um.reviseProject(ctx, projectID, cond, newBid)
+	if err != nil {
+		return err
+	}
+	if err := um.writeBidRevision(ctx, projectID, bid, rev); err != nil {
+		um.unreviseProject(ctx, projectID, before, cond, newBid)
+		return err
+	}
+	return nil
+}
+
+// writeBidRevision writes rev to the bid's document, creating it for revision 1.
+func (um *ProjectManagerImpl) writeBidRevision(ctx context.Context, projectID string, bid BID, rev BidRevision) error {
+	log := logging.FromContext(ctx)
+	status := BidActive
+	if rev.Action == ActionRetracted {
+		status = BidRetracted
+	}
+
+	if rev.Revision == 1 {
+		created := BID{
+			ID:         bid.ID,
+			SellerID:   bid.SellerID,
+			BuyerID:    bid.BuyerID,
+			Amount:     rev.Amount,
+			Currency:   rev.Currency,
+			Original:   rev.Original,
+			Commitment: rev.Commitment,
+			ProjectID:  projectID,
+			Status:     status,
+			Revision:   rev.Revision,
+			CreatedAt:  rev.At,
+			UpdatedAt:  rev.At,
+			Revisions:  []BidRevision{rev},
+		}
+		result, err := um.MongoClient.UpsertOne(ctx, um.DBConfig.BidsDBName,
+			um.DBConfig.CollectionName, bidFilter(projectID, bid.ID), bson.M{"$setOnInsert": created})
+		if util.IsDuplicateKey(err) || err == nil && result.UpsertedCount == 0 {
+			return ErrBidConflict // Created concurrently, see the unique index in migrations
+		}
+		if err != nil {
+			log.Error("mongo error creating bid", logging.Err(err))
+			return err
+		}
+		return nil
+	}
+
+	filter := bidFilter(projectID, bid.ID)
+	filter["revision"] = rev.Revision - 1
+	set := bson.M{
+		"ammount":    rev.Amount,
+		"status":     status,
+		"revision":   rev.Revision,
+		"updated_at": rev.At,
+	}
+	if rev.Currency != "" {
+		set["currency"], set["original"] = rev.Currency, rev.Original
+	}
+	if rev.Reason != "" {
+		set["retract_reason"] = rev.Reason
+	}
+	if rev.Commitment != "" {
+		set["commitment"] = rev.Commitment
+	}
+	if rev.Action == ActionRevealed {
+		set["revealed"] = true
+	}
+	update := bson.M{"$set": set, "$push": bson.M{"revisions": rev}}
+	result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.BidsDBName,
+		um.DBConfig.CollectionName, filter, update)
+	if err != nil {
+		log.Error("mongo error updating bid", logging.Err(err))
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := um.GetBid(ctx, projectID, bid.ID); err != nil {
+			return err
+		}
+		return ErrBidConflict
+	}
+	return nil
+}
+
+// reviseProject increments the version of a project about to get a bid
+// revision, and its BidCount for a new bid, extends it as cond asks and
+// returns the project as it was before. The write is conditional on the
+// version read just before, which must be cond.Version unless that is zero,
+// so unreviseProject can take it back exactly.
+func (um *ProjectManagerImpl) reviseProject(ctx context.Context, projectID string, cond BidCondition, newBid bool) (ProjectDetails, error) {
+	for attempt := 0; attempt < maxReviseAttempts; attempt++ {
+		current, err := um.GetProject(ctx, projectID)
+		if err != nil {
+			return current, err
+		}
+		if cond.Version != 0 && current.Version != cond.Version {
+			return current, ErrVersionMismatch
+		}
+		filter := atVersion(projectID, current.Version)
+		inc := bson.M{}
+		update := bson.M{"$inc": inc}
+		if newBid {
+			inc["bid_count"] = 1
+		}
+		if !cond.ExtendTo.IsZero() {
+			switch {
+			case current.Status != StatusOpen:
+				return current, ErrProjectNotOpen
+			case !current.EndDate.Equal(cond.EndDate):
+				return current, ErrEndDateMoved
+			}
+			filter["status"], filter["end_date"] = StatusOpen, cond.EndDate
+			inc["extensions"] = 1
+			update["$set"] = bson.M{"end_date": cond.ExtendTo}
+		}
+		result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+			um.DBConfig.CollectionName, filter, versioned(update))
+		if err != nil {
+			logging.FromContext(ctx).Error("mongo error revising project", logging.Err(err))
+			return current, err
+		}
+		if result.MatchedCount > 0 {
+			return current, nil
+		}
+		// Written concurrently; read the project again
+	}
+	return ProjectDetails{}, ErrBidConflict
+}
+
+// unreviseProject puts back the fields reviseProject changed under cond, for
+// a bid revision that could not be written. Counts back at zero are unset
+// rather than stored, like on new projects, so that sorting by them orders
+// both alike. If the project was written to since, only the BidCount of a new
+// bid is taken back. Failures are only logged: the bid revision has already
+// failed.
+func (um *ProjectManagerImpl) unreviseProject(ctx context.Context, projectID string, before ProjectDetails, cond BidCondition, newBid bool) {
+	set, unset := bson.M{}, bson.M{}
+	restore := func(field string, value int) {
+		if value == 0 {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	restore("version", before.Version)
+	if newBid {
+		restore("bid_count", before.BidCount)
+	}
+	if !cond.ExtendTo.IsZero() {
+		restore("extensions", before.Extensions)
+		set["end_date"] = cond.EndDate
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, atVersion(projectID, before.Version+1), update)
+	if err == nil && result.MatchedCount == 0 && newBid {
+		err = um.uncountBid(ctx, projectID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("mongo error unrevising project", logging.Err(err))
+	}
+}
+
+// uncountBid takes one bid off the project's BidCount, unsetting it at zero.
+func (um *ProjectManagerImpl) uncountBid(ctx context.Context, projectID string) error {
+	for attempt := 0; attempt < maxReviseAttempts; attempt++ {
+		for _, step := range []struct{ filter, update bson.M }{
+			{bson.M{"id": projectID, "bid_count": 1}, bson.M{"$unset": bson.M{"bid_count": ""}}},
+			{bson.M{"id": projectID, "bid_count": bson.M{"$gt": 1}}, bson.M{"$inc": bson.M{"bid_count": -1}}},
+		} {
+			result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+				um.DBConfig.CollectionName, step.filter, versioned(step.update))
+			if err != nil || result.MatchedCount > 0 {
+				return err
+			}
+		}
+		// Counted or uncounted concurrently; try again
+	}
+	return ErrBidConflict
+}
+
+// bidFilter matches the document of one bid.
+func bidFilter(projectID, bidID string) bson.M {
+	return bson.M{"project_id": projectID, "id": bidID}
+}
+
+// validBidID reports whether id can be used as a bid ID.
+// IDs are used as keys, so they cannot be empty, contain dots or start with '$'.
+func validBidID(id string) bool {
+	return id != "" && !strings.Contains(id, ".") && !strings.HasPrefix(id, "$")
+}
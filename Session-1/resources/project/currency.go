@@ -0,0 +1,83 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/21keshav/IBackendApplication/money"
+)
+
+//
+// Currencies
+//
+// Amounts are minor units of the project's Currency. Bids in another
+// currency are rejected, or converted under CurrencyPolicy "convert".
+//
+
+// Currency policies, chosen per project with ProjectDetails.CurrencyPolicy.
+const (
+	CurrencyReject  = "reject"  // Bids in another currency are rejected (default)
+	CurrencyConvert = "convert" // Bids in another currency are converted to the project's
+)
+
+// Currency errors returned by ProjectManager and BidManager.
+var (
+	ErrCurrencyMismatch = errors.New("bid currency differs from the project's")
+	ErrCurrencyRequired = errors.New("converting bids needs a project currency")
+)
+
+// validateCurrency checks the project's currency and policy.
+func (p ProjectDetails) validateCurrency() error {
+	if p.Currency != "" && !money.Known(p.Currency) {
+		return money.ErrUnknownCurrency
+	}
+	if p.CurrencyPolicy == CurrencyConvert && p.Currency == "" {
+		return ErrCurrencyRequired
+	}
+	return nil
+}
+
+// bidFields is BID without its JSON methods.
+type bidFields BID
+
+// MarshalJSON writes the bid with its amount under "amount" and "ammount".
+func (b BID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		bidFields
+		Legacy int `json:"ammount,omitempty"`
+	}{bidFields(b), b.Amount})
+}
+
+// UnmarshalJSON reads a bid whose amount is under "amount" or "ammount", as
+// minor units or as a decimal string in the bid's currency. Fields missing
+// from data keep their value, so a bid can be patched in place.
+func (b *BID) UnmarshalJSON(data []byte) error {
+	body := struct {
+		*bidFields
+		Amount json.RawMessage `json:"amount"`
+		Legacy json.RawMessage `json:"ammount"`
+	}{bidFields: (*bidFields)(b)}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	amount, ok, err := money.DecodeAmount(body.Amount, body.Legacy, b.Currency)
+	if err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+	if ok {
+		b.Amount = amount
+	}
+	return nil
+}
+
+// revisionFields is BidRevision without its JSON methods.
+type revisionFields BidRevision
+
+// MarshalJSON writes the revision with its amount under "amount" and "ammount".
+func (r BidRevision) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		revisionFields
+		Legacy int `json:"ammount"`
+	}{revisionFields(r), r.Amount})
+}
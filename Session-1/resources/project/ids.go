@@ -0,0 +1,19 @@
+package project
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+//
+// Identifiers
+//
+// Clients may choose the IDs of the buyers, sellers, projects and bids they
+// create; the server generates one for any created without. Generated IDs are
+// MongoDB ObjectIDs in hex: 24 characters, unique across instances, that sort
+// in creation order to the second. A client-chosen ID already in use is
+// rejected (see ErrProjectExists, ErrBuyerExists, ErrSellerExists) by the
+// unique indexes of the migrations package.
+//
+
+// NewID returns a new unique identifier.
+func NewID() string {
+	return primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,125 @@
+package project
+
+import (
+	"errors"
+	"time"
+)
+
+//
+// Project Lifecycle
+//
+// A project moves through a small state machine:
+//
+//	draft -> open -> closed -> awarded
+//	  |       |        |
+//	  +-------+--------+----> cancelled
+//
+// Bids are only accepted while a project is open and the current time
+// falls inside its [StartDate, EndDate) bidding window.
+//
+
+// Status is the lifecycle state of a project.
+type Status string
+
+const (
+	StatusDraft     Status = "draft"     // Created but not yet published
+	StatusOpen      Status = "open"      // Accepting bids inside the bidding window
+	StatusClosed    Status = "closed"    // Bidding finished, no winner picked yet
+	StatusAwarded   Status = "awarded"   // Winner has been computed
+	StatusCancelled Status = "cancelled" // Withdrawn by the seller
+)
+
+// transitions lists the states each status may be moved to by hand. Only
+// AwardProject moves a project to awarded, together with its award.
+var transitions = map[Status][]Status{
+	StatusDraft:     {StatusOpen, StatusCancelled},
+	StatusOpen:      {StatusClosed, StatusCancelled},
+	StatusClosed:    {StatusCancelled},
+	StatusAwarded:   {},
+	StatusCancelled: {},
+}
+
+// closedStatuses are the states in which a project does not take bids.
+// Stored filters use them with $nin so legacy projects without a status still match.
+var closedStatuses = []Status{StatusDraft, StatusClosed, StatusAwarded, StatusCancelled}
+
+// finalStatuses are the states after bidding, in which a project is read-only.
+var finalStatuses = []Status{StatusClosed, StatusAwarded, StatusCancelled}
+
+// Lifecycle errors. Callers compare against these to decide how to respond.
+var (
+	ErrInvalidStatus      = errors.New("unknown project status")
+	ErrInvalidTransition  = errors.New("project status transition not allowed")
+	ErrInvalidWindow      = errors.New("project end date must be after start date")
+	ErrProjectNotOpen     = errors.New("project is not open for bidding")
+	ErrBiddingNotStarted  = errors.New("bidding window has not started yet")
+	ErrBiddingWindowEnded = errors.New("bidding window has ended")
+)
+
+// Valid reports whether s is one of the known lifecycle states.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a project in state s may move to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentStatus returns the project's lifecycle state. Projects stored
+// before statuses existed have none and are treated as open.
+func (p ProjectDetails) CurrentStatus() Status {
+	if p.Status == "" {
+		return StatusOpen
+	}
+	return p.Status
+}
+
+// Editable reports whether the project's details may still be changed.
+func (p ProjectDetails) Editable() bool {
+	status := p.CurrentStatus()
+	return status == StatusDraft || status == StatusOpen
+}
+
+// ValidateLifecycle checks that a new project starts in a sensible state
+// with a well-formed bidding window, soft close, price rules and currency.
+func (p ProjectDetails) ValidateLifecycle() error {
+	status := p.CurrentStatus()
+	if status != StatusDraft && status != StatusOpen {
+		return ErrInvalidStatus
+	}
+	if !p.StartDate.IsZero() && !p.EndDate.IsZero() && !p.EndDate.After(p.StartDate) {
+		return ErrInvalidWindow
+	}
+	if err := p.validateRevealWindow(); err != nil {
+		return err
+	}
+	if err := p.validateSoftClose(); err != nil {
+		return err
+	}
+	if err := p.validatePriceRules(); err != nil {
+		return err
+	}
+	return p.validateCurrency()
+}
+
+// AcceptingBids returns nil if a bid placed at now is allowed, or the
+// lifecycle error explaining why it is not.
+func (p ProjectDetails) AcceptingBids(now time.Time) error {
+	if p.CurrentStatus() != StatusOpen {
+		return ErrProjectNotOpen
+	}
+	if !p.StartDate.IsZero() && now.Before(p.StartDate) {
+		return ErrBiddingNotStarted
+	}
+	if !p.EndDate.IsZero() && !now.Before(p.EndDate) {
+		return ErrBiddingWindowEnded
+	}
+	return nil
+}
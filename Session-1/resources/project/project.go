@@ -2,12 +2,29 @@ package project
 
 import (
 	"context"
+	"errors"
 	"math"
 	"time"
 
 	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/money"
 	"github.com/21keshav/IBackendApplication/util"
-	"github.com/golang/glog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Lookup and write errors returned by ProjectManager.
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrBuyerNotFound   = errors.New("buyer not found")
+	ErrSellerNotFound  = errors.New("seller not found")
+	ErrBidNotFound     = errors.New("bid not found")
+	ErrProjectExists   = errors.New("a project with this id already exists")
+	ErrBuyerExists     = errors.New("a buyer with this id already exists")
+	ErrSellerExists    = errors.New("a seller with this id already exists")
+	ErrProjectReadOnly = errors.New("project can no longer be modified")
+	ErrInvalidBidID    = errors.New("bid id must be non-empty and contain no '.' or leading '$'")
 )
 
 //
@@ -15,73 +32,144 @@ import (
 //
 
 // ProjectDetails represents a project posted by a seller.
-// Each project can have multiple bids from buyers.
+// Each project can have multiple bids from buyers, stored separately (see bids.go).
+// The ID is generated by the server when a new project comes without one.
 type ProjectDetails struct {
-	ID        string         `json:"id,omitempty" bson:"id,omitempty"`
-	Details   []string       `json:"details,omitempty" bson:"details,omitempty"`   // Additional project details
-	SellerID  string         `json:"seller_id,omitempty" bson:"seller_id,omitempty"`
-	BIDS      map[string]BID `json:"bids,omitempty" bson:"bids,omitempty"`         // Keyed by Bid ID
-	startDate time.Duration  `json:"start_date,omitempty" bson:"start_date,omitempty"`
-	endDate   time.Duration  `json:"end_date,omitempty" bson:"end_date,omitempty"`
+	ID                  string    `json:"id,omitempty" bson:"id,omitempty" validate:"required,key,max=64"`
+	Details             []string  `json:"details,omitempty" bson:"details,omitempty" validate:"max=50"` // Additional project details
+	SellerID            string    `json:"seller_id,omitempty" bson:"seller_id,omitempty" validate:"required"`
+	Tags                []string  `json:"tags,omitempty" bson:"tags,omitempty" validate:"max=20"`                                                  // Listing labels
+	Status              Status    `json:"status,omitempty" bson:"status,omitempty" validate:"omitempty,oneof=draft open closed awarded cancelled"` // Lifecycle state
+	Strategy            string    `json:"strategy,omitempty" bson:"strategy,omitempty"`                                                            // Auction strategy name
+	AmendmentPolicy     string    `json:"amendment_policy,omitempty" bson:"amendment_policy,omitempty" validate:"omitempty,oneof=any improve-only"`
+	StartDate           time.Time `json:"start_date,omitempty" bson:"start_date,omitempty"`                                  // Zero opens bidding at once
+	EndDate             time.Time `json:"end_date,omitempty" bson:"end_date,omitempty"`                                      // Zero never closes bidding
+	Sealed              bool      `json:"sealed,omitempty" bson:"sealed,omitempty"`                                          // Commit-reveal bidding
+	RevealEndDate       time.Time `json:"reveal_end_date,omitempty" bson:"reveal_end_date,omitempty"`                        // Zero takes reveals until awarded
+	SoftCloseMinutes    int       `json:"soft_close_minutes,omitempty" bson:"soft_close_minutes,omitempty" validate:"min=0"` // Late-bid window before EndDate
+	ExtendMinutes       int       `json:"extend_minutes,omitempty" bson:"extend_minutes,omitempty" validate:"min=0"`         // Extension per late bid
+	HardCloseDate       time.Time `json:"hard_close_date,omitempty" bson:"hard_close_date,omitempty"`                        // Latest possible EndDate
+	Extensions          int       `json:"extensions,omitempty" bson:"extensions,omitempty"`
+	BidCount            int       `json:"bid_count,omitempty" bson:"bid_count,omitempty"`                                                  // Retracted bids included
+	BidIncrement        int       `json:"bid_increment,omitempty" bson:"bid_increment,omitempty" validate:"min=0"`                         // Smallest raise over the leader
+	BidIncrementPercent int       `json:"bid_increment_percent,omitempty" bson:"bid_increment_percent,omitempty" validate:"min=0,max=100"` // Smallest raise, in percent
+	StartPrice          int       `json:"start_price,omitempty" bson:"start_price,omitempty" validate:"min=0"`                             // Smallest first bid
+	ReservePrice        int       `json:"reserve_price,omitempty" bson:"reserve_price,omitempty" validate:"min=0"`                         // Smallest winning bid
+	ReserveHidden       bool      `json:"reserve_hidden,omitempty" bson:"reserve_hidden,omitempty"`                                        // Shown to the seller only
+	BuyNowPrice         int       `json:"buy_now_price,omitempty" bson:"buy_now_price,omitempty" validate:"min=0"`                         // Wins at once
+	Currency            string    `json:"currency,omitempty" bson:"currency,omitempty"`                                                    // ISO-4217 code
+	CurrencyPolicy      string    `json:"currency_policy,omitempty" bson:"currency_policy,omitempty" validate:"omitempty,oneof=reject convert"`
+	Award               *Award    `json:"award,omitempty" bson:"award,omitempty"`     // Set once awarded
+	Version             int       `json:"version,omitempty" bson:"version,omitempty"` // Incremented by every write
+}
+
+// Award records the outcome of a project's auction.
+type Award struct {
+	BuyerID  string    `json:"buyer_id" bson:"buyer_id"`
+	BidID    string    `json:"bid_id" bson:"bid_id"`
+	Price    int       `json:"price" bson:"price"` // Clearing price
+	Strategy string    `json:"strategy" bson:"strategy"`
+	Currency string    `json:"currency,omitempty" bson:"currency,omitempty"`
+	ClosedAt time.Time `json:"closed_at" bson:"closed_at"`
 }
 
 // BID represents a buyer's offer for a project.
+// ID, BuyerID and Amount, or Commitment on sealed projects, come from the buyer; the remaining fields are
+// maintained by the server and ignored on input. New bids without an ID get one from the server.
 type BID struct {
-	ID       string `json:"id,omitempty" bson:"id,omitempty"`
-	SellerID string `json:"seller_id,omitempty" bson:"seller_id,omitempty"`
-	BuyerID  string `json:"buyer_id,omitempty" bson:"buyer_id,omitempty"`
-	Amount   int    `json:"ammount,omitempty" bson:"ammount,omitempty"`
+	ID       string       `json:"id,omitempty" bson:"id,omitempty" validate:"required,key,max=64"`
+	SellerID string       `json:"seller_id,omitempty" bson:"seller_id,omitempty"`
+	BuyerID  string       `json:"buyer_id,omitempty" bson:"buyer_id,omitempty" validate:"required"`
+	Amount   int          `json:"amount,omitempty" bson:"ammount,omitempty" validate:"min=1"` // Minor units; also "ammount"
+	Currency string       `json:"currency,omitempty" bson:"currency,omitempty"`               // ISO-4217 code
+	Original *money.Money `json:"original,omitempty" bson:"original,omitempty"`               // As bid, before conversion
+
+	Commitment string `json:"commitment,omitempty" bson:"commitment,omitempty"` // Sealed amount hash
+	Revealed   bool   `json:"revealed,omitempty" bson:"revealed,omitempty"`
+
+	ProjectID     string        `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Status        BidStatus     `json:"status,omitempty" bson:"status,omitempty"`
+	Revision      int           `json:"revision,omitempty" bson:"revision,omitempty"` // Latest revision
+	RetractReason string        `json:"retract_reason,omitempty" bson:"retract_reason,omitempty"`
+	CreatedAt     time.Time     `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt     time.Time     `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	Revisions     []BidRevision `json:"revisions,omitempty" bson:"revisions,omitempty"` // Oldest first
 }
 
 // Seller represents a seller who can create projects.
+// The ID is generated by the server when a new seller comes without one.
 type Seller struct {
-	ID         string `json:"id,omitempty" bson:"id,omitempty"`
-	SellerID   string `json:"seller_id,omitempty" bson:"seller_id,omitempty"`
-	SellerName string `json:"seller_name,omitempty" bson:"seller_name,omitempty"`
+	ID         string `json:"id,omitempty" bson:"id,omitempty" validate:"required,key,max=64"`
+	SellerID   string `json:"seller_id,omitempty" bson:"seller_id,omitempty"` // Deprecated: always ID
+	SellerName string `json:"seller_name,omitempty" bson:"seller_name,omitempty" validate:"max=200"`
+
+	Password     string `json:"password,omitempty" bson:"-" validate:"omitempty,min=8,max=72"` // Input only, never stored
+	PasswordHash string `json:"-" bson:"password_hash,omitempty"`                              // bcrypt
 }
 
 // Buyer represents a buyer who can place bids on projects.
+// The ID is generated by the server when a new buyer comes without one.
 type Buyer struct {
-	ID        string `json:"id,omitempty" bson:"id,omitempty"`
-	BuyerID   string `json:"buyer_id,omitempty" bson:"buyer_id,omitempty"`
-	BuyerName string `json:"buyer_name,omitempty" bson:"buyer_name,omitempty"`
+	ID        string `json:"id,omitempty" bson:"id,omitempty" validate:"required,key,max=64"`
+	BuyerID   string `json:"buyer_id,omitempty" bson:"buyer_id,omitempty"` // Deprecated: always ID
+	BuyerName string `json:"buyer_name,omitempty" bson:"buyer_name,omitempty" validate:"max=200"`
+
+	Password     string `json:"password,omitempty" bson:"-" validate:"omitempty,min=8,max=72"` // Input only, never stored
+	PasswordHash string `json:"-" bson:"password_hash,omitempty"`                              // bcrypt
 }
 
-//
 // ProjectManager Interface
 //
 // Defines operations for managing projects, buyers, sellers, and bids.
 // This makes it easy to swap implementations (e.g., MongoDB vs mock for testing).
-//
+// Every method runs its database calls under ctx, normally the context of
+// the HTTP request being served.
 type ProjectManager interface {
-	CreateProject(projectDetails ProjectDetails) error
-	GetProjects() ([]ProjectDetails, error)
-	GetProject(projectID string) (ProjectDetails, error)
-	UpdateProject(projectID string, bid BID) error
+	CreateProject(ctx context.Context, projectDetails ProjectDetails) error
+	GetProjects(ctx context.Context) ([]ProjectDetails, error)
+	ListProjects(ctx context.Context, query ProjectQuery) (ProjectPage, error)
+	GetProject(ctx context.Context, projectID string) (ProjectDetails, error)
+	UpdateProjectStatus(ctx context.Context, projectID string, status Status) error
+	UpdateProjectDetails(ctx context.Context, projectID string, changes ProjectDetails) error
+	DeleteProject(ctx context.Context, projectID string, version int) error
+	GetExpiredProjects(ctx context.Context, now time.Time) ([]ProjectDetails, error)
+	AwardProject(ctx context.Context, projectID string, award Award, version int) error
+
+	GetBids(ctx context.Context, projectID string) ([]BID, error)
+	GetBid(ctx context.Context, projectID, bidID string) (BID, error)
+	AddBidRevision(ctx context.Context, projectID string, bid BID, rev BidRevision, cond BidCondition) error
 
-	CreateBuyer(buyer Buyer) error
-	GetBuyer(buyerID string) (Buyer, error)
+	SetProxyBid(ctx context.Context, proxy ProxyBid) error
+	GetProxyBid(ctx context.Context, projectID, buyerID string) (ProxyBid, error)
+	GetProxyBids(ctx context.Context, projectID string) ([]ProxyBid, error)
+	DeleteProxyBid(ctx context.Context, projectID, buyerID string) error
 
-	CreateSeller(seller Seller) error
+	CreateBuyer(ctx context.Context, buyer Buyer) error
+	GetBuyers(ctx context.Context) ([]Buyer, error)
+	GetBuyer(ctx context.Context, buyerID string) (Buyer, error)
+	UpdateBuyer(ctx context.Context, buyerID string, changes Buyer) error
+	DeleteBuyer(ctx context.Context, buyerID string) error
+
+	CreateSeller(ctx context.Context, seller Seller) error
+	GetSellers(ctx context.Context) ([]Seller, error)
+	GetSeller(ctx context.Context, sellerID string) (Seller, error)
+	UpdateSeller(ctx context.Context, sellerID string, changes Seller) error
+	DeleteSeller(ctx context.Context, sellerID string) error
 }
 
-//
 // ProjectManagerImpl
 //
 // Concrete implementation of ProjectManager backed by MongoDB.
 // Uses a generic MongoClient interface for all persistence operations.
-//
 type ProjectManagerImpl struct {
-	MongoClient util.MongoClient   // Mongo client wrapper
-	ctx         context.Context    // Context for DB operations
+	MongoClient util.MongoClient       // Mongo client wrapper
 	DBConfig    config.DatabaseDetails // Config (db/collection names)
 }
 
 // NewProjectManager creates a new ProjectManager backed by MongoDB.
-func NewProjectManager(mongoClient util.MongoClient, ctx context.Context, dbConfig config.DatabaseDetails) ProjectManager {
+func NewProjectManager(mongoClient util.MongoClient, dbConfig config.DatabaseDetails) ProjectManager {
 	return &ProjectManagerImpl{
 		MongoClient: mongoClient,
-		ctx:         ctx,
 		DBConfig:    dbConfig,
 	}
 }
@@ -91,127 +179,532 @@ func NewProjectManager(mongoClient util.MongoClient, ctx context.Context, dbConf
 //
 
 // CreateSeller inserts a new seller into the Sellers collection.
-func (um *ProjectManagerImpl) CreateSeller(seller Seller) error {
-	glog.Info("pm-create-seller")
-	defer glog.Info("pm-create-seller-completed")
+// A seller whose ID is taken is rejected with ErrSellerExists.
+func (um *ProjectManagerImpl) CreateSeller(ctx context.Context, seller Seller) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-create-seller")
+	defer log.Debug("pm-create-seller-completed")
 
-	_, err := um.MongoClient.InsertData(um.DBConfig.SellersDBName,
+	seller.SellerID = seller.ID // Kept for older clients reading it
+	_, err := um.MongoClient.InsertData(ctx, um.DBConfig.SellersDBName,
 		um.DBConfig.CollectionName, seller)
+	if util.IsDuplicateKey(err) {
+		return ErrSellerExists
+	}
 	if err != nil {
-		glog.Error("mongo error inserting seller", err)
+		log.Error("mongo error inserting seller", logging.Err(err))
 		return err
 	}
 	return nil
 }
 
+// GetSellers fetches all sellers.
+func (um *ProjectManagerImpl) GetSellers(ctx context.Context) ([]Seller, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-sellers")
+	defer log.Debug("pm-get-sellers-completed")
+
+	sellers := []Seller{}
+	err := um.MongoClient.FindAllObjects(ctx, um.DBConfig.SellersDBName,
+		um.DBConfig.CollectionName, &sellers, math.MaxInt32)
+	if err != nil {
+		log.Error("mongo error finding sellers", logging.Err(err))
+		return sellers, err
+	}
+	return sellers, nil
+}
+
+// GetSeller fetches a seller by ID.
+func (um *ProjectManagerImpl) GetSeller(ctx context.Context, sellerID string) (Seller, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-seller")
+	defer log.Debug("pm-get-seller-completed")
+
+	var seller Seller
+	err := um.MongoClient.FindObject(ctx, um.DBConfig.SellersDBName,
+		um.DBConfig.CollectionName, Seller{ID: sellerID}, &seller)
+	if err == mongo.ErrNoDocuments {
+		return seller, ErrSellerNotFound
+	}
+	if err != nil {
+		log.Error("mongo error finding seller", logging.Err(err))
+		return seller, err
+	}
+	return seller, nil
+}
+
+// UpdateSeller sets the non-empty fields of changes on an existing seller.
+// The seller's ID cannot be changed.
+func (um *ProjectManagerImpl) UpdateSeller(ctx context.Context, sellerID string, changes Seller) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-update-seller")
+	defer log.Debug("pm-update-seller-completed")
+
+	changes.ID, changes.SellerID = "", ""
+	if changes == (Seller{}) {
+		_, err := um.GetSeller(ctx, sellerID)
+		return err
+	}
+	return um.updateOne(ctx, um.DBConfig.SellersDBName, Seller{ID: sellerID}, changes, ErrSellerNotFound)
+}
+
+// DeleteSeller removes a seller by ID.
+func (um *ProjectManagerImpl) DeleteSeller(ctx context.Context, sellerID string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-delete-seller")
+	defer log.Debug("pm-delete-seller-completed")
+
+	return um.deleteOne(ctx, um.DBConfig.SellersDBName, Seller{ID: sellerID}, ErrSellerNotFound)
+}
+
 //
 // Buyer Operations
 //
 
 // CreateBuyer inserts a new buyer into the Buyers collection.
-func (um *ProjectManagerImpl) CreateBuyer(buyer Buyer) error {
-	glog.Info("pm-create-buyer")
-	defer glog.Info("pm-create-buyer-completed")
+// A buyer whose ID is taken is rejected with ErrBuyerExists.
+func (um *ProjectManagerImpl) CreateBuyer(ctx context.Context, buyer Buyer) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-create-buyer")
+	defer log.Debug("pm-create-buyer-completed")
 
-	_, err := um.MongoClient.InsertData(um.DBConfig.BuyersDBName,
+	buyer.BuyerID = buyer.ID // Kept for older clients reading it
+	_, err := um.MongoClient.InsertData(ctx, um.DBConfig.BuyersDBName,
 		um.DBConfig.CollectionName, buyer)
+	if util.IsDuplicateKey(err) {
+		return ErrBuyerExists
+	}
 	if err != nil {
-		glog.Error("mongo error inserting buyer", err)
+		log.Error("mongo error inserting buyer", logging.Err(err))
 		return err
 	}
 	return nil
 }
 
+// GetBuyers fetches all buyers.
+func (um *ProjectManagerImpl) GetBuyers(ctx context.Context) ([]Buyer, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-buyers")
+	defer log.Debug("pm-get-buyers-completed")
+
+	buyers := []Buyer{}
+	err := um.MongoClient.FindAllObjects(ctx, um.DBConfig.BuyersDBName,
+		um.DBConfig.CollectionName, &buyers, math.MaxInt32)
+	if err != nil {
+		log.Error("mongo error finding buyers", logging.Err(err))
+		return buyers, err
+	}
+	return buyers, nil
+}
+
 // GetBuyer fetches a buyer by ID.
-func (um *ProjectManagerImpl) GetBuyer(buyerID string) (Buyer, error) {
-	glog.Info("pm-get-buyer")
-	defer glog.Info("pm-get-buyer-completed")
+func (um *ProjectManagerImpl) GetBuyer(ctx context.Context, buyerID string) (Buyer, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-buyer")
+	defer log.Debug("pm-get-buyer-completed")
 
 	var buyer Buyer
-	err := um.MongoClient.FindObject(um.DBConfig.BuyersDBName,
+	err := um.MongoClient.FindObject(ctx, um.DBConfig.BuyersDBName,
 		um.DBConfig.CollectionName, Buyer{ID: buyerID}, &buyer)
+	if err == mongo.ErrNoDocuments {
+		return buyer, ErrBuyerNotFound
+	}
 	if err != nil {
-		glog.Error("mongo error finding buyer", err)
+		log.Error("mongo error finding buyer", logging.Err(err))
 		return buyer, err
 	}
 	return buyer, nil
 }
 
+// UpdateBuyer sets the non-empty fields of changes on an existing buyer.
+// The buyer's ID cannot be changed.
+func (um *ProjectManagerImpl) UpdateBuyer(ctx context.Context, buyerID string, changes Buyer) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-update-buyer")
+	defer log.Debug("pm-update-buyer-completed")
+
+	changes.ID, changes.BuyerID = "", ""
+	if changes == (Buyer{}) {
+		_, err := um.GetBuyer(ctx, buyerID)
+		return err
+	}
+	return um.updateOne(ctx, um.DBConfig.BuyersDBName, Buyer{ID: buyerID}, changes, ErrBuyerNotFound)
+}
+
+// DeleteBuyer removes a buyer by ID.
+func (um *ProjectManagerImpl) DeleteBuyer(ctx context.Context, buyerID string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-delete-buyer")
+	defer log.Debug("pm-delete-buyer-completed")
+
+	return um.deleteOne(ctx, um.DBConfig.BuyersDBName, Buyer{ID: buyerID}, ErrBuyerNotFound)
+}
+
 //
 // Project Operations
 //
 
 // CreateProject inserts a new project into the Projects collection.
-func (um *ProjectManagerImpl) CreateProject(projectDetails ProjectDetails) error {
-	glog.Info("pm-create-project")
-	defer glog.Info("pm-create-project-completed")
+// Projects without an explicit status are created open for bidding.
+// A project whose ID is taken is rejected with ErrProjectExists.
+func (um *ProjectManagerImpl) CreateProject(ctx context.Context, projectDetails ProjectDetails) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-create-project")
+	defer log.Debug("pm-create-project-completed")
 
-	_, err := um.MongoClient.InsertData(um.DBConfig.ProjectDBName,
+	if projectDetails.Status == "" {
+		projectDetails.Status = StatusOpen
+	}
+	projectDetails.Award = nil    // Only set by AwardProject
+	projectDetails.Extensions = 0 // Only counted by AddBidRevision
+	projectDetails.BidCount = 0   // Only counted by AddBidRevision
+	projectDetails.Version = 1    // Only incremented by later writes
+	if err := projectDetails.ValidateLifecycle(); err != nil {
+		log.Error("invalid project lifecycle", logging.Err(err))
+		return err
+	}
+
+	_, err := um.MongoClient.InsertData(ctx, um.DBConfig.ProjectDBName,
 		um.DBConfig.CollectionName, projectDetails)
+	if util.IsDuplicateKey(err) {
+		return ErrProjectExists
+	}
 	if err != nil {
-		glog.Error("mongo error inserting project", err)
+		log.Error("mongo error inserting project", logging.Err(err))
 		return err
 	}
 	return nil
 }
 
-// GetProjects fetches all projects.
-func (um *ProjectManagerImpl) GetProjects() ([]ProjectDetails, error) {
-	glog.Info("pm-get-projects")
-	defer glog.Info("pm-get-projects-completed")
+// GetProjects fetches all projects. The projection leaves out the legacy
+// "bids" array embedded in old project documents. See ListProjects to fetch
+// them a page at a time.
+func (um *ProjectManagerImpl) GetProjects(ctx context.Context) ([]ProjectDetails, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-projects")
+	defer log.Debug("pm-get-projects-completed")
 
 	var projects []ProjectDetails
-	err := um.MongoClient.FindAllObjects(um.DBConfig.ProjectDBName,
-		um.DBConfig.CollectionName, &projects, math.MaxInt32)
+	err := um.MongoClient.FindObjectsWithOptions(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, bson.M{}, util.FindOptions{Projection: bson.M{"bids": 0}}, &projects)
 	if err != nil {
-		glog.Error("mongo error finding projects", err)
+		log.Error("mongo error finding projects", logging.Err(err))
 		return projects, err
 	}
 	return projects, nil
 }
 
 // GetProject fetches a single project by ID.
-func (um *ProjectManagerImpl) GetProject(projectID string) (ProjectDetails, error) {
-	glog.Info("pm-get-project")
-	defer glog.Info("pm-get-project-completed")
+func (um *ProjectManagerImpl) GetProject(ctx context.Context, projectID string) (ProjectDetails, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-project")
+	defer log.Debug("pm-get-project-completed")
 
 	var projectDetails ProjectDetails
-	err := um.MongoClient.FindObject(um.DBConfig.ProjectDBName,
+	err := um.MongoClient.FindObject(ctx, um.DBConfig.ProjectDBName,
 		um.DBConfig.CollectionName, ProjectDetails{ID: projectID}, &projectDetails)
+	if err == mongo.ErrNoDocuments {
+		return projectDetails, ErrProjectNotFound
+	}
 	if err != nil {
-		glog.Error("mongo error finding project", err)
+		log.Error("mongo error finding project", logging.Err(err))
 		return projectDetails, err
 	}
 	return projectDetails, nil
 }
 
-// UpdateProject adds or updates a bid inside a project.
-// Loads the project, mutates its BIDS map, then updates it in DB.
-func (um *ProjectManagerImpl) UpdateProject(projectID string, bid BID) error {
-	glog.Info("pm-update-project")
-	defer glog.Info("pm-update-project-completed")
+// UpdateProjectStatus moves a project to a new lifecycle state.
+// The transition is checked against the current state, and the write is
+// conditional on that state so a concurrent transition cannot be overwritten.
+func (um *ProjectManagerImpl) UpdateProjectStatus(ctx context.Context, projectID string, status Status) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-update-project-status")
+	defer log.Debug("pm-update-project-status-completed")
 
-	// Fetch existing project
-	var projectDetails ProjectDetails
-	err := um.MongoClient.FindObject(um.DBConfig.ProjectDBName,
-		um.DBConfig.CollectionName, ProjectDetails{ID: projectID}, &projectDetails)
+	projectDetails, err := um.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	current := projectDetails.CurrentStatus()
+	if !current.CanTransitionTo(status) {
+		return ErrInvalidTransition
+	}
+
+	filter := bson.M{"id": projectID}
+	if projectDetails.Status != "" {
+		filter["status"] = projectDetails.Status
+	}
+	result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, filter, versioned(bson.M{"$set": bson.M{"status": status}}))
+	if err != nil {
+		log.Error("mongo error updating project status", logging.Err(err))
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Someone else moved the project on between our read and write.
+		return ErrInvalidTransition
+	}
+	return nil
+}
+
+// UpdateProjectDetails changes the editable fields of a project: details,
+// strategy, amendment and currency policies, price rules, bidding window,
+// soft close and reveal end date. Empty fields in changes are left untouched; the currency
+// itself is fixed once the project is created.
+// Only draft or open projects can be edited, and their strategy and price
+// rules only until the first bid (ErrTermsLocked). A Status in changes moves the
+// project through its lifecycle in the same write, as UpdateProjectStatus
+// would. A Version in changes is not written but required: if the project has
+// another version, nothing is written and ErrVersionMismatch is returned.
+func (um *ProjectManagerImpl) UpdateProjectDetails(ctx context.Context, projectID string, changes ProjectDetails) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-update-project-details")
+	defer log.Debug("pm-update-project-details-completed")
+
+	projectDetails, err := um.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	set := bson.M{}
+	terms := false // Strategy or price rules change
+	if changes.Details != nil {
+		set["details"] = changes.Details
+	}
+	if changes.Tags != nil {
+		set["tags"] = changes.Tags
+	}
+	if changes.Strategy != "" {
+		set["strategy"] = changes.Strategy
+		terms = true
+	}
+	if changes.AmendmentPolicy != "" {
+		set["amendment_policy"] = changes.AmendmentPolicy
+	}
+	if changes.CurrencyPolicy != "" {
+		set["currency_policy"] = changes.CurrencyPolicy
+		projectDetails.CurrencyPolicy = changes.CurrencyPolicy
+	}
+	if !changes.StartDate.IsZero() {
+		set["start_date"] = changes.StartDate
+		projectDetails.StartDate = changes.StartDate
+	}
+	if !changes.EndDate.IsZero() {
+		set["end_date"] = changes.EndDate
+		projectDetails.EndDate = changes.EndDate
+	}
+	if changes.BidIncrement != 0 {
+		set["bid_increment"] = changes.BidIncrement
+		projectDetails.BidIncrement = changes.BidIncrement
+		terms = true
+	}
+	if changes.BidIncrementPercent != 0 {
+		set["bid_increment_percent"] = changes.BidIncrementPercent
+		projectDetails.BidIncrementPercent = changes.BidIncrementPercent
+		terms = true
+	}
+	if changes.StartPrice != 0 {
+		set["start_price"] = changes.StartPrice
+		projectDetails.StartPrice = changes.StartPrice
+		terms = true
+	}
+	if changes.ReservePrice != 0 {
+		set["reserve_price"] = changes.ReservePrice
+		projectDetails.ReservePrice = changes.ReservePrice
+		terms = true
+	}
+	if changes.ReserveHidden {
+		set["reserve_hidden"] = true
+		projectDetails.ReserveHidden = true
+		terms = true
+	}
+	if changes.BuyNowPrice != 0 {
+		set["buy_now_price"] = changes.BuyNowPrice
+		projectDetails.BuyNowPrice = changes.BuyNowPrice
+		terms = true
+	}
+	if changes.SoftCloseMinutes != 0 {
+		set["soft_close_minutes"] = changes.SoftCloseMinutes
+		projectDetails.SoftCloseMinutes = changes.SoftCloseMinutes
+	}
+	if changes.ExtendMinutes != 0 {
+		set["extend_minutes"] = changes.ExtendMinutes
+		projectDetails.ExtendMinutes = changes.ExtendMinutes
+	}
+	if !changes.HardCloseDate.IsZero() {
+		set["hard_close_date"] = changes.HardCloseDate
+		projectDetails.HardCloseDate = changes.HardCloseDate
+	}
+	if !changes.RevealEndDate.IsZero() {
+		set["reveal_end_date"] = changes.RevealEndDate
+		projectDetails.RevealEndDate = changes.RevealEndDate
+	}
+	if changes.Version != 0 && changes.Version != projectDetails.Version {
+		return ErrVersionMismatch
+	}
+	filter := bson.M{"id": projectID, "status": bson.M{"$nin": finalStatuses}}
+	if len(set) > 0 {
+		if !projectDetails.Editable() {
+			return ErrProjectReadOnly
+		}
+		if err := projectDetails.ValidateLifecycle(); err != nil {
+			return err
+		}
+	}
+	if terms {
+		if projectDetails.BidCount > 0 {
+			return ErrTermsLocked
+		}
+		filter["bid_count"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	if changes.Status != "" {
+		if !projectDetails.CurrentStatus().CanTransitionTo(changes.Status) {
+			return ErrInvalidTransition
+		}
+		set["status"] = changes.Status
+		delete(filter, "status")
+		if projectDetails.Status != "" {
+			filter["status"] = projectDetails.Status
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	if changes.Version != 0 {
+		filter["version"] = changes.Version
+	}
+	result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, filter, versioned(bson.M{"$set": set}))
+	if err != nil {
+		log.Error("mongo error updating project details", logging.Err(err))
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if changes.Version != 0 {
+			// Finishing the project changes its version too
+			return ErrVersionMismatch
+		}
+		if changes.Status != "" {
+			return ErrInvalidTransition // Moved on between our read and write
+		}
+		if terms {
+			if current, err := um.GetProject(ctx, projectID); err == nil && current.Editable() {
+				return ErrTermsLocked // A bid arrived between our read and write
+			}
+		}
+		return ErrProjectReadOnly
+	}
+	return nil
+}
+
+// GetExpiredProjects returns the open projects whose bidding window ended at or before now.
+// Projects without an end date never expire; sealed projects expire once their reveal phase is over.
+func (um *ProjectManagerImpl) GetExpiredProjects(ctx context.Context, now time.Time) ([]ProjectDetails, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-expired-projects")
+	defer log.Debug("pm-get-expired-projects-completed")
+
+	projects := []ProjectDetails{}
+	filter := bson.M{
+		"status":   StatusOpen,
+		"end_date": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"sealed": bson.M{"$ne": true}},
+			{"reveal_end_date": bson.M{"$lte": now}},
+		},
+	}
+	err := um.MongoClient.FindObjects(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, filter, &projects)
 	if err != nil {
-		glog.Error("mongo error finding project", err)
+		log.Error("mongo error finding expired projects", logging.Err(err))
+		return projects, err
+	}
+	return projects, nil
+}
+
+// AwardProject moves an open or closed project to awarded and records the
+// award in one write, so a project is never left closed without an award.
+// The write is conditional on the project's status, so a project can only be
+// awarded once, and on version unless it is zero.
+func (um *ProjectManagerImpl) AwardProject(ctx context.Context, projectID string, award Award, version int) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-award-project")
+	defer log.Debug("pm-award-project-completed")
+
+	filter := bson.M{"id": projectID, "status": bson.M{"$in": []Status{StatusOpen, StatusClosed}}}
+	if version != 0 {
+		filter["version"] = version
+	}
+	update := versioned(bson.M{"$set": bson.M{"status": StatusAwarded, "award": award}})
+	result, err := um.MongoClient.UpdateOne(ctx, um.DBConfig.ProjectDBName,
+		um.DBConfig.CollectionName, filter, update)
+	if err != nil {
+		log.Error("mongo error awarding project", logging.Err(err))
 		return err
 	}
+	if result.MatchedCount == 0 {
+		current, err := um.GetProject(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		if version != 0 && current.Version != version {
+			return ErrVersionMismatch
+		}
+		return ErrInvalidTransition
+	}
+	return nil
+}
+
+// DeleteProject removes a project, if it has version unless that is zero.
+// Its bids are kept as history.
+func (um *ProjectManagerImpl) DeleteProject(ctx context.Context, projectID string, version int) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-delete-project")
+	defer log.Debug("pm-delete-project-completed")
+
+	filter := bson.M{"id": projectID}
+	if version != 0 {
+		filter["version"] = version
+	}
+	err := um.deleteOne(ctx, um.DBConfig.ProjectDBName, filter, ErrProjectNotFound)
+	if err == ErrProjectNotFound && version != 0 {
+		if _, err := um.GetProject(ctx, projectID); err != nil {
+			return err
+		}
+		return ErrVersionMismatch
+	}
+	return err
+}
+
+//
+// Shared helpers
+//
 
-	// Add/update the bid
-	if projectDetails.BIDS == nil {
-		projectDetails.BIDS = make(map[string]BID)
+// updateOne applies $set with changes to the single document matching filter,
+// returning notFound if nothing matched.
+func (um *ProjectManagerImpl) updateOne(ctx context.Context, dbName string, filter, changes interface{}, notFound error) error {
+	result, err := um.MongoClient.UpdateOne(ctx, dbName, um.DBConfig.CollectionName,
+		filter, bson.M{"$set": changes})
+	if err != nil {
+		logging.FromContext(ctx).Error("mongo error updating document", logging.Err(err))
+		return err
 	}
-	projectDetails.BIDS[bid.ID] = bid
+	if result.MatchedCount == 0 {
+		return notFound
+	}
+	return nil
+}
 
-	// Persist changes
-	_, err = um.MongoClient.UpdateOne(um.DBConfig.ProjectDBName,
-		um.DBConfig.CollectionName, ProjectDetails{ID: projectID}, projectDetails)
+// deleteOne removes the single document matching filter,
+// returning notFound if nothing was deleted.
+func (um *ProjectManagerImpl) deleteOne(ctx context.Context, dbName string, filter interface{}, notFound error) error {
+	result, err := um.MongoClient.DeleteOne(ctx, dbName, um.DBConfig.CollectionName, filter)
 	if err != nil {
-		glog.Error("mongo error updating project", err)
+		logging.FromContext(ctx).Error("mongo error deleting document", logging.Err(err))
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return notFound
+	}
 	return nil
 }
@@ -0,0 +1,124 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//
+// Proxy Bids
+//
+// A proxy bid is a buyer's secret maximum, kept in the "proxies" collection
+// of BidsDBName and raised on by bidManager/proxy.go.
+//
+
+// ProxyCollection is the collection of BidsDBName holding proxy bids.
+const ProxyCollection = "proxies"
+
+// ErrProxyNotFound is returned when a buyer has no proxy bid on a project.
+var ErrProxyNotFound = errors.New("proxy bid not found")
+
+// ProxyBid is a buyer's hidden maximum for a project. BidID names the bid
+// that is raised automatically; it must be the buyer's own.
+type ProxyBid struct {
+	ProjectID string    `json:"project_id" bson:"project_id"`
+	BuyerID   string    `json:"buyer_id" bson:"buyer_id" validate:"required"`
+	BidID     string    `json:"bid_id" bson:"bid_id" validate:"required,key,max=64"`
+	Maximum   int       `json:"maximum" bson:"maximum" validate:"min=1"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"` // When Maximum was last set; earlier proxies win ties
+}
+
+// SetProxyBid creates or replaces the buyer's proxy bid on a project.
+func (um *ProjectManagerImpl) SetProxyBid(ctx context.Context, proxy ProxyBid) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-set-proxy-bid")
+	defer log.Debug("pm-set-proxy-bid-completed")
+
+	if !validBidID(proxy.BidID) {
+		return ErrInvalidBidID
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"bid_id":     proxy.BidID,
+			"maximum":    proxy.Maximum,
+			"updated_at": proxy.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": proxy.CreatedAt},
+	}
+	_, err := um.MongoClient.UpsertOne(ctx, um.DBConfig.BidsDBName, ProxyCollection,
+		proxyFilter(proxy.ProjectID, proxy.BuyerID), update)
+	if util.IsDuplicateKey(err) {
+		// A concurrent upsert inserted the proxy first; this one now updates it
+		_, err = um.MongoClient.UpsertOne(ctx, um.DBConfig.BidsDBName, ProxyCollection,
+			proxyFilter(proxy.ProjectID, proxy.BuyerID), update)
+	}
+	if err != nil {
+		log.Error("mongo error setting proxy bid", logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// GetProxyBid fetches a buyer's proxy bid on a project.
+func (um *ProjectManagerImpl) GetProxyBid(ctx context.Context, projectID, buyerID string) (ProxyBid, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-proxy-bid")
+	defer log.Debug("pm-get-proxy-bid-completed")
+
+	var proxy ProxyBid
+	err := um.MongoClient.FindObject(ctx, um.DBConfig.BidsDBName, ProxyCollection,
+		proxyFilter(projectID, buyerID), &proxy)
+	if err == mongo.ErrNoDocuments {
+		return proxy, ErrProxyNotFound
+	}
+	if err != nil {
+		log.Error("mongo error finding proxy bid", logging.Err(err))
+		return proxy, err
+	}
+	return proxy, nil
+}
+
+// GetProxyBids returns every proxy bid on a project.
+func (um *ProjectManagerImpl) GetProxyBids(ctx context.Context, projectID string) ([]ProxyBid, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-get-proxy-bids")
+	defer log.Debug("pm-get-proxy-bids-completed")
+
+	proxies := []ProxyBid{}
+	err := um.MongoClient.FindObjects(ctx, um.DBConfig.BidsDBName, ProxyCollection,
+		bson.M{"project_id": projectID}, &proxies)
+	if err != nil {
+		log.Error("mongo error finding proxy bids", logging.Err(err))
+		return nil, err
+	}
+	return proxies, nil
+}
+
+// DeleteProxyBid removes a buyer's proxy bid; the bids it placed are kept.
+func (um *ProjectManagerImpl) DeleteProxyBid(ctx context.Context, projectID, buyerID string) error {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-delete-proxy-bid")
+	defer log.Debug("pm-delete-proxy-bid-completed")
+
+	result, err := um.MongoClient.DeleteOne(ctx, um.DBConfig.BidsDBName, ProxyCollection,
+		proxyFilter(projectID, buyerID))
+	if err != nil {
+		log.Error("mongo error deleting proxy bid", logging.Err(err))
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrProxyNotFound
+	}
+	return nil
+}
+
+func proxyFilter(projectID, buyerID string) bson.M {
+	return bson.M{"project_id": projectID, "buyer_id": buyerID}
+}
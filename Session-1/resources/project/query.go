@@ -0,0 +1,253 @@
+package project
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// Project Listing
+//
+// ListProjects pages with keyset cursors on (sort value, id), so writes
+// between two pages never move a project onto both or off both.
+//
+
+// Page sizes for ListProjects.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Sort keys for ProjectQuery.Sort. Prefix one with "-" to sort descending.
+const (
+	SortID       = "id"
+	SortEndDate  = "end_date"
+	SortBidCount = "bid_count"
+)
+
+// Listing errors returned by ListProjects.
+var (
+	ErrInvalidSort     = errors.New("sort must be id, end_date or bid_count, optionally prefixed with '-'")
+	ErrInvalidField    = errors.New("fields names an unknown project field")
+	ErrInvalidCursor   = errors.New("cursor is malformed or belongs to another sort")
+	ErrInvalidPageSize = errors.New("limit must be between 1 and 200")
+)
+
+// ProjectQuery selects, orders and pages the projects returned by ListProjects.
+// Zero fields do not filter.
+type ProjectQuery struct {
+	SellerID  string
+	Status    Status
+	Tag       string    // Projects carrying this tag
+	EndAfter  time.Time // Projects ending at or after this time
+	EndBefore time.Time // Projects ending before this time
+	Sort      string    // One of the Sort keys (default SortID)
+	Fields    []string  // JSON names of the fields to return (nil = all); id and the sort field are always returned
+	Cursor    string    // NextCursor of the previous page
+	Limit     int       // Page size (0 = DefaultPageSize)
+}
+
+// ProjectPage is one page of ListProjects.
+type ProjectPage struct {
+	Projects   []ProjectDetails
+	NextCursor string // Empty on the last page
+}
+
+// cursor is the decoded form of ProjectPage.NextCursor.
+type cursor struct {
+	Sort  string     `json:"s"`
+	ID    string     `json:"id"`
+	End   *time.Time `json:"end,omitempty"`   // Sort value for SortEndDate, nil when missing
+	Count *int       `json:"count,omitempty"` // Sort value for SortBidCount, nil when missing
+}
+
+// projectFields maps the JSON names of ProjectDetails fields to their BSON names.
+var projectFields = func() map[string]string {
+	fields := map[string]string{}
+	t := reflect.TypeOf(ProjectDetails{})
+	for i := 0; i < t.NumField(); i++ {
+		jsonName := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		bsonName := strings.Split(t.Field(i).Tag.Get("bson"), ",")[0]
+		fields[jsonName] = bsonName
+	}
+	return fields
+}()
+
+// ListProjects returns the page of projects selected by query.
+func (um *ProjectManagerImpl) ListProjects(ctx context.Context, query ProjectQuery) (ProjectPage, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("pm-list-projects")
+	defer log.Debug("pm-list-projects-completed")
+
+	page := ProjectPage{Projects: []ProjectDetails{}}
+	field, descending, err := parseSort(query.Sort)
+	if err != nil {
+		return page, err
+	}
+	sortKey := field
+	if descending {
+		sortKey = "-" + field
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit < 1 || limit > MaxPageSize {
+		return page, ErrInvalidPageSize
+	}
+	projection, err := projectionFor(query.Fields, field)
+	if err != nil {
+		return page, err
+	}
+
+	conditions := []bson.M{}
+	if query.SellerID != "" {
+		conditions = append(conditions, bson.M{"seller_id": query.SellerID})
+	}
+	if query.Status != "" {
+		conditions = append(conditions, bson.M{"status": query.Status})
+	}
+	if query.Tag != "" {
+		conditions = append(conditions, bson.M{"tags": query.Tag})
+	}
+	if !query.EndAfter.IsZero() {
+		conditions = append(conditions, bson.M{"end_date": bson.M{"$gte": query.EndAfter}})
+	}
+	if !query.EndBefore.IsZero() {
+		conditions = append(conditions, bson.M{"end_date": bson.M{"$lt": query.EndBefore}})
+	}
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor, sortKey)
+		if err != nil {
+			return page, err
+		}
+		conditions = append(conditions, after.condition(field, descending))
+	}
+	filter := bson.M{}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+
+	direction := 1
+	if descending {
+		direction = -1
+	}
+	sort := bson.D{{Key: "id", Value: 1}}
+	if field != SortID {
+		sort = bson.D{{Key: field, Value: direction}, {Key: "id", Value: 1}}
+	} else if descending {
+		sort = bson.D{{Key: "id", Value: -1}}
+	}
+
+	// Fetch one project more than the page holds to learn whether another page follows
+	err = um.MongoClient.FindObjectsWithOptions(ctx, um.DBConfig.ProjectDBName, um.DBConfig.CollectionName,
+		filter, util.FindOptions{Projection: projection, Sort: sort, Limit: int64(limit + 1)}, &page.Projects)
+	if err != nil {
+		log.Error("mongo error listing projects", logging.Err(err))
+		return page, err
+	}
+	if len(page.Projects) > limit {
+		page.Projects = page.Projects[:limit]
+		page.NextCursor = encodeCursor(sortKey, page.Projects[limit-1])
+	}
+	return page, nil
+}
+
+// parseSort splits a sort key into its field and direction.
+func parseSort(key string) (string, bool, error) {
+	descending := strings.HasPrefix(key, "-")
+	field := strings.TrimPrefix(key, "-")
+	switch field {
+	case "":
+		if descending {
+			return "", false, ErrInvalidSort
+		}
+		return SortID, false, nil
+	case SortID, SortEndDate, SortBidCount:
+		return field, descending, nil
+	}
+	return "", false, ErrInvalidSort
+}
+
+// projectionFor returns the projection returning fields, the project ID and
+// the sort field, or everything but embedded bids when no fields are named.
+// reserve_hidden is always returned so a hidden reserve price stays hidden.
+func projectionFor(fields []string, sortField string) (bson.M, error) {
+	if len(fields) == 0 {
+		return bson.M{"bids": 0}, nil
+	}
+	projection := bson.M{"id": 1, sortField: 1, "reserve_hidden": 1}
+	for _, name := range fields {
+		bsonName, ok := projectFields[name]
+		if !ok {
+			return nil, ErrInvalidField
+		}
+		projection[bsonName] = 1
+	}
+	return projection, nil
+}
+
+// encodeCursor returns the cursor of the page following p.
+func encodeCursor(sort string, p ProjectDetails) string {
+	after := cursor{Sort: sort, ID: p.ID}
+	field, _, _ := parseSort(sort)
+	switch {
+	case field == SortEndDate && !p.EndDate.IsZero():
+		after.End = &p.EndDate
+	case field == SortBidCount && p.BidCount != 0:
+		after.Count = &p.BidCount
+	}
+	raw, _ := json.Marshal(after)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor reads a cursor, which must have been issued for sort.
+func decodeCursor(value, sort string) (cursor, error) {
+	var after cursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(raw, &after) != nil || after.Sort != sort || after.ID == "" {
+		return after, ErrInvalidCursor
+	}
+	return after, nil
+}
+
+// condition matches the projects sorting after the cursor's.
+func (after cursor) condition(field string, descending bool) bson.M {
+	if field == SortID {
+		if descending {
+			return bson.M{"id": bson.M{"$lt": after.ID}}
+		}
+		return bson.M{"id": bson.M{"$gt": after.ID}}
+	}
+
+	var value interface{}
+	switch {
+	case after.End != nil:
+		value = *after.End
+	case after.Count != nil:
+		value = *after.Count
+	}
+	missing := bson.M{field: bson.M{"$exists": false}}
+	tied := bson.M{"id": bson.M{"$gt": after.ID}}
+	if value == nil {
+		tied[field] = bson.M{"$exists": false}
+		if descending {
+			return tied
+		}
+		return bson.M{"$or": []bson.M{tied, {field: bson.M{"$exists": true}}}}
+	}
+	tied[field] = value
+	if descending {
+		return bson.M{"$or": []bson.M{{field: bson.M{"$lt": value}}, tied, missing}}
+	}
+	return bson.M{"$or": []bson.M{{field: bson.M{"$gt": value}}, tied}}
+}
@@ -0,0 +1,53 @@
+package project
+
+import "errors"
+
+//
+// Price Rules
+//
+// Start price, increment, reserve and buy-now price, all in the project's
+// currency; whether a bid reaches one depends on the strategy.
+//
+
+// ErrSealedPriceRules is returned for sealed projects with price rules other
+// than a reserve, ErrTermsLocked for changes to the strategy or price rules of
+// a project that has bids.
+var (
+	ErrSealedPriceRules = errors.New("sealed projects only take a reserve price")
+	ErrTermsLocked      = errors.New("strategy and price rules cannot change once a project has bids")
+)
+
+// HasIncrement reports whether bids must beat the leading bid by a minimum increment.
+func (p ProjectDetails) HasIncrement() bool {
+	return p.BidIncrement > 0 || p.BidIncrementPercent > 0
+}
+
+// Increment returns the smallest amount a bid must beat a leading bid of
+// amount by. Without an increment it is 1, the smallest step proxies make.
+func (p ProjectDetails) Increment(amount int) int {
+	increment := p.BidIncrement
+	if percent := (amount*p.BidIncrementPercent + 99) / 100; percent > increment {
+		increment = percent
+	}
+	if increment < 1 {
+		return 1
+	}
+	return increment
+}
+
+// Public returns the project as shown to callers other than its seller,
+// without a hidden reserve price.
+func (p ProjectDetails) Public() ProjectDetails {
+	if p.ReserveHidden {
+		p.ReservePrice = 0
+	}
+	return p
+}
+
+// validatePriceRules checks the price rules a project can use.
+func (p ProjectDetails) validatePriceRules() error {
+	if p.Sealed && (p.HasIncrement() || p.StartPrice > 0 || p.BuyNowPrice > 0) {
+		return ErrSealedPriceRules
+	}
+	return nil
+}
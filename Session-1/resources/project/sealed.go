@@ -0,0 +1,118 @@
+package project
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Sealed Bids
+//
+// Sealed bids carry a commitment until revealed after bidding ends:
+//
+//	commitment = hex(sha256("<project id>:<bid id>:<amount>:<salt>"))
+//
+
+// Sealed bid errors returned by ProjectManager and BidManager.
+var (
+	ErrNotSealed           = errors.New("project does not take sealed bids")
+	ErrRevealNotStarted    = errors.New("sealed bids can only be revealed once bidding has ended")
+	ErrRevealEnded         = errors.New("reveal phase has ended")
+	ErrRevealPending       = errors.New("sealed bids cannot be awarded before the reveal phase is over")
+	ErrRevealMismatch      = errors.New("amount and salt do not match the bid's commitment")
+	ErrBidRevealed         = errors.New("bid has already been revealed")
+	ErrInvalidRevealWindow = errors.New("reveal end date needs sealed bids and must be after the end date")
+)
+
+// Commit returns the commitment for a sealed bid of amount on projectID.
+func Commit(projectID, bidID string, amount int, salt string) string {
+	sum := sha256.Sum256([]byte(projectID + ":" + bidID + ":" + strconv.Itoa(amount) + ":" + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidCommitment reports whether s is a hex-encoded SHA-256 digest.
+func ValidCommitment(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size
+}
+
+// Opens reports whether amount and salt are the ones committed to by bid.
+func (b BID) Opens(projectID string, amount int, salt string) bool {
+	want := Commit(projectID, b.ID, amount, salt)
+	return subtle.ConstantTimeCompare([]byte(want), []byte(strings.ToLower(b.Commitment))) == 1
+}
+
+// WithoutAmounts returns a copy of b with its amounts removed, as shown to
+// callers other than its buyer while they are secret.
+func (b BID) WithoutAmounts() BID {
+	b.Amount = 0
+	revisions := make([]BidRevision, len(b.Revisions))
+	for i, rev := range b.Revisions {
+		rev.Amount = 0
+		revisions[i] = rev
+	}
+	if b.Revisions != nil {
+		b.Revisions = revisions
+	}
+	return b
+}
+
+// AmountsSecret reports whether the amounts of the project's bids must be
+// hidden: sealed projects keep them secret until they are awarded.
+func (p ProjectDetails) AmountsSecret() bool {
+	return p.Sealed && p.CurrentStatus() != StatusAwarded
+}
+
+// AcceptingReveals returns nil if a sealed bid may be revealed at now, or
+// the error explaining why it may not.
+func (p ProjectDetails) AcceptingReveals(now time.Time) error {
+	if !p.Sealed {
+		return ErrNotSealed
+	}
+	switch p.CurrentStatus() {
+	case StatusOpen, StatusClosed:
+	case StatusDraft:
+		return ErrRevealNotStarted
+	default:
+		return ErrRevealEnded
+	}
+	if !p.biddingEnded(now) {
+		return ErrRevealNotStarted
+	}
+	if !p.RevealEndDate.IsZero() && !now.Before(p.RevealEndDate) {
+		return ErrRevealEnded
+	}
+	return nil
+}
+
+// RevealOver reports whether a sealed project's reveal phase has finished
+// at now, so its auction may be computed. Without a RevealEndDate the phase
+// lasts until the project is awarded.
+func (p ProjectDetails) RevealOver(now time.Time) bool {
+	if !p.biddingEnded(now) {
+		return false
+	}
+	return p.RevealEndDate.IsZero() || !now.Before(p.RevealEndDate)
+}
+
+// biddingEnded reports whether the project has been closed or its end date has passed.
+func (p ProjectDetails) biddingEnded(now time.Time) bool {
+	return p.CurrentStatus() == StatusClosed || !p.EndDate.IsZero() && !now.Before(p.EndDate)
+}
+
+// validateRevealWindow checks that a reveal end date is only set on sealed
+// projects and comes after the end of bidding.
+func (p ProjectDetails) validateRevealWindow() error {
+	if p.RevealEndDate.IsZero() {
+		return nil
+	}
+	if !p.Sealed || !p.EndDate.IsZero() && !p.RevealEndDate.After(p.EndDate) {
+		return ErrInvalidRevealWindow
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package project
+
+import (
+	"errors"
+	"time"
+)
+
+//
+// Soft Close
+//
+// A bid stored in the final SoftCloseMinutes before EndDate pushes EndDate
+// out by ExtendMinutes, but never past HardCloseDate. The extension is made
+// in the same write as the bid's, see BidCondition.
+//
+
+// Soft close errors returned by ProjectManager and BidManager.
+var (
+	ErrInvalidSoftClose = errors.New("soft close needs soft_close_minutes, extend_minutes and an end date, and no sealed bids")
+	ErrInvalidHardClose = errors.New("hard close date needs a soft close and must not be before the end date")
+	ErrEndDateMoved     = errors.New("project end date was changed concurrently, retry")
+)
+
+// HasSoftClose reports whether late bids extend the project's end date.
+func (p ProjectDetails) HasSoftClose() bool {
+	return p.SoftCloseMinutes > 0
+}
+
+// ExtendedEnd returns the end date after a bid accepted at now, and
+// whether the bid extends it.
+func (p ProjectDetails) ExtendedEnd(now time.Time) (time.Time, bool) {
+	if !p.HasSoftClose() || p.EndDate.IsZero() || now.Before(p.EndDate.Add(-minutes(p.SoftCloseMinutes))) {
+		return p.EndDate, false
+	}
+	end := p.EndDate.Add(minutes(p.ExtendMinutes))
+	if !p.HardCloseDate.IsZero() && end.After(p.HardCloseDate) {
+		end = p.HardCloseDate
+	}
+	return end, end.After(p.EndDate)
+}
+
+// validateSoftClose checks the soft close settings.
+func (p ProjectDetails) validateSoftClose() error {
+	if (p.SoftCloseMinutes > 0) != (p.ExtendMinutes > 0) {
+		return ErrInvalidSoftClose
+	}
+	if p.HasSoftClose() && (p.EndDate.IsZero() || p.Sealed) {
+		return ErrInvalidSoftClose
+	}
+	if !p.HardCloseDate.IsZero() && (!p.HasSoftClose() || p.HardCloseDate.Before(p.EndDate)) {
+		return ErrInvalidHardClose
+	}
+	return nil
+}
+
+func minutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
@@ -0,0 +1,36 @@
+package project
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// Versions
+//
+// Every write to a project increments its Version, starting at 1.
+//
+
+// ErrVersionMismatch is returned when a write expects a version the project no longer has.
+var ErrVersionMismatch = errors.New("project was changed since the given version was read")
+
+// versioned adds an increment of the project's version to update.
+func versioned(update bson.M) bson.M {
+	inc, _ := update["$inc"].(bson.M)
+	if inc == nil {
+		inc = bson.M{}
+		update["$inc"] = inc
+	}
+	inc["version"] = 1
+	return update
+}
+
+// atVersion matches the project if it has version. Projects written before
+// versions existed have none until their first write.
+func atVersion(projectID string, version int) bson.M {
+	if version == 0 {
+		return bson.M{"id": projectID, "version": bson.M{"$exists": false}}
+	}
+	return bson.M{"id": projectID, "version": version}
+}
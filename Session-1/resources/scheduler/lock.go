@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// Lease Locks
+//
+// A lease is a document in the locks collection naming its owner and when
+// the lease expires:
+//
+//	{"_id": "project:p1", "owner": "host-1:4242", "expires_at": ISODate("...")}
+//
+// Acquire upserts the document on the condition that it has expired or is
+// already ours. If another owner holds a live lease the condition fails, the
+// upsert tries to insert a second document with the same _id and the unique
+// _id index rejects it, so exactly one owner wins. Leases expire on their own,
+// so a replica that dies while holding one only delays the work.
+//
+
+// LocksCollection is the collection lease documents are stored in.
+const LocksCollection = "locks"
+
+// Locker hands out named, time-limited leases.
+type Locker interface {
+	// Acquire takes or renews the lease on name until now+ttl.
+	// It returns false if another owner holds a lease that has not expired.
+	Acquire(ctx context.Context, name string, ttl time.Duration, now time.Time) (bool, error)
+
+	// Release gives up the lease on name if it is still ours.
+	Release(ctx context.Context, name string) error
+}
+
+// MongoLocker is a Locker backed by lease documents in MongoDB.
+type MongoLocker struct {
+	mongoClient util.MongoClient // Mongo client wrapper
+	dbName      string           // Database holding the locks collection
+	owner       string           // Identifies this instance, e.g. host and pid
+}
+
+// NewLocker creates a Locker storing leases in dbName on behalf of owner.
+// Every replica must use a distinct owner.
+func NewLocker(mongoClient util.MongoClient, dbName, owner string) Locker {
+	return &MongoLocker{
+		mongoClient: mongoClient,
+		dbName:      dbName,
+		owner:       owner,
+	}
+}
+
+// Acquire takes or renews the lease on name.
+func (ml *MongoLocker) Acquire(ctx context.Context, name string, ttl time.Duration, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$lte": now}},
+			{"owner": ml.owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": ml.owner, "expires_at": now.Add(ttl)}}
+	_, err := ml.mongoClient.UpsertOne(ctx, ml.dbName, LocksCollection, filter, update)
+	if util.IsDuplicateKey(err) {
+		return false, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("mongo error acquiring lease", "lease", name, logging.Err(err))
+		return false, err
+	}
+	return true, nil
+}
+
+// Release deletes the lease on name if this instance still owns it.
+func (ml *MongoLocker) Release(ctx context.Context, name string) error {
+	filter := bson.M{"_id": name, "owner": ml.owner}
+	_, err := ml.mongoClient.DeleteOne(ctx, ml.dbName, LocksCollection, filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("mongo error releasing lease", "lease", name, logging.Err(err))
+	}
+	return err
+}
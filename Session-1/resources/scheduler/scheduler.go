@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+//
+// Auction Scheduler
+//
+// The scheduler periodically looks for open projects whose bidding window
+// has ended and closes them: projects with bids are awarded through the
+// project's auction strategy (bidManager.ComputeBID), which publishes the
+// award, projects without bids are moved to closed and published as closed.
+// Sealed projects are left open until their reveal end date has passed too,
+// so buyers can reveal their bids first; those without a reveal end date are
+// awarded by their seller.
+//
+// Every replica may run a scheduler. Before closing a project an instance
+// takes the lease "project:<id>", so each project is closed by one instance.
+//
+
+// Defaults used for zero Config fields.
+const (
+	DefaultInterval = 10 * time.Second
+	DefaultLeaseTTL = 30 * time.Second
+)
+
+// Config tunes a Scheduler.
+type Config struct {
+	Interval time.Duration    // Time between scans for expired projects
+	LeaseTTL time.Duration    // How long a project lease is held; must exceed the time to close a project
+	Now      func() time.Time // Clock, time.Now if nil
+}
+
+// Scheduler closes projects whose bidding window has ended.
+type Scheduler interface {
+	// RunOnce closes every project that has expired by now.
+	// Failures on individual projects are logged and retried on the next run.
+	RunOnce(ctx context.Context) error
+
+	// Run calls RunOnce every interval until ctx is done.
+	Run(ctx context.Context)
+}
+
+// SchedulerImpl is the concrete implementation of Scheduler.
+type SchedulerImpl struct {
+	bidManager     bidManager.BidManager  // Computes and records awards
+	projectManager project.ProjectManager // Finds and closes projects
+	locker         Locker                 // Keeps replicas from closing the same project
+	publisher      events.Publisher       // Receives close events
+	interval       time.Duration
+	leaseTTL       time.Duration
+	now            func() time.Time
+}
+
+// NewScheduler creates a Scheduler. The bid manager should use the same clock.
+func NewScheduler(bidManager bidManager.BidManager, projectManager project.ProjectManager,
+	locker Locker, publisher events.Publisher, conf Config) Scheduler {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultInterval
+	}
+	if conf.LeaseTTL <= 0 {
+		conf.LeaseTTL = DefaultLeaseTTL
+	}
+	if conf.Now == nil {
+		conf.Now = time.Now
+	}
+	return &SchedulerImpl{
+		bidManager:     bidManager,
+		projectManager: projectManager,
+		locker:         locker,
+		publisher:      publisher,
+		interval:       conf.Interval,
+		leaseTTL:       conf.LeaseTTL,
+		now:            conf.Now,
+	}
+}
+
+// Run scans for expired projects immediately and then on every tick.
+func (s *SchedulerImpl) Run(ctx context.Context) {
+	ctx = logging.With(ctx, "component", "scheduler")
+	log := logging.FromContext(ctx)
+	log.Info("scheduler-started")
+	defer log.Info("scheduler-stopped")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			log.Error("scheduler-run-error", logging.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce closes the projects that have expired by now.
+func (s *SchedulerImpl) RunOnce(ctx context.Context) error {
+	log := logging.FromContext(ctx)
+	log.Debug("scheduler-run")
+	defer log.Debug("scheduler-run-completed")
+
+	expired, err := s.projectManager.GetExpiredProjects(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	for _, p := range expired {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		projectCtx := logging.With(ctx, logging.KeyProjectID, p.ID)
+		if err := s.closeProject(projectCtx, p.ID); err != nil {
+			logging.FromContext(projectCtx).Error("scheduler-close-error", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// closeProject closes one project while holding its lease.
+// The work must finish before the lease expires, so it runs under a deadline of leaseTTL.
+func (s *SchedulerImpl) closeProject(ctx context.Context, projectID string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.leaseTTL)
+	defer cancel()
+
+	name := "project:" + projectID
+	acquired, err := s.locker.Acquire(ctx, name, s.leaseTTL, s.now())
+	if err != nil || !acquired {
+		return err
+	}
+	defer s.locker.Release(ctx, name)
+
+	// Another instance may have closed the project since we listed it.
+	current, err := s.projectManager.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if current.CurrentStatus() != project.StatusOpen {
+		return nil
+	}
+	// A late bid may have extended the project since we listed it.
+	if current.EndDate.After(s.now()) {
+		return nil
+	}
+
+	_, err = s.bidManager.ComputeBID(ctx, projectID, 0)
+	switch err {
+	case nil:
+		return nil
+	case bidManager.ErrNoBids:
+		if err := s.projectManager.UpdateProjectStatus(ctx, projectID, project.StatusClosed); err != nil {
+			return err
+		}
+		s.publish(events.ProjectClosed, projectID, nil)
+		return nil
+	default:
+		return err
+	}
+}
+
+func (s *SchedulerImpl) publish(eventType, projectID string, data interface{}) {
+	s.publisher.Publish(events.Event{
+		Type:      eventType,
+		ProjectID: projectID,
+		Time:      s.now(),
+		Data:      data,
+	})
+}
@@ -0,0 +1,168 @@
+// +build functional
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/controller"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/net/context"
+)
+
+// setupServer wires the full application against the in-memory backend,
+// or against MongoDB on localhost:27017 when FUNCTIONAL_BACKEND=mongo.
+func setupServer() *echo.Echo {
+	e := echo.New()
+
+	// Fake config for testing (use test DBs if possible)
+	var conf config.Config
+	conf.Mongo.URI = "mongodb://localhost:27017"
+	conf.Database.Backend = os.Getenv("FUNCTIONAL_BACKEND")
+	conf.DatabaseDetails = config.DatabaseDetails{
+		BuyersDBName:   "buyersDB_test",
+		SellersDBName:  "sellersDB_test",
+		ProjectDBName:  "projectsDB_test",
+		BidsDBName:     "bidsDB_test",
+		CollectionName: "bids_test",
+	}
+
+	// Mongo client + managers
+	var mongoClient util.MongoClient
+	if conf.Database.Backend == config.BackendMongo {
+		var err error
+		mongoClient, err = util.NewMongoClient(context.TODO(), options.Client().ApplyURI(conf.Mongo.URI))
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		mongoClient = util.NewMemoryMongoClient()
+	}
+
+	projectManager := project.NewProjectManager(mongoClient, conf.DatabaseDetails)
+	bidMgr := bidManager.NewBidManager(projectManager, bidManager.Options{})
+
+	tokenManager := auth.NewTokenManager("functional-secret", 0, "")
+
+	c := controller.NewController(bidMgr, projectManager, tokenManager, controller.Options{})
+	c.AttachHandlers(e)
+
+	return e
+}
+
+// send issues a JSON request, authenticated with token when it is not empty.
+func send(t *testing.T, method, url, token string, payload interface{}) *http.Response {
+	var body bytes.Buffer
+	if payload != nil {
+		assert.NoError(t, json.NewEncoder(&body).Encode(payload))
+	}
+	req, _ := http.NewRequest(method, url, &body)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	return res
+}
+
+// login exchanges credentials for a token via POST /v1/auth/token.
+func login(t *testing.T, serverURL, role, id, password string) string {
+	res := send(t, http.MethodPost, serverURL+"/v1/auth/token", "",
+		map[string]string{"role": role, "id": id, "password": password})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var token struct{ Token string }
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&token))
+	res.Body.Close()
+	return token.Token
+}
+
+func TestFunctionalFlow(t *testing.T) {
+	e := setupServer()
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	// --- 1. Create Seller ---
+	seller := map[string]string{"id": "s201", "seller_name": "TestSeller", "password": "seller-pass"}
+	body, _ := json.Marshal(seller)
+	res, err := http.Post(server.URL+"/create-seller", "application/json", bytes.NewBuffer(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// --- 2. Create Buyer ---
+	buyer := map[string]string{"id": "b101", "buyer_name": "TestBuyer", "password": "buyer-pass"}
+	body, _ = json.Marshal(buyer)
+	res, err = http.Post(server.URL+"/create-buyer", "application/json", bytes.NewBuffer(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// --- 3. Log in ---
+	sellerToken := login(t, server.URL, "seller", "s201", "seller-pass")
+	buyerToken := login(t, server.URL, "buyer", "b101", "buyer-pass")
+
+	// --- 4. Create Project ---
+	// The server picks the project's ID and returns where to find it
+	projectPayload := map[string]interface{}{
+		"seller_id": "s201",
+		"details":   []string{"Test Project", "Demo project"},
+	}
+	res = send(t, http.MethodPost, server.URL+"/create-project", sellerToken, projectPayload)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	location := res.Header.Get("Location")
+	assert.True(t, strings.HasPrefix(location, "/v1/projects/"), location)
+	projectID := strings.TrimPrefix(location, "/v1/projects/")
+
+	// Buyers cannot create projects
+	res = send(t, http.MethodPost, server.URL+"/create-project", buyerToken, projectPayload)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// --- 5. Get Projects ---
+	res = send(t, http.MethodGet, server.URL+"/get-projects", buyerToken, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	data, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	t.Logf("Projects: %s", string(data))
+	assert.Contains(t, string(data), `"id":"`+projectID+`"`)
+
+	buyerID := "b101"
+
+	// --- 6. Place a Bid ---
+	bidPayload := map[string]interface{}{
+		"id":       "bid1",
+		"buyer_id": buyerID,
+		"ammount":  1000,
+	}
+	res = send(t, http.MethodPut, server.URL+"/update-bid?projectID="+projectID, "", bidPayload)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	res = send(t, http.MethodPut, server.URL+"/update-bid?projectID="+projectID, buyerToken, bidPayload)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// --- 7. Compute Winning Bid ---
+	res = send(t, http.MethodPost, server.URL+"/compute-bid?projectID="+projectID, buyerToken, nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = send(t, http.MethodPost, server.URL+"/compute-bid?projectID="+projectID, sellerToken, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	data, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	t.Logf("Winning Bidder: %s", string(data))
+	assert.Contains(t, string(data), `"buyer_name":"TestBuyer"`)
+	assert.Contains(t, string(data), `"clearing_price":1000`)
+
+	// --- 8. Bidding is closed once the project is awarded ---
+	res = send(t, http.MethodPut, server.URL+"/update-bid?projectID="+projectID, buyerToken, bidPayload)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+var _ = Describe("TokenManager", func() {
+	var tokenManager auth.TokenManager
+
+	BeforeEach(func() {
+		tokenManager = auth.NewTokenManager("test-secret", time.Hour, "admin-pass")
+	})
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims *auth.Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		Expect(err).ToNot(HaveOccurred())
+		return token
+	}
+
+	It("round-trips the role and subject", func() {
+		token, expiresAt, err := tokenManager.IssueToken(auth.RoleSeller, "s1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expiresAt).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+
+		claims, err := tokenManager.ParseToken(token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claims.Role).To(Equal(auth.RoleSeller))
+		Expect(claims.Subject).To(Equal("s1"))
+	})
+
+	It("refuses to issue tokens for unknown roles or without a subject", func() {
+		_, _, err := tokenManager.IssueToken("root", "s1")
+		Expect(err).To(Equal(auth.ErrInvalidRole))
+		_, _, err = tokenManager.IssueToken(auth.RoleBuyer, "")
+		Expect(err).To(Equal(auth.ErrInvalidRole))
+	})
+
+	It("rejects tokens signed with another secret", func() {
+		other := auth.NewTokenManager("other-secret", time.Hour, "")
+		token, _, err := other.IssueToken(auth.RoleAdmin, auth.AdminSubject)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tokenManager.ParseToken(token)
+		Expect(err).To(Equal(auth.ErrUnauthorized))
+	})
+
+	It("rejects expired tokens", func() {
+		claims := &auth.Claims{Role: auth.RoleBuyer, StandardClaims: jwt.StandardClaims{
+			Subject:   "u1",
+			ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		}}
+		_, err := tokenManager.ParseToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), claims))
+		Expect(err).To(Equal(auth.ErrUnauthorized))
+	})
+
+	It("rejects unsigned tokens and unknown roles", func() {
+		claims := &auth.Claims{Role: auth.RoleAdmin, StandardClaims: jwt.StandardClaims{Subject: "admin"}}
+		_, err := tokenManager.ParseToken(sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims))
+		Expect(err).To(Equal(auth.ErrUnauthorized))
+
+		claims = &auth.Claims{Role: "root", StandardClaims: jwt.StandardClaims{Subject: "u1"}}
+		_, err = tokenManager.ParseToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), claims))
+		Expect(err).To(Equal(auth.ErrUnauthorized))
+	})
+
+	It("checks the admin password", func() {
+		Expect(tokenManager.CheckAdminPassword("admin-pass")).To(Succeed())
+		Expect(tokenManager.CheckAdminPassword("wrong")).To(Equal(auth.ErrInvalidCredentials))
+
+		disabled := auth.NewTokenManager("test-secret", time.Hour, "")
+		Expect(disabled.CheckAdminPassword("")).To(Equal(auth.ErrInvalidCredentials))
+	})
+})
+
+var _ = Describe("Claims", func() {
+	It("lets admins act as anyone", func() {
+		admin := &auth.Claims{Role: auth.RoleAdmin, StandardClaims: jwt.StandardClaims{Subject: auth.AdminSubject}}
+		Expect(admin.Is(auth.RoleSeller, "s1")).To(BeTrue())
+
+		buyer := &auth.Claims{Role: auth.RoleBuyer, StandardClaims: jwt.StandardClaims{Subject: "u1"}}
+		Expect(buyer.Is(auth.RoleBuyer, "u1")).To(BeTrue())
+		Expect(buyer.Is(auth.RoleBuyer, "u2")).To(BeFalse())
+		Expect(buyer.Is(auth.RoleSeller, "u1")).To(BeFalse())
+	})
+})
+
+var _ = Describe("Passwords", func() {
+	It("verifies only the hashed password", func() {
+		hash, err := auth.HashPassword("secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hash).ToNot(ContainSubstring("secret"))
+
+		Expect(auth.CheckPassword(hash, "secret")).To(Succeed())
+		Expect(auth.CheckPassword(hash, "wrong")).To(Equal(auth.ErrInvalidCredentials))
+		Expect(auth.CheckPassword("", "")).To(Equal(auth.ErrInvalidCredentials))
+	})
+})
@@ -3,6 +3,7 @@ package bidManager_test
 import (
 	"context"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,10 +15,11 @@ import (
 // --- Mock ProjectManager ---
 
 type mockProjectManager struct {
-	updateCalled   bool
-	updateProjectID string
-	updateBid      project.BID
-	updateErr      error
+	bids       map[string]project.BID // Stored bids, by ID
+	revisions  []project.BidRevision  // Revisions passed to AddBidRevision
+	revisedBid project.BID            // Bid passed to the last AddBidRevision
+	addErrs    []error                // Errors returned by successive AddBidRevision calls
+	getBidsErr error
 
 	getProjectCalled bool
 	getProjectID     string
@@ -28,34 +30,106 @@ type mockProjectManager struct {
 	getBuyerID     string
 	getBuyerRes    project.Buyer
 	getBuyerErr    error
+
+	statusUpdates []project.Status
+	statusErr     error
+
+	award    *project.Award
+	awardErr error
+}
+
+func (m *mockProjectManager) GetBid(_ context.Context, projectID, bidID string) (project.BID, error) {
+	bid, ok := m.bids[bidID]
+	if !ok {
+		return bid, project.ErrBidNotFound
+	}
+	return bid, nil
 }
 
-func (m *mockProjectManager) UpdateProject(projectID string, bid project.BID) error {
-	m.updateCalled = true
-	m.updateProjectID = projectID
-	m.updateBid = bid
-	return m.updateErr
+func (m *mockProjectManager) GetBids(context.Context, string) ([]project.BID, error) {
+	bids := []project.BID{}
+	for _, bid := range m.bids {
+		bids = append(bids, bid)
+	}
+	return bids, m.getBidsErr
 }
 
-func (m *mockProjectManager) GetProject(projectID string) (project.ProjectDetails, error) {
+// AddBidRevision records the revision and returns the next queued error, if any.
+func (m *mockProjectManager) AddBidRevision(_ context.Context, projectID string, bid project.BID, rev project.BidRevision, _ project.BidCondition) error {
+	m.revisions = append(m.revisions, rev)
+	m.revisedBid = bid
+	if len(m.addErrs) > 0 {
+		err := m.addErrs[0]
+		m.addErrs = m.addErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (m *mockProjectManager) GetProject(_ context.Context, projectID string) (project.ProjectDetails, error) {
 	m.getProjectCalled = true
 	m.getProjectID = projectID
 	return m.getProjectRes, m.getProjectErr
 }
 
-func (m *mockProjectManager) GetBuyer(buyerID string) (project.Buyer, error) {
+func (m *mockProjectManager) GetBuyer(_ context.Context, buyerID string) (project.Buyer, error) {
 	m.getBuyerCalled = true
 	m.getBuyerID = buyerID
 	return m.getBuyerRes, m.getBuyerErr
 }
 
+func (m *mockProjectManager) UpdateProjectStatus(_ context.Context, projectID string, status project.Status) error {
+	m.statusUpdates = append(m.statusUpdates, status)
+	return m.statusErr
+}
+
+// UpdateProjectDetails records status changes like UpdateProjectStatus.
+func (m *mockProjectManager) UpdateProjectDetails(_ context.Context, projectID string, changes project.ProjectDetails) error {
+	if changes.Status != "" {
+		m.statusUpdates = append(m.statusUpdates, changes.Status)
+		return m.statusErr
+	}
+	return nil
+}
+
+// AwardProject records the award and, like the real implementation, the move to awarded.
+func (m *mockProjectManager) AwardProject(_ context.Context, projectID string, award project.Award, _ int) error {
+	m.award = &award
+	m.statusUpdates = append(m.statusUpdates, project.StatusAwarded)
+	return m.awardErr
+}
+
 // Unused methods to satisfy interface (not tested here)
-func (m *mockProjectManager) CreateProject(project.ProjectDetails) error { return nil }
-func (m *mockProjectManager) GetProjects() ([]project.ProjectDetails, error) {
+func (m *mockProjectManager) CreateProject(context.Context, project.ProjectDetails) error { return nil }
+func (m *mockProjectManager) GetProjects(context.Context) ([]project.ProjectDetails, error) {
 	return nil, nil
 }
-func (m *mockProjectManager) CreateBuyer(project.Buyer) error { return nil }
-func (m *mockProjectManager) CreateSeller(project.Seller) error { return nil }
+func (m *mockProjectManager) ListProjects(context.Context, project.ProjectQuery) (project.ProjectPage, error) {
+	return project.ProjectPage{}, nil
+}
+func (m *mockProjectManager) CreateBuyer(context.Context, project.Buyer) error         { return nil }
+func (m *mockProjectManager) CreateSeller(context.Context, project.Seller) error       { return nil }
+func (m *mockProjectManager) DeleteProject(context.Context, string, int) error         { return nil }
+func (m *mockProjectManager) GetBuyers(context.Context) ([]project.Buyer, error)       { return nil, nil }
+func (m *mockProjectManager) UpdateBuyer(context.Context, string, project.Buyer) error { return nil }
+func (m *mockProjectManager) DeleteBuyer(context.Context, string) error                { return nil }
+func (m *mockProjectManager) GetSellers(context.Context) ([]project.Seller, error)     { return nil, nil }
+func (m *mockProjectManager) GetSeller(context.Context, string) (project.Seller, error) {
+	return project.Seller{}, nil
+}
+func (m *mockProjectManager) UpdateSeller(context.Context, string, project.Seller) error { return nil }
+func (m *mockProjectManager) DeleteSeller(context.Context, string) error                 { return nil }
+func (m *mockProjectManager) GetExpiredProjects(context.Context, time.Time) ([]project.ProjectDetails, error) {
+	return nil, nil
+}
+func (m *mockProjectManager) SetProxyBid(context.Context, project.ProxyBid) error { return nil }
+func (m *mockProjectManager) GetProxyBid(context.Context, string, string) (project.ProxyBid, error) {
+	return project.ProxyBid{}, project.ErrProxyNotFound
+}
+func (m *mockProjectManager) GetProxyBids(context.Context, string) ([]project.ProxyBid, error) {
+	return nil, nil
+}
+func (m *mockProjectManager) DeleteProxyBid(context.Context, string, string) error { return nil }
 
 // --- Test Suite ---
 
@@ -64,33 +138,185 @@ var _ = Describe("BidManager", func() {
 		mockPM *mockProjectManager
 		bm     BidManager
 	)
+	ctx := context.TODO()
 
 	BeforeEach(func() {
 		mockPM = &mockProjectManager{}
-		bm = NewBidManager(mockPM, context.TODO())
+		bm = NewBidManager(mockPM, Options{})
 	})
 
 	// --- DoBID Tests ---
 	Describe("DoBID", func() {
-		It("should call UpdateProject successfully", func() {
+		It("places a new bid as revision 1", func() {
 			bid := project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}
-			err := bm.DoBID("p1", bid)
+			err := bm.DoBID(ctx, "p1", bid, 0)
 
 			Expect(err).To(BeNil())
-			Expect(mockPM.updateCalled).To(BeTrue())
-			Expect(mockPM.updateProjectID).To(Equal("p1"))
-			Expect(mockPM.updateBid).To(Equal(bid))
+			Expect(mockPM.revisedBid).To(Equal(bid))
+			Expect(mockPM.revisions).To(HaveLen(1))
+			Expect(mockPM.revisions[0].Revision).To(Equal(1))
+			Expect(mockPM.revisions[0].Action).To(Equal(project.ActionPlaced))
+			Expect(mockPM.revisions[0].Amount).To(Equal(100))
 		})
 
-		It("should return error if UpdateProject fails", func() {
-			mockPM.updateErr = errors.New("update failed")
+		It("should return error if the bid cannot be stored", func() {
+			mockPM.addErrs = []error{errors.New("update failed")}
 			bid := project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}
 
-			err := bm.DoBID("p1", bid)
+			err := bm.DoBID(ctx, "p1", bid, 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("update failed"))
 		})
+
+		Context("Amendments", func() {
+			BeforeEach(func() {
+				mockPM.bids = map[string]project.BID{
+					"b1": {ID: "b1", BuyerID: "buyer1", Amount: 100, Status: project.BidActive, Revision: 2},
+				}
+			})
+
+			It("amends the buyer's existing bid as the next revision", func() {
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 120}, 0)).To(Succeed())
+
+				Expect(mockPM.revisions).To(HaveLen(1))
+				Expect(mockPM.revisions[0].Revision).To(Equal(3))
+				Expect(mockPM.revisions[0].Action).To(Equal(project.ActionAmended))
+				Expect(mockPM.revisions[0].Amount).To(Equal(120))
+			})
+
+			It("does not let another buyer take over the bid", func() {
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer2", Amount: 90}, 0)).To(Equal(project.ErrBidTaken))
+				Expect(mockPM.revisions).To(BeEmpty())
+			})
+
+			It("does not amend a retracted bid", func() {
+				bid := mockPM.bids["b1"]
+				bid.Status = project.BidRetracted
+				mockPM.bids["b1"] = bid
+
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 90}, 0)).To(Equal(project.ErrBidRetracted))
+			})
+
+			It("only accepts improvements under the improve-only policy", func() {
+				mockPM.getProjectRes = project.ProjectDetails{ID: "p1", AmendmentPolicy: project.AmendImproveOnly}
+
+				// The default reverse auction ranks lower amounts better
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 120}, 0)).To(Equal(ErrBidNotImproved))
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}, 0)).To(Equal(ErrBidNotImproved))
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 90}, 0)).To(Succeed())
+
+				mockPM.getProjectRes.Strategy = StrategyFirstPrice
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 90}, 0)).To(Equal(ErrBidNotImproved))
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 110}, 0)).To(Succeed())
+			})
+
+			It("retries after losing a race with a concurrent change", func() {
+				mockPM.addErrs = []error{project.ErrBidConflict}
+
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 120}, 0)).To(Succeed())
+				Expect(mockPM.revisions).To(HaveLen(2))
+			})
+
+			It("gives up after repeated conflicts", func() {
+				mockPM.addErrs = []error{project.ErrBidConflict, project.ErrBidConflict, project.ErrBidConflict}
+
+				Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 120}, 0)).To(Equal(project.ErrBidConflict))
+			})
+		})
+
+		Context("Lifecycle", func() {
+			var bid project.BID
+
+			BeforeEach(func() {
+				bid = project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}
+			})
+
+			It("accepts bids inside the bidding window", func() {
+				mockPM.getProjectRes = project.ProjectDetails{
+					ID:        "p1",
+					Status:    project.StatusOpen,
+					StartDate: time.Now().Add(-time.Hour),
+					EndDate:   time.Now().Add(time.Hour),
+				}
+
+				Expect(bm.DoBID(ctx, "p1", bid, 0)).To(Succeed())
+				Expect(mockPM.revisions).To(HaveLen(1))
+			})
+
+			It("rejects bids on projects that are not open", func() {
+				mockPM.getProjectRes = project.ProjectDetails{ID: "p1", Status: project.StatusAwarded}
+
+				Expect(bm.DoBID(ctx, "p1", bid, 0)).To(Equal(project.ErrProjectNotOpen))
+				Expect(mockPM.revisions).To(BeEmpty())
+			})
+
+			It("rejects bids before the window opens", func() {
+				mockPM.getProjectRes = project.ProjectDetails{
+					ID:        "p1",
+					StartDate: time.Now().Add(time.Hour),
+				}
+
+				Expect(bm.DoBID(ctx, "p1", bid, 0)).To(Equal(project.ErrBiddingNotStarted))
+				Expect(mockPM.revisions).To(BeEmpty())
+			})
+
+			It("rejects bids after the window ends", func() {
+				mockPM.getProjectRes = project.ProjectDetails{
+					ID:      "p1",
+					EndDate: time.Now().Add(-time.Minute),
+				}
+
+				Expect(bm.DoBID(ctx, "p1", bid, 0)).To(Equal(project.ErrBiddingWindowEnded))
+				Expect(mockPM.revisions).To(BeEmpty())
+			})
+
+			It("returns the error if the project cannot be loaded", func() {
+				mockPM.getProjectErr = errors.New("db error")
+
+				Expect(bm.DoBID(ctx, "p1", bid, 0)).To(MatchError("db error"))
+				Expect(mockPM.revisions).To(BeEmpty())
+			})
+		})
+	})
+
+	// --- RetractBid Tests ---
+	Describe("RetractBid", func() {
+		BeforeEach(func() {
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 100, Status: project.BidActive, Revision: 1},
+			}
+		})
+
+		It("adds a retraction revision that keeps the amount", func() {
+			Expect(bm.RetractBid(ctx, "p1", "b1", "changed my mind")).To(Succeed())
+
+			Expect(mockPM.revisions).To(HaveLen(1))
+			rev := mockPM.revisions[0]
+			Expect(rev.Revision).To(Equal(2))
+			Expect(rev.Action).To(Equal(project.ActionRetracted))
+			Expect(rev.Amount).To(Equal(100))
+			Expect(rev.Reason).To(Equal("changed my mind"))
+		})
+
+		It("cannot retract twice", func() {
+			bid := mockPM.bids["b1"]
+			bid.Status = project.BidRetracted
+			mockPM.bids["b1"] = bid
+
+			Expect(bm.RetractBid(ctx, "p1", "b1", "again")).To(Equal(project.ErrBidRetracted))
+		})
+
+		It("reports unknown bids", func() {
+			Expect(bm.RetractBid(ctx, "p1", "nope", "why")).To(Equal(project.ErrBidNotFound))
+		})
+
+		It("rejects retractions once bidding has closed", func() {
+			mockPM.getProjectRes = project.ProjectDetails{ID: "p1", Status: project.StatusClosed}
+
+			Expect(bm.RetractBid(ctx, "p1", "b1", "too late")).To(Equal(project.ErrProjectNotOpen))
+			Expect(mockPM.revisions).To(BeEmpty())
+		})
 	})
 
 	// --- ComputeBID Tests ---
@@ -98,28 +324,157 @@ var _ = Describe("BidManager", func() {
 		It("should return the buyer with the lowest bid", func() {
 			// Project with two bids
 			projectDetails := project.ProjectDetails{
-				ID:   "p1",
-				BIDS: map[string]project.BID{
-					"b1": {ID: "b1", BuyerID: "buyer1", Amount: 200},
-					"b2": {ID: "b2", BuyerID: "buyer2", Amount: 100}, // lowest
-				},
+				ID: "p1",
+			}
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 200},
+				"b2": {ID: "b2", BuyerID: "buyer2", Amount: 100}, // lowest
 			}
 			mockPM.getProjectRes = projectDetails
 			mockPM.getBuyerRes = project.Buyer{ID: "buyer2", BuyerName: "LowestBidder"}
 
-			buyer, err := bm.ComputeBID("p1")
+			result, err := bm.ComputeBID(ctx, "p1", 0)
 
 			Expect(err).To(BeNil())
 			Expect(mockPM.getProjectCalled).To(BeTrue())
 			Expect(mockPM.getBuyerCalled).To(BeTrue())
 			Expect(mockPM.getBuyerID).To(Equal("buyer2"))
-			Expect(buyer.BuyerName).To(Equal("LowestBidder"))
+			Expect(result.Winner.BuyerName).To(Equal("LowestBidder"))
+			Expect(result.Strategy).To(Equal(StrategyReverse))
+			Expect(result.ClearingPrice).To(Equal(100))
+			Expect(result.RankedBids).To(HaveLen(2))
+			Expect(mockPM.statusUpdates).To(Equal([]project.Status{project.StatusAwarded}))
+		})
+
+		It("records the award with the time from the injected clock", func() {
+			closedAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+			bm = NewBidManager(mockPM, Options{Now: func() time.Time { return closedAt }})
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID:       "p1",
+				Strategy: StrategyFirstPrice,
+			}
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 200},
+				"b2": {ID: "b2", BuyerID: "buyer2", Amount: 150},
+			}
+
+			result, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ClosedAt).To(Equal(closedAt))
+			Expect(mockPM.award).To(Equal(&project.Award{
+				BuyerID:  "buyer1",
+				BidID:    "b1",
+				Price:    200,
+				Strategy: StrategyFirstPrice,
+				ClosedAt: closedAt,
+			}))
+		})
+
+		It("fails if the project was awarded concurrently", func() {
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID: "p1",
+			}
+			mockPM.bids = map[string]project.BID{"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10}}
+			mockPM.awardErr = project.ErrInvalidTransition
+
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).To(Equal(project.ErrInvalidTransition))
+		})
+
+		It("uses the project's strategy to pick the winner and price", func() {
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID:       "p1",
+				Strategy: StrategySecondPrice,
+			}
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 200},
+				"b2": {ID: "b2", BuyerID: "buyer2", Amount: 150},
+				"b3": {ID: "b3", BuyerID: "buyer3", Amount: 100},
+			}
+
+			result, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockPM.getBuyerID).To(Equal("buyer1"))
+			Expect(result.WinningBid.ID).To(Equal("b1"))
+			Expect(result.ClearingPrice).To(Equal(150))
+			Expect(result.Strategy).To(Equal(StrategySecondPrice))
+		})
+
+		It("rejects an unknown strategy", func() {
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID:       "p1",
+				Strategy: "dutch",
+			}
+			mockPM.bids = map[string]project.BID{"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10}}
+
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).To(Equal(ErrUnknownStrategy))
+			Expect(mockPM.statusUpdates).To(BeEmpty())
+		})
+
+		It("awards a closed project without closing it again", func() {
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID:     "p1",
+				Status: project.StatusClosed,
+			}
+			mockPM.bids = map[string]project.BID{"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10}}
+
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockPM.statusUpdates).To(Equal([]project.Status{project.StatusAwarded}))
+		})
+
+		It("refuses to award a project twice", func() {
+			mockPM.getProjectRes = project.ProjectDetails{
+				ID:     "p1",
+				Status: project.StatusAwarded,
+			}
+			mockPM.bids = map[string]project.BID{"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10}}
+
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).To(Equal(project.ErrInvalidTransition))
+			Expect(mockPM.getBuyerCalled).To(BeFalse())
+		})
+
+		It("uses only each buyer's latest active bid", func() {
+			t0 := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			mockPM.bids = map[string]project.BID{
+				// buyer1 moved from b1 to b2; b1 must not count
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10, Status: project.BidActive, UpdatedAt: t0},
+				"b2": {ID: "b2", BuyerID: "buyer1", Amount: 80, Status: project.BidActive, UpdatedAt: t0.Add(time.Minute)},
+				// buyer2's cheapest bid was retracted
+				"b3": {ID: "b3", BuyerID: "buyer2", Amount: 5, Status: project.BidRetracted, UpdatedAt: t0.Add(time.Hour)},
+				"b4": {ID: "b4", BuyerID: "buyer2", Amount: 60, Status: project.BidActive, UpdatedAt: t0},
+			}
+
+			result, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.WinningBid.ID).To(Equal("b4"))
+			Expect(result.RankedBids).To(HaveLen(2))
+			Expect(result.RankedBids[1].ID).To(Equal("b2"))
+		})
+
+		It("treats a project whose bids were all retracted as having no bids", func() {
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 10, Status: project.BidRetracted},
+			}
+
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+
+			Expect(err).To(Equal(ErrNoBids))
 		})
 
 		It("should return error if GetProject fails", func() {
 			mockPM.getProjectErr = errors.New("db error")
 
-			_, err := bm.ComputeBID("p1")
+			_, err := bm.ComputeBID(ctx, "p1", 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("db error"))
@@ -127,15 +482,15 @@ var _ = Describe("BidManager", func() {
 
 		It("should return error if GetBuyer fails", func() {
 			projectDetails := project.ProjectDetails{
-				ID:   "p1",
-				BIDS: map[string]project.BID{
-					"b1": {ID: "b1", BuyerID: "buyer1", Amount: 150},
-				},
+				ID: "p1",
+			}
+			mockPM.bids = map[string]project.BID{
+				"b1": {ID: "b1", BuyerID: "buyer1", Amount: 150},
 			}
 			mockPM.getProjectRes = projectDetails
 			mockPM.getBuyerErr = errors.New("buyer lookup failed")
 
-			_, err := bm.ComputeBID("p1")
+			_, err := bm.ComputeBID(ctx, "p1", 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("buyer lookup failed"))
@@ -143,15 +498,16 @@ var _ = Describe("BidManager", func() {
 
 		It("should handle empty bids gracefully", func() {
 			projectDetails := project.ProjectDetails{
-				ID:   "p1",
-				BIDS: map[string]project.BID{}, // no bids
+				ID: "p1",
 			}
+			mockPM.bids = map[string]project.BID{}
 			mockPM.getProjectRes = projectDetails
 
 			// Expect error because no buyer can be found
-			_, err := bm.ComputeBID("p1")
+			_, err := bm.ComputeBID(ctx, "p1", 0)
 
-			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(ErrNoBids))
+			Expect(mockPM.statusUpdates).To(BeEmpty())
 		})
 	})
 })
@@ -0,0 +1,193 @@
+package config_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		dir  string
+		env  map[string]string
+		file string
+	)
+
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	writeFile := func(contents string) string {
+		path := filepath.Join(dir, "config.toml")
+		Expect(ioutil.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "config")
+		Expect(err).ToNot(HaveOccurred())
+		env = map[string]string{}
+		file = writeFile(`
+[http]
+addr = ":8080"
+readTimeout = "5s"
+
+[auth]
+secret = "file-secret-0123456789abcdefghijkl"
+`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Load", func() {
+		It("fills unset values with defaults", func() {
+			conf, err := config.Load(file, lookupEnv, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.HTTP.Addr).To(Equal(":8080"))
+			Expect(conf.HTTP.ReadTimeout.Duration).To(Equal(5 * time.Second))
+			Expect(conf.HTTP.WriteTimeout.Duration).To(Equal(30 * time.Second))
+			Expect(conf.Mongo.URI).To(Equal("mongodb://localhost:27017"))
+			Expect(conf.Mongo.MaxPoolSize).To(Equal(100))
+			Expect(conf.Auth.TokenTTL.Duration).To(Equal(24 * time.Hour))
+		})
+
+		It("lets the environment override the file and flags override both", func() {
+			env["BIDDING_HTTP_ADDR"] = ":9090"
+			env["BIDDING_HTTP_READ_TIMEOUT"] = "7s"
+			env["BIDDING_MONGO_MAX_POOL_SIZE"] = "20"
+			env["BIDDING_SCHEDULER_ENABLED"] = "false"
+
+			conf, err := config.Load(file, lookupEnv, map[string]string{"http.addr": "127.0.0.1:7000"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.HTTP.Addr).To(Equal("127.0.0.1:7000"))
+			Expect(conf.HTTP.ReadTimeout.Duration).To(Equal(7 * time.Second))
+			Expect(conf.Mongo.MaxPoolSize).To(Equal(20))
+			Expect(conf.Scheduler.Enabled).To(BeFalse())
+		})
+
+		It("names environment variables after the setting key", func() {
+			Expect(config.EnvName("http.readTimeout")).To(Equal("BIDDING_HTTP_READ_TIMEOUT"))
+			Expect(config.EnvName("DatabaseDetails.BuyersDBName")).To(Equal("BIDDING_DATABASE_DETAILS_BUYERS_DB_NAME"))
+		})
+
+		It("builds the Mongo URI from the legacy server and port", func() {
+			file = writeFile(`
+[database]
+server = "mongodb://db.internal"
+port = "27018"
+
+[auth]
+secret = "s-0123456789abcdefghijklmnopqrstu"
+`)
+			conf, err := config.Load(file, lookupEnv, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.Mongo.URI).To(Equal("mongodb://db.internal:27018"))
+		})
+
+		It("fails when the file cannot be decoded", func() {
+			file = writeFile("[http]\naddr = \n")
+			_, err := config.Load(file, lookupEnv, nil)
+			Expect(err).To(MatchError(ContainSubstring("reading config file")))
+		})
+
+		It("fails on values of the wrong type", func() {
+			env["BIDDING_HTTP_WRITE_TIMEOUT"] = "soon"
+			_, err := config.Load(file, lookupEnv, nil)
+			Expect(err).To(MatchError(ContainSubstring("BIDDING_HTTP_WRITE_TIMEOUT")))
+
+			delete(env, "BIDDING_HTTP_WRITE_TIMEOUT")
+			_, err = config.Load(file, lookupEnv, map[string]string{"mongo.maxPoolSize": "many"})
+			Expect(err).To(MatchError(ContainSubstring("--mongo.maxPoolSize")))
+		})
+
+		It("rejects example and short auth secrets", func() {
+			env["BIDDING_AUTH_SECRET"] = "change-me"
+			_, err := config.Load(file, lookupEnv, nil)
+			Expect(err).To(MatchError(ContainSubstring("auth.secret must not be the example value")))
+
+			env["BIDDING_AUTH_SECRET"] = "too-short"
+			_, err = config.Load(file, lookupEnv, nil)
+			Expect(err).To(MatchError(ContainSubstring("auth.secret must be at least 32 bytes, got 9")))
+		})
+
+		It("reports every invalid setting at once", func() {
+			env["BIDDING_AUTH_SECRET"] = ""
+			env["BIDDING_DATABASE_BACKEND"] = "postgres"
+			env["BIDDING_HTTP_BODY_LIMIT"] = "lots"
+			env["BIDDING_MONGO_PASSWORD"] = "p"
+
+			_, err := config.Load(file, lookupEnv, map[string]string{"mongo.uri": "http://localhost"})
+			Expect(err).To(HaveOccurred())
+			for _, problem := range []string{
+				"auth.secret must be set",
+				"database.backend",
+				"http.bodyLimit",
+				"mongo.password requires mongo.username",
+			} {
+				Expect(err.Error()).To(ContainSubstring(problem))
+			}
+			// Only checked when the mongo backend is used
+			Expect(err.Error()).ToNot(ContainSubstring("mongo.uri"))
+
+			delete(env, "BIDDING_DATABASE_BACKEND")
+			_, err = config.Load(file, lookupEnv, map[string]string{"mongo.uri": "http://localhost"})
+			Expect(err).To(MatchError(ContainSubstring("mongo.uri must start with mongodb://")))
+		})
+	})
+
+	Describe("RegisterFlags", func() {
+		It("returns only the flags given on the command line", func() {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := config.RegisterFlags(fs)
+			Expect(fs.Parse([]string{"--config", "other.toml", "--print-config", "--http.addr=:1"})).To(Succeed())
+
+			Expect(*flags.ConfigFile).To(Equal("other.toml"))
+			Expect(*flags.PrintConfig).To(BeTrue())
+			Expect(flags.Overrides()).To(Equal(map[string]string{"http.addr": ":1"}))
+		})
+	})
+
+	Describe("PrintConfig", func() {
+		It("redacts secrets and passwords in the Mongo URI", func() {
+			env["BIDDING_MONGO_URI"] = "mongodb://app:hunter2@db:27017/bids"
+			env["BIDDING_MONGO_USERNAME"] = "app"
+			env["BIDDING_MONGO_PASSWORD"] = "hunter2"
+			conf, err := config.Load(file, lookupEnv, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			var out bytes.Buffer
+			Expect(conf.PrintConfig(&out)).To(Succeed())
+			Expect(out.String()).ToNot(ContainSubstring("hunter2"))
+			Expect(out.String()).ToNot(ContainSubstring("file-secret-0123456789abcdefghijkl"))
+			Expect(out.String()).To(ContainSubstring(`uri = "mongodb://app:xxxxx@db:27017/bids"`))
+			Expect(out.String()).To(ContainSubstring(`secret = "<redacted>"`))
+			Expect(out.String()).To(ContainSubstring(`addr = ":8080"`))
+			Expect(out.String()).To(ContainSubstring(`adminPassword = ""`))
+		})
+
+		It("prints settings that Load reads back unchanged", func() {
+			conf, err := config.Load(file, lookupEnv, nil)
+			Expect(err).ToNot(HaveOccurred())
+			conf.Auth.Secret = ""
+
+			var out bytes.Buffer
+			Expect(conf.PrintConfig(&out)).To(Succeed())
+			reloaded, err := config.Load(writeFile(out.String()), lookupEnv, map[string]string{"auth.secret": "file-secret-0123456789abcdefghijkl"})
+			Expect(err).ToNot(HaveOccurred())
+			conf.Auth.Secret = "file-secret-0123456789abcdefghijkl"
+			Expect(reloaded).To(Equal(conf))
+		})
+	})
+})
@@ -2,35 +2,54 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/21keshav/IBackendApplication/auth"
 	"github.com/21keshav/IBackendApplication/controller"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
 	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/validation"
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 )
 
 // --- Mock implementations for dependencies ---
 
 type mockBidManager struct {
-	doBIDCalled     bool
-	doBIDErr        error
-	computeCalled   bool
-	computeResult   interface{}
-	computeErr      error
+	doBIDCalled   bool
+	doBIDErr      error
+	computeCalled bool
+	computeResult bidManager.AuctionResult
+	computeErr    error
 }
 
-func (m *mockBidManager) DoBID(projectID string, bid project.BID) error {
+func (m *mockBidManager) DoBID(_ context.Context, projectID string, bid project.BID, _ int) error {
 	m.doBIDCalled = true
 	return m.doBIDErr
 }
 
-func (m *mockBidManager) ComputeBID(projectID string) (interface{}, error) {
+func (m *mockBidManager) RetractBid(_ context.Context, projectID, bidID, reason string) error {
+	return nil
+}
+
+func (m *mockBidManager) RevealBid(_ context.Context, projectID, bidID string, amount int, salt string) error {
+	return nil
+}
+
+func (m *mockBidManager) SetProxyBid(context.Context, string, project.ProxyBid) error { return nil }
+
+func (m *mockBidManager) CancelProxyBid(context.Context, string, string) error { return nil }
+
+func (m *mockBidManager) ComputeBID(_ context.Context, projectID string, _ int) (bidManager.AuctionResult, error) {
 	m.computeCalled = true
 	return m.computeResult, m.computeErr
 }
@@ -46,37 +65,112 @@ type mockProjectManager struct {
 	createBuyerErr   error
 	getProjectsRes   []project.ProjectDetails
 	getProjectsErr   error
+
+	updateStatusCalled bool
+	updateStatusValue  project.Status
+	updateStatusErr    error
+
+	createProjectArg project.ProjectDetails
+	getProjectRes    project.ProjectDetails
+	getBuyerErr      error
+	getSellerErr     error
 }
 
-func (m *mockProjectManager) CreateProject(p project.ProjectDetails) error {
+func (m *mockProjectManager) CreateProject(_ context.Context, p project.ProjectDetails) error {
 	m.createProjectCalled = true
+	m.createProjectArg = p
 	return m.createProjectErr
 }
 
-func (m *mockProjectManager) CreateSeller(s project.Seller) error {
+func (m *mockProjectManager) GetProject(context.Context, string) (project.ProjectDetails, error) {
+	return m.getProjectRes, nil
+}
+
+func (m *mockProjectManager) CreateSeller(_ context.Context, s project.Seller) error {
 	m.createSellerCalled = true
 	return m.createSellerErr
 }
 
-func (m *mockProjectManager) CreateBuyer(b project.Buyer) error {
+func (m *mockProjectManager) CreateBuyer(_ context.Context, b project.Buyer) error {
 	m.createBuyerCalled = true
 	return m.createBuyerErr
 }
 
-func (m *mockProjectManager) GetProjects() ([]project.ProjectDetails, error) {
+func (m *mockProjectManager) GetProjects(context.Context) ([]project.ProjectDetails, error) {
 	m.getProjectsCalled = true
 	return m.getProjectsRes, m.getProjectsErr
 }
+func (m *mockProjectManager) ListProjects(context.Context, project.ProjectQuery) (project.ProjectPage, error) {
+	return project.ProjectPage{}, nil
+}
+
+func (m *mockProjectManager) UpdateProjectStatus(_ context.Context, projectID string, status project.Status) error {
+	m.updateStatusCalled = true
+	m.updateStatusValue = status
+	return m.updateStatusErr
+}
+
+func (m *mockProjectManager) GetBuyer(context.Context, string) (project.Buyer, error) {
+	return project.Buyer{}, m.getBuyerErr
+}
+
+func (m *mockProjectManager) GetSeller(context.Context, string) (project.Seller, error) {
+	return project.Seller{}, m.getSellerErr
+}
+
+// Unused methods to satisfy interface (not tested here)
+func (m *mockProjectManager) GetBid(context.Context, string, string) (project.BID, error) {
+	return project.BID{}, project.ErrBidNotFound
+}
+func (m *mockProjectManager) AddBidRevision(context.Context, string, project.BID, project.BidRevision, project.BidCondition) error {
+	return nil
+}
+func (m *mockProjectManager) UpdateProjectDetails(context.Context, string, project.ProjectDetails) error {
+	return nil
+}
+func (m *mockProjectManager) DeleteProject(context.Context, string, int) error           { return nil }
+func (m *mockProjectManager) GetBids(context.Context, string) ([]project.BID, error)     { return nil, nil }
+func (m *mockProjectManager) GetBuyers(context.Context) ([]project.Buyer, error)         { return nil, nil }
+func (m *mockProjectManager) UpdateBuyer(context.Context, string, project.Buyer) error   { return nil }
+func (m *mockProjectManager) DeleteBuyer(context.Context, string) error                  { return nil }
+func (m *mockProjectManager) GetSellers(context.Context) ([]project.Seller, error)       { return nil, nil }
+func (m *mockProjectManager) UpdateSeller(context.Context, string, project.Seller) error { return nil }
+func (m *mockProjectManager) DeleteSeller(context.Context, string) error                 { return nil }
+func (m *mockProjectManager) GetExpiredProjects(context.Context, time.Time) ([]project.ProjectDetails, error) {
+	return nil, nil
+}
+func (m *mockProjectManager) AwardProject(context.Context, string, project.Award, int) error {
+	return nil
+}
+func (m *mockProjectManager) SetProxyBid(context.Context, project.ProxyBid) error { return nil }
+func (m *mockProjectManager) GetProxyBid(context.Context, string, string) (project.ProxyBid, error) {
+	return project.ProxyBid{}, project.ErrProxyNotFound
+}
+func (m *mockProjectManager) GetProxyBids(context.Context, string) ([]project.ProxyBid, error) {
+	return nil, nil
+}
+func (m *mockProjectManager) DeleteProxyBid(context.Context, string, string) error { return nil }
+
+// as attaches the claims the authenticate middleware would set for a caller.
+func as(ctx echo.Context, role auth.Role, id string) echo.Context {
+	ctx.Set(auth.ContextKey, &auth.Claims{Role: role, StandardClaims: jwt.StandardClaims{Subject: id}})
+	return ctx
+}
+
+// asAdmin attaches admin claims, which pass every ownership check.
+func asAdmin(ctx echo.Context) echo.Context {
+	return as(ctx, auth.RoleAdmin, auth.AdminSubject)
+}
 
 // --- Test Suite ---
 
 var _ = Describe("Controller", func() {
 	var (
-		e          *echo.Echo
-		rec        *httptest.ResponseRecorder
-		c          controller.Controller
-		mockBid    *mockBidManager
-		mockProj   *mockProjectManager
+		e        *echo.Echo
+		rec      *httptest.ResponseRecorder
+		c        controller.Controller
+		mockBid  *mockBidManager
+		mockProj *mockProjectManager
 	)
 
 	BeforeEach(func() {
@@ -84,16 +178,16 @@ var _ = Describe("Controller", func() {
 		rec = httptest.NewRecorder()
 		mockBid = &mockBidManager{}
 		mockProj = &mockProjectManager{}
-		c = controller.NewController(mockBid, mockProj)
+		c = controller.NewController(mockBid, mockProj, auth.NewTokenManager("test-secret", 0, ""), controller.Options{})
 	})
 
 	// --- CreateProject ---
 	Describe("CreateProject", func() {
 		It("should create a project successfully", func() {
-			body := `{"Name":"Test Project"}`
+			body := `{"id":"p1","seller_id":"s1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateProject(ctx)
 
@@ -105,7 +199,7 @@ var _ = Describe("Controller", func() {
 		It("should return 400 on invalid JSON", func() {
 			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString("{invalid"))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateProject(ctx)
 
@@ -115,10 +209,10 @@ var _ = Describe("Controller", func() {
 
 		It("should return 500 when ProjectManager fails", func() {
 			mockProj.createProjectErr = errors.New("db error")
-			body := `{"Name":"Test Project"}`
+			body := `{"id":"p1","seller_id":"s1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateProject(ctx)
 
@@ -130,10 +224,10 @@ var _ = Describe("Controller", func() {
 	// --- CreateSeller ---
 	Describe("CreateSeller", func() {
 		It("should create a seller successfully", func() {
-			body := `{"Name":"Seller1"}`
+			body := `{"id":"s1","seller_name":"Seller1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-seller", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateSeller(ctx)
 
@@ -144,10 +238,10 @@ var _ = Describe("Controller", func() {
 
 		It("should return 500 when ProjectManager fails", func() {
 			mockProj.createSellerErr = errors.New("db error")
-			body := `{"Name":"Seller1"}`
+			body := `{"id":"s1","seller_name":"Seller1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-seller", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateSeller(ctx)
 
@@ -159,10 +253,10 @@ var _ = Describe("Controller", func() {
 	// --- CreateBuyer ---
 	Describe("CreateBuyer", func() {
 		It("should create a buyer successfully", func() {
-			body := `{"Name":"Buyer1"}`
+			body := `{"id":"u1","buyer_name":"Buyer1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-buyer", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateBuyer(ctx)
 
@@ -173,10 +267,10 @@ var _ = Describe("Controller", func() {
 
 		It("should return 500 when ProjectManager fails", func() {
 			mockProj.createBuyerErr = errors.New("db error")
-			body := `{"Name":"Buyer1"}`
+			body := `{"id":"u1","buyer_name":"Buyer1"}`
 			req := httptest.NewRequest(http.MethodPost, "/create-buyer", bytes.NewBufferString(body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.CreateBuyer(ctx)
 
@@ -188,11 +282,11 @@ var _ = Describe("Controller", func() {
 	// --- UpdateBID ---
 	Describe("UpdateBID", func() {
 		It("should update a bid successfully", func() {
-			bid := project.BID{Amount: 100}
+			bid := project.BID{ID: "b1", BuyerID: "u1", Amount: 100}
 			data, _ := json.Marshal(bid)
 			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.UpdateBID(ctx)
 
@@ -203,25 +297,102 @@ var _ = Describe("Controller", func() {
 
 		It("should return 500 when BidManager fails", func() {
 			mockBid.doBIDErr = errors.New("update failed")
-			bid := project.BID{Amount: 100}
+			bid := project.BID{ID: "b1", BuyerID: "u1", Amount: 100}
 			data, _ := json.Marshal(bid)
 			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.UpdateBID(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
 		})
+
+		It("should return 409 when the project is not open", func() {
+			mockBid.doBIDErr = project.ErrProjectNotOpen
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u1", Amount: 100})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateBID(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(project.ErrProjectNotOpen.Error()))
+		})
+
+		It("should return 422 when the bidding window has ended", func() {
+			mockBid.doBIDErr = project.ErrBiddingWindowEnded
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u1", Amount: 100})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateBID(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	// --- UpdateProjectStatus ---
+	Describe("UpdateProjectStatus", func() {
+		It("should move the project to the requested status", func() {
+			req := httptest.NewRequest(http.MethodPut, "/update-project-status?projectID=123&status=cancelled", nil)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateProjectStatus(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(mockProj.updateStatusValue).To(Equal(project.StatusCancelled))
+		})
+
+		It("should return 422 for an unknown status", func() {
+			req := httptest.NewRequest(http.MethodPut, "/update-project-status?projectID=123&status=bogus", nil)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateProjectStatus(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(mockProj.updateStatusCalled).To(BeFalse())
+		})
+
+		It("should return 409 for a disallowed transition", func() {
+			mockProj.updateStatusErr = project.ErrInvalidTransition
+			req := httptest.NewRequest(http.MethodPut, "/update-project-status?projectID=123&status=open", nil)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateProjectStatus(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should map a wrapped error like the error it wraps", func() {
+			mockProj.updateStatusErr = fmt.Errorf("project 123: %w", project.ErrInvalidTransition)
+			req := httptest.NewRequest(http.MethodPut, "/update-project-status?projectID=123&status=open", nil)
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			err := c.UpdateProjectStatus(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			var problem controller.Problem
+			Expect(json.Unmarshal(rec.Body.Bytes(), &problem)).To(Succeed())
+			Expect(problem.Code).To(Equal("invalid_transition"))
+		})
 	})
 
 	// --- GetProjects ---
 	Describe("GetProjects", func() {
 		It("should return projects successfully", func() {
-			mockProj.getProjectsRes = []project.ProjectDetails{{Name: "P1"}}
+			mockProj.getProjectsRes = []project.ProjectDetails{{ID: "P1"}}
 			req := httptest.NewRequest(http.MethodGet, "/get-projects", nil)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.GetProjects(ctx)
 
@@ -233,7 +404,7 @@ var _ = Describe("Controller", func() {
 		It("should return 500 when ProjectManager fails", func() {
 			mockProj.getProjectsErr = errors.New("db fail")
 			req := httptest.NewRequest(http.MethodGet, "/get-projects", nil)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.GetProjects(ctx)
 
@@ -245,21 +416,25 @@ var _ = Describe("Controller", func() {
 	// --- ComputeBID ---
 	Describe("ComputeBID", func() {
 		It("should compute bid successfully", func() {
-			mockBid.computeResult = "Buyer1"
+			mockBid.computeResult = bidManager.AuctionResult{
+				Winner:        project.Buyer{BuyerName: "Buyer1"},
+				ClearingPrice: 90,
+			}
 			req := httptest.NewRequest(http.MethodPost, "/compute-bid?projectID=123", nil)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.ComputeBID(ctx)
 
 			Expect(err).To(BeNil())
 			Expect(rec.Code).To(Equal(http.StatusOK))
 			Expect(mockBid.computeCalled).To(BeTrue())
+			Expect(rec.Body.String()).To(ContainSubstring(`"clearing_price":90`))
 		})
 
 		It("should return 500 when BidManager fails", func() {
 			mockBid.computeErr = errors.New("calc error")
 			req := httptest.NewRequest(http.MethodPost, "/compute-bid?projectID=123", nil)
-			ctx := e.NewContext(req, rec)
+			ctx := asAdmin(e.NewContext(req, rec))
 
 			err := c.ComputeBID(ctx)
 
@@ -267,4 +442,201 @@ var _ = Describe("Controller", func() {
 			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
 		})
 	})
+
+	// --- Authorization ---
+	Describe("Authorization", func() {
+		It("should return 401 when the caller is not authenticated", func() {
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u1", Amount: 100})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			ctx := e.NewContext(req, rec)
+
+			err := c.UpdateBID(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(mockBid.doBIDCalled).To(BeFalse())
+		})
+
+		It("should let sellers create projects only under their own SellerID", func() {
+			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(`{"id":"p1","seller_id":"s2"}`))
+			ctx := as(e.NewContext(req, rec), auth.RoleSeller, "s1")
+
+			err := c.CreateProject(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(mockProj.createProjectCalled).To(BeFalse())
+		})
+
+		It("should default the SellerID to the calling seller", func() {
+			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(`{"id":"p1"}`))
+			ctx := as(e.NewContext(req, rec), auth.RoleSeller, "s1")
+
+			err := c.CreateProject(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(mockProj.createProjectArg.SellerID).To(Equal("s1"))
+		})
+
+		It("should not let buyers create projects", func() {
+			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(`{"id":"p1","seller_id":"u1"}`))
+			ctx := as(e.NewContext(req, rec), auth.RoleBuyer, "u1")
+
+			Expect(c.CreateProject(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("should let buyers bid only under their own BuyerID", func() {
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u2", Amount: 100})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			ctx := as(e.NewContext(req, rec), auth.RoleBuyer, "u1")
+
+			err := c.UpdateBID(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(mockBid.doBIDCalled).To(BeFalse())
+		})
+
+		It("should let only the owning seller compute bids", func() {
+			mockProj.getProjectRes = project.ProjectDetails{ID: "123", SellerID: "s1"}
+			req := httptest.NewRequest(http.MethodPost, "/compute-bid?projectID=123", nil)
+			ctx := as(e.NewContext(req, rec), auth.RoleSeller, "s2")
+
+			Expect(c.ComputeBID(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(mockBid.computeCalled).To(BeFalse())
+
+			rec = httptest.NewRecorder()
+			ctx = as(e.NewContext(req, rec), auth.RoleSeller, "s1")
+
+			Expect(c.ComputeBID(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(mockBid.computeCalled).To(BeTrue())
+		})
+
+		It("should require a bearer token on protected routes", func() {
+			tokenManager := auth.NewTokenManager("test-secret", 0, "")
+			c.AttachHandlers(e)
+
+			req := httptest.NewRequest(http.MethodGet, "/get-projects", nil)
+			e.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get(echo.HeaderWWWAuthenticate)).To(Equal("Bearer"))
+
+			token, _, err := tokenManager.IssueToken(auth.RoleBuyer, "u1")
+			Expect(err).ToNot(HaveOccurred())
+			rec = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodGet, "/get-projects", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			e.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	// --- Validation & problem details ---
+	Describe("Validation", func() {
+		problemOf := func(rec *httptest.ResponseRecorder) controller.Problem {
+			var p controller.Problem
+			Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(controller.MIMEApplicationProblemJSON))
+			Expect(json.Unmarshal(rec.Body.Bytes(), &p)).To(Succeed())
+			return p
+		}
+
+		It("should reject a bid with a non-positive amount and no ID", func() {
+			data, _ := json.Marshal(project.BID{BuyerID: "u1", Amount: -5})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			Expect(c.UpdateBID(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(mockBid.doBIDCalled).To(BeFalse())
+
+			p := problemOf(rec)
+			Expect(p.Status).To(Equal(http.StatusUnprocessableEntity))
+			Expect(p.Code).To(Equal(controller.CodeValidationFailed))
+			Expect(p.Instance).To(Equal("/update-bid"))
+			Expect(p.Errors).To(ConsistOf(
+				validation.FieldError{Field: "id", Code: validation.CodeRequired, Message: "is required"},
+				validation.FieldError{Field: "amount", Code: validation.CodeTooSmall, Message: "must be at least 1"},
+			))
+		})
+
+		It("should require the projectID query param", func() {
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u1", Amount: 5})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid", bytes.NewBuffer(data))
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			Expect(c.UpdateBID(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(problemOf(rec).Errors[0].Field).To(Equal("projectID"))
+		})
+
+		It("should reject bids from buyers that do not exist", func() {
+			mockProj.getBuyerErr = project.ErrBuyerNotFound
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "ghost", Amount: 5})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			Expect(c.UpdateBID(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(problemOf(rec).Errors).To(ConsistOf(
+				validation.FieldError{Field: "buyer_id", Code: validation.CodeNotFound, Message: "buyer does not exist"},
+			))
+		})
+
+		It("should reject projects without a SellerID or with an unknown seller", func() {
+			req := httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(`{"id":"p1"}`))
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			Expect(c.CreateProject(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(problemOf(rec).Errors[0].Field).To(Equal("seller_id"))
+
+			mockProj.getSellerErr = project.ErrSellerNotFound
+			rec = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodPost, "/create-project", bytes.NewBufferString(`{"id":"p1","seller_id":"ghost","strategy":"dutch"}`))
+			ctx = asAdmin(e.NewContext(req, rec))
+
+			Expect(c.CreateProject(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(problemOf(rec).Errors).To(HaveLen(2))
+			Expect(mockProj.createProjectCalled).To(BeFalse())
+		})
+
+		It("should report malformed bodies as 400 problems", func() {
+			req := httptest.NewRequest(http.MethodPost, "/create-buyer", bytes.NewBufferString("{invalid"))
+			ctx := e.NewContext(req, rec)
+
+			Expect(c.CreateBuyer(ctx)).To(Succeed())
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(problemOf(rec).Code).To(Equal(controller.CodeMalformedBody))
+		})
+
+		It("should not leak internal error details", func() {
+			mockProj.createBuyerErr = errors.New("connection refused to 10.0.0.5")
+			req := httptest.NewRequest(http.MethodPost, "/create-buyer", bytes.NewBufferString(`{"id":"u1"}`))
+			ctx := e.NewContext(req, rec)
+
+			Expect(c.CreateBuyer(ctx)).To(Succeed())
+			p := problemOf(rec)
+			Expect(p.Status).To(Equal(http.StatusInternalServerError))
+			Expect(p.Code).To(Equal(controller.CodeInternal))
+			Expect(rec.Body.String()).ToNot(ContainSubstring("10.0.0.5"))
+		})
+
+		It("should give domain errors a machine-readable code", func() {
+			mockBid.doBIDErr = project.ErrProjectNotOpen
+			data, _ := json.Marshal(project.BID{ID: "b1", BuyerID: "u1", Amount: 5})
+			req := httptest.NewRequest(http.MethodPut, "/update-bid?projectID=123", bytes.NewBuffer(data))
+			ctx := asAdmin(e.NewContext(req, rec))
+
+			Expect(c.UpdateBID(ctx)).To(Succeed())
+			p := problemOf(rec)
+			Expect(p.Code).To(Equal("project_not_open"))
+			Expect(p.Title).To(Equal("Conflict"))
+			Expect(p.Detail).To(Equal(project.ErrProjectNotOpen.Error()))
+		})
+	})
 })
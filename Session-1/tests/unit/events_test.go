@@ -0,0 +1,180 @@
+package events_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+// received drains the events queued on sub without waiting for more.
+func received(sub *events.Subscription) []events.Event {
+	var got []events.Event
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return got
+			}
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func types(list []events.Event) []string {
+	names := []string{}
+	for _, event := range list {
+		names = append(names, event.Type)
+	}
+	return names
+}
+
+var _ = Describe("Hub", func() {
+	var hub *events.Hub
+
+	publish := func(eventType, projectID string) {
+		hub.Publish(events.Event{Type: eventType, ProjectID: projectID, Time: time.Now()})
+	}
+
+	BeforeEach(func() {
+		hub = events.NewHub(events.HubConfig{History: 4, Buffer: 2})
+	})
+
+	It("delivers a project's events to its subscribers with increasing IDs", func() {
+		sub := hub.Subscribe("p1", 0)
+		other := hub.Subscribe("p2", 0)
+		publish(events.BidPlaced, "p1")
+		publish(events.BidPlaced, "p2")
+		publish(events.LeaderChanged, "p1")
+
+		got := received(sub)
+		Expect(types(got)).To(Equal([]string{events.BidPlaced, events.LeaderChanged}))
+		Expect(got[1].ID).To(BeNumerically(">", got[0].ID))
+		Expect(types(received(other))).To(Equal([]string{events.BidPlaced}))
+	})
+
+	It("replays the events published after Last-Event-ID", func() {
+		sub := hub.Subscribe("p1", 0)
+		publish(events.BidPlaced, "p1")
+		publish(events.BidRetracted, "p1")
+		publish(events.BidPlaced, "p2")
+		publish(events.LeaderChanged, "p1")
+		first := received(sub)[0]
+		sub.Close()
+
+		resumed := hub.Subscribe("p1", first.ID)
+		Expect(types(received(resumed))).To(Equal([]string{events.BidRetracted, events.LeaderChanged}))
+	})
+
+	It("asks the subscriber to reload when the missed events are no longer kept", func() {
+		sub := hub.Subscribe("p1", 0)
+		publish(events.BidPlaced, "p1")
+		first := received(sub)[0]
+		sub.Close()
+		for i := 0; i < 5; i++ {
+			publish(events.BidPlaced, "p2")
+		}
+
+		Expect(types(received(hub.Subscribe("p1", first.ID)))).To(Equal([]string{events.StreamReset}))
+		Expect(types(received(hub.Subscribe("p1", 42)))).To(Equal([]string{events.StreamReset}))
+	})
+
+	It("drops subscribers that fall behind without blocking the publisher", func() {
+		slow := hub.Subscribe("p1", 0)
+		for i := 0; i < 3; i++ {
+			publish(events.BidPlaced, "p1")
+		}
+
+		Expect(received(slow)).To(HaveLen(2))
+		_, open := <-slow.Events()
+		Expect(open).To(BeFalse())
+		Expect(slow.Err()).To(Equal(events.ErrSlowConsumer))
+	})
+
+	It("ends every subscription when closed", func() {
+		sub := hub.Subscribe("p1", 0)
+		hub.Close()
+		publish(events.BidPlaced, "p1")
+
+		Expect(received(sub)).To(BeEmpty())
+		Expect(sub.Err()).To(Equal(events.ErrHubClosed))
+		Expect(hub.Subscribe("p1", 0).Err()).To(Equal(events.ErrHubClosed))
+		sub.Close()
+	})
+
+	It("is a Publisher alongside others", func() {
+		sub := hub.Subscribe("p1", 0)
+		events.NewMultiPublisher(events.Discard, hub).Publish(events.Event{Type: events.ProjectClosed, ProjectID: "p1"})
+		Expect(types(received(sub))).To(Equal([]string{events.ProjectClosed}))
+	})
+})
+
+var _ = Describe("BidManager events", func() {
+	var (
+		hub *events.Hub
+		sub *events.Subscription
+		bm  bidManager.BidManager
+	)
+	ctx := context.TODO()
+
+	BeforeEach(func() {
+		pm := project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p1", SellerID: "s1"})).To(Succeed())
+
+		hub = events.NewHub(events.HubConfig{})
+		sub = hub.Subscribe("p1", 0)
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Publisher: hub})
+	})
+
+	It("publishes bids and changes of the leading bid", func() {
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 300}, 0)).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b2", BuyerID: "buyer2", Amount: 400}, 0)).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b2", BuyerID: "buyer2", Amount: 200}, 0)).To(Succeed())
+
+		got := received(sub)
+		Expect(types(got)).To(Equal([]string{
+			events.BidPlaced, events.LeaderChanged, // b1 is the first bid
+			events.BidPlaced,                       // b2 ranks behind b1
+			events.BidPlaced, events.LeaderChanged, // b2 undercuts b1
+		}))
+		Expect(got[3].Data).To(Equal(bidManager.BidActivity{
+			BidID: "b2", BuyerID: "buyer2", Amount: 200, Revision: 2, Action: project.ActionAmended,
+		}))
+		Expect(got[4].Data).To(Equal(&bidManager.BidActivity{BidID: "b2", BuyerID: "buyer2", Amount: 200, Revision: 2}))
+	})
+
+	It("publishes retractions and the award", func() {
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 300}, 0)).To(Succeed())
+		Expect(bm.RetractBid(ctx, "p1", "b1", "mistake")).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b2", BuyerID: "buyer2", Amount: 400}, 0)).To(Succeed())
+		_, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		got := received(sub)
+		Expect(types(got)).To(Equal([]string{
+			events.BidPlaced, events.LeaderChanged,
+			events.BidRetracted, events.LeaderChanged,
+			events.BidPlaced, events.LeaderChanged,
+			events.ProjectAwarded,
+		}))
+		Expect(got[3].Data).To(BeNil())
+		Expect(got[6].Data.(bidManager.AuctionResult).WinningBid.ID).To(Equal("b2"))
+	})
+})
@@ -0,0 +1,83 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/health"
+	"github.com/labstack/echo"
+)
+
+var _ = Describe("HealthChecker", func() {
+	var (
+		e      *echo.Echo
+		checks map[string]health.Check
+	)
+
+	ok := func(context.Context) error { return nil }
+
+	get := func(path string) (int, health.Report) {
+		checker := health.NewHealthChecker(20*time.Millisecond, checks)
+		checker.AttachHandlers(e)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var report health.Report
+		Expect(json.Unmarshal(rec.Body.Bytes(), &report)).To(Succeed())
+		return rec.Code, report
+	}
+
+	BeforeEach(func() {
+		e = echo.New()
+		checks = map[string]health.Check{"mongo": ok, "cache": ok}
+	})
+
+	It("reports liveness without running the checks", func() {
+		checks["mongo"] = func(context.Context) error {
+			Fail("liveness must not check dependencies")
+			return nil
+		}
+		code, report := get("/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(report.Status).To(Equal(health.StatusOK))
+	})
+
+	It("is ready when every dependency is", func() {
+		code, report := get("/readyz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(report.Status).To(Equal(health.StatusOK))
+		Expect(report.Checks).To(Equal(map[string]health.CheckResult{
+			"mongo": {Status: health.StatusOK},
+			"cache": {Status: health.StatusOK},
+		}))
+	})
+
+	It("reports each failing dependency with 503", func() {
+		checks["mongo"] = func(context.Context) error { return errors.New("server selection timeout") }
+		code, report := get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(report.Status).To(Equal(health.StatusUnavailable))
+		Expect(report.Checks["mongo"]).To(Equal(health.CheckResult{
+			Status: health.StatusUnavailable,
+			Error:  "server selection timeout",
+		}))
+		Expect(report.Checks["cache"].Status).To(Equal(health.StatusOK))
+	})
+
+	It("fails checks that outlive the timeout", func() {
+		checks["mongo"] = func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		code, report := get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(report.Checks["mongo"].Error).To(Equal(context.DeadlineExceeded.Error()))
+	})
+})
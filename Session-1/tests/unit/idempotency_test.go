@@ -0,0 +1,138 @@
+package idempotency_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		client util.MongoClient
+		store  idempotency.Store
+		now    time.Time
+	)
+	ctx := context.TODO()
+	created := idempotency.Response{Status: 201, ContentType: "application/json", Location: "/v1/projects/p1", ETag: `"1"`, Body: []byte(`{"id":"p1"}`)}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		client = util.NewMemoryMongoClient()
+		store = idempotency.NewStore(client, "projects", idempotency.Options{
+			TTL: time.Hour, OperationTimeout: 10 * time.Second, Now: func() time.Time { return now },
+		})
+	})
+
+	It("claims a new key and replays its response", func() {
+		token, stored, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(BeNil())
+		Expect(store.Complete(ctx, "k1", token, created)).To(Succeed())
+
+		_, stored, err = store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(&created))
+	})
+
+	It("rejects a key reused with a different fingerprint", func() {
+		token, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Complete(ctx, "k1", token, created)).To(Succeed())
+
+		_, _, err = store.Begin(ctx, "k1", "f2")
+		Expect(err).To(Equal(idempotency.ErrKeyReused))
+	})
+
+	It("reports a key whose request is still handled as in progress", func() {
+		_, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = store.Begin(ctx, "k1", "f1")
+		Expect(err).To(Equal(idempotency.ErrInProgress))
+	})
+
+	It("lets a released key be claimed again", func() {
+		token, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Release(ctx, "k1", token)).To(Succeed())
+
+		_, stored, err := store.Begin(ctx, "k1", "f2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(BeNil())
+	})
+
+	It("keeps completed responses when released", func() {
+		token, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Complete(ctx, "k1", token, created)).To(Succeed())
+		Expect(store.Release(ctx, "k1", token)).To(Succeed())
+
+		_, stored, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(&created))
+		Expect(store.Complete(ctx, "k1", token, created)).To(Equal(idempotency.ErrNotClaimed))
+	})
+
+	It("claims keys again once their response expires", func() {
+		token, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Complete(ctx, "k1", token, created)).To(Succeed())
+
+		now = now.Add(time.Hour)
+		_, stored, err := store.Begin(ctx, "k1", "f2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(BeNil())
+
+		var record idempotency.Record
+		Expect(client.FindObject(ctx, "projects", idempotency.Collection, bson.M{"_id": "k1"}, &record)).To(Succeed())
+		Expect(record.Fingerprint).To(Equal("f2"))
+		Expect(record.ExpiresAt).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("claims keys abandoned without a response", func() {
+		abandoned, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+
+		// Two operation timeouts, for the request and for storing its response, and a margin
+		now = now.Add(49 * time.Second)
+		_, _, err = store.Begin(ctx, "k1", "f1")
+		Expect(err).To(Equal(idempotency.ErrInProgress))
+
+		now = now.Add(time.Second)
+		token, stored, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(BeNil())
+		Expect(token).ToNot(Equal(abandoned))
+	})
+
+	It("only completes or releases a key for the request holding its claim", func() {
+		abandoned, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		now = now.Add(time.Minute)
+		token, _, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.Release(ctx, "k1", abandoned)).To(Succeed())
+		Expect(store.Complete(ctx, "k1", abandoned, created)).To(Equal(idempotency.ErrNotClaimed))
+		Expect(store.Complete(ctx, "k1", token, created)).To(Succeed())
+
+		_, stored, err := store.Begin(ctx, "k1", "f1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(&created))
+	})
+
+	It("rejects overlong keys", func() {
+		long := make([]byte, idempotency.MaxKeyLength+1)
+		for i := range long {
+			long[i] = 'k'
+		}
+		_, _, err := store.Begin(ctx, string(long), "f1")
+		Expect(err).To(Equal(idempotency.ErrKeyTooLong))
+	})
+})
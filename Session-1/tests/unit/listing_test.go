@@ -0,0 +1,112 @@
+package listing_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+var _ = Describe("Project listing", func() {
+	var (
+		pm  project.ProjectManager
+		end time.Time
+	)
+	ctx := context.TODO()
+
+	// ids lists every page of query and returns the project IDs in order.
+	ids := func(query project.ProjectQuery) []string {
+		var found []string
+		for {
+			page, err := pm.ListProjects(ctx, query)
+			Expect(err).ToNot(HaveOccurred())
+			for _, p := range page.Projects {
+				found = append(found, p.ID)
+			}
+			if page.NextCursor == "" {
+				return found
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	BeforeEach(func() {
+		end = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		pm = project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm := bidManager.NewBidManager(pm, bidManager.Options{})
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+
+		for _, p := range []project.ProjectDetails{
+			{ID: "p1", SellerID: "s1", EndDate: end.Add(2 * time.Hour), Tags: []string{"roads"}},
+			{ID: "p2", SellerID: "s2", EndDate: end, Tags: []string{"roads", "bridges"}},
+			{ID: "p3", SellerID: "s1", Tags: []string{"bridges"}},
+			{ID: "p4", SellerID: "s1", EndDate: end},
+			{ID: "p5", SellerID: "s2", Status: project.StatusDraft, EndDate: end.Add(time.Hour)},
+		} {
+			Expect(pm.CreateProject(ctx, p)).To(Succeed())
+		}
+		for _, bid := range []struct{ projectID, bidID, buyerID string }{
+			{"p1", "b1", "buyer1"}, {"p1", "b2", "buyer2"}, {"p3", "b3", "buyer1"},
+		} {
+			Expect(bm.DoBID(ctx, bid.projectID, project.BID{ID: bid.bidID, BuyerID: bid.buyerID, Amount: 100}, 0)).To(Succeed())
+		}
+	})
+
+	It("pages through every project in id order", func() {
+		Expect(ids(project.ProjectQuery{Limit: 2})).To(Equal([]string{"p1", "p2", "p3", "p4", "p5"}))
+		Expect(ids(project.ProjectQuery{Sort: "-id", Limit: 2})).To(Equal([]string{"p5", "p4", "p3", "p2", "p1"}))
+	})
+
+	It("filters by seller, status, tag and end date", func() {
+		Expect(ids(project.ProjectQuery{SellerID: "s1"})).To(Equal([]string{"p1", "p3", "p4"}))
+		Expect(ids(project.ProjectQuery{Status: project.StatusDraft})).To(Equal([]string{"p5"}))
+		Expect(ids(project.ProjectQuery{Tag: "bridges"})).To(Equal([]string{"p2", "p3"}))
+		Expect(ids(project.ProjectQuery{EndAfter: end, EndBefore: end.Add(2 * time.Hour)})).To(Equal([]string{"p2", "p4", "p5"}))
+	})
+
+	It("sorts by end date with projects without one first", func() {
+		Expect(ids(project.ProjectQuery{Sort: project.SortEndDate, Limit: 1})).To(Equal([]string{"p3", "p2", "p4", "p5", "p1"}))
+		Expect(ids(project.ProjectQuery{Sort: "-end_date", Limit: 2})).To(Equal([]string{"p1", "p5", "p2", "p4", "p3"}))
+	})
+
+	It("sorts by bid count", func() {
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.BidCount).To(Equal(2))
+		Expect(ids(project.ProjectQuery{Sort: "-bid_count", Limit: 1})).To(Equal([]string{"p1", "p3", "p2", "p4", "p5"}))
+		Expect(ids(project.ProjectQuery{Sort: project.SortBidCount, Limit: 3})).To(Equal([]string{"p2", "p4", "p5", "p3", "p1"}))
+	})
+
+	It("returns only the requested fields", func() {
+		page, err := pm.ListProjects(ctx, project.ProjectQuery{Fields: []string{"tags"}, Sort: project.SortEndDate, Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(page.Projects).To(Equal([]project.ProjectDetails{{ID: "p3", Tags: []string{"bridges"}}}))
+	})
+
+	It("rejects bad sorts, fields, cursors and page sizes", func() {
+		_, err := pm.ListProjects(ctx, project.ProjectQuery{Sort: "price"})
+		Expect(err).To(MatchError(project.ErrInvalidSort))
+		_, err = pm.ListProjects(ctx, project.ProjectQuery{Fields: []string{"colour"}})
+		Expect(err).To(MatchError(project.ErrInvalidField))
+		_, err = pm.ListProjects(ctx, project.ProjectQuery{Limit: project.MaxPageSize + 1})
+		Expect(err).To(MatchError(project.ErrInvalidPageSize))
+
+		page, err := pm.ListProjects(ctx, project.ProjectQuery{Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = pm.ListProjects(ctx, project.ProjectQuery{Sort: project.SortEndDate, Cursor: page.NextCursor})
+		Expect(err).To(MatchError(project.ErrInvalidCursor))
+	})
+})
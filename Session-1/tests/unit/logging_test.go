@@ -0,0 +1,124 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/labstack/echo"
+)
+
+var _ = Describe("Logging", func() {
+	var out bytes.Buffer
+
+	// lines decodes every JSON line written to out.
+	lines := func() []map[string]interface{} {
+		var entries []map[string]interface{}
+		for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+			var entry map[string]interface{}
+			Expect(json.Unmarshal(line, &entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	BeforeEach(func() {
+		out.Reset()
+	})
+
+	Describe("New", func() {
+		It("writes JSON or logfmt and drops lines below the level", func() {
+			log, err := logging.New(&out, logging.FormatLogfmt, "warn")
+			Expect(err).ToNot(HaveOccurred())
+			log.Info("hidden")
+			log.Warn("shown", logging.KeyProjectID, "p1", logging.Err(errors.New("boom")))
+			Expect(out.String()).ToNot(ContainSubstring("hidden"))
+			Expect(out.String()).To(ContainSubstring(`level=WARN msg=shown project_id=p1 error=boom`))
+		})
+
+		It("rejects unknown levels and formats", func() {
+			_, err := logging.New(&out, logging.FormatJSON, "loud")
+			Expect(err).To(MatchError(ContainSubstring(`unknown log level "loud"`)))
+			_, err = logging.New(&out, "xml", "info")
+			Expect(err).To(MatchError(ContainSubstring(`unknown log format "xml"`)))
+		})
+	})
+
+	Describe("context", func() {
+		It("carries the logger and the fields added along the way", func() {
+			log, err := logging.New(&out, logging.FormatJSON, "info")
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx := logging.NewContext(context.Background(), log)
+			ctx = logging.With(ctx, logging.KeyProjectID, "p1")
+			ctx = logging.With(ctx, logging.KeyBidID, "b1")
+			logging.FromContext(ctx).Info("bid-placed")
+
+			Expect(lines()).To(ConsistOf(And(
+				HaveKeyWithValue("msg", "bid-placed"),
+				HaveKeyWithValue("project_id", "p1"),
+				HaveKeyWithValue("bid_id", "b1"),
+			)))
+		})
+
+		It("falls back to the default logger", func() {
+			Expect(logging.FromContext(context.Background())).To(Equal(logging.Default()))
+		})
+	})
+
+	Describe("Middleware", func() {
+		var e *echo.Echo
+
+		serve := func(requestID string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/v1/projects/p1", nil)
+			if requestID != "" {
+				req.Header.Set(echo.HeaderXRequestID, requestID)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		BeforeEach(func() {
+			log, err := logging.New(&out, logging.FormatJSON, "info")
+			Expect(err).ToNot(HaveOccurred())
+			e = echo.New()
+			e.Use(logging.Middleware(log))
+			e.GET("/v1/projects/:id", func(c echo.Context) error {
+				logging.FromContext(c.Request().Context()).Info("handler")
+				return c.NoContent(http.StatusNoContent)
+			})
+		})
+
+		It("uses the client's request ID in every line and echoes it back", func() {
+			rec := serve("abc-123")
+			Expect(rec.Header().Get(echo.HeaderXRequestID)).To(Equal("abc-123"))
+
+			entries := lines()
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0]).To(HaveKeyWithValue("msg", "handler"))
+			Expect(entries[0]).To(HaveKeyWithValue("request_id", "abc-123"))
+			Expect(entries[1]).To(HaveKeyWithValue("msg", "request"))
+			Expect(entries[1]).To(HaveKeyWithValue("request_id", "abc-123"))
+			Expect(entries[1]).To(HaveKeyWithValue("route", "/v1/projects/:id"))
+			Expect(entries[1]).To(HaveKeyWithValue("status", 204.0))
+		})
+
+		It("generates an ID when the header is missing or unusable", func() {
+			generated := serve("").Header().Get(echo.HeaderXRequestID)
+			Expect(generated).To(MatchRegexp(`^[0-9a-f]{32}$`))
+
+			replaced := serve("bad id\nlevel=ERROR").Header().Get(echo.HeaderXRequestID)
+			Expect(replaced).To(MatchRegexp(`^[0-9a-f]{32}$`))
+			Expect(replaced).ToNot(Equal(generated))
+			Expect(out.String()).ToNot(ContainSubstring("bad id"))
+		})
+	})
+})
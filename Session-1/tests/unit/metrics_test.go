@@ -0,0 +1,224 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/21keshav/IBackendApplication/metrics"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+	"github.com/21keshav/IBackendApplication/util/fakes"
+	"github.com/labstack/echo"
+)
+
+type stubBidManager struct {
+	err    error
+	result bidManager.AuctionResult
+}
+
+func (s *stubBidManager) DoBID(context.Context, string, project.BID, int) error {
+	return s.err
+}
+
+func (s *stubBidManager) RetractBid(context.Context, string, string, string) error {
+	return s.err
+}
+
+func (s *stubBidManager) RevealBid(context.Context, string, string, int, string) error {
+	return s.err
+}
+
+func (s *stubBidManager) SetProxyBid(context.Context, string, project.ProxyBid) error {
+	return s.err
+}
+
+func (s *stubBidManager) CancelProxyBid(context.Context, string, string) error {
+	return s.err
+}
+
+func (s *stubBidManager) ComputeBID(context.Context, string, int) (bidManager.AuctionResult, error) {
+	return s.result, s.err
+}
+
+var _ = Describe("Metrics", func() {
+	var m *metrics.Metrics
+
+	scrape := func() string {
+		var out bytes.Buffer
+		_, err := m.Registry().WriteTo(&out)
+		Expect(err).ToNot(HaveOccurred())
+		return out.String()
+	}
+
+	BeforeEach(func() {
+		m = metrics.New()
+	})
+
+	Describe("Registry", func() {
+		It("writes counters and cumulative histograms in the text format", func() {
+			r := metrics.NewRegistry()
+			requests := r.NewCounterVec("requests_total", "Requests.", "path")
+			latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1})
+
+			requests.WithLabelValues(`/a"b`).Add(2)
+			requests.WithLabelValues("/").Inc()
+			latency.WithLabelValues().Observe(0.05)
+			latency.WithLabelValues().Observe(0.5)
+			latency.WithLabelValues().Observe(3)
+
+			var out bytes.Buffer
+			_, err := r.WriteTo(&out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(Equal(`# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{path="/"} 1
+requests_total{path="/a\"b"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.55
+latency_seconds_count 3
+`))
+		})
+
+		It("rejects the wrong number of label values", func() {
+			Expect(func() { m.Bids.WithLabelValues() }).To(Panic())
+		})
+	})
+
+	Describe("HTTP", func() {
+		var e *echo.Echo
+
+		serve := func(method, path string) int {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+			return rec.Code
+		}
+
+		BeforeEach(func() {
+			e = echo.New()
+			e.Use(m.Middleware)
+			e.GET("/v1/projects/:id", func(c echo.Context) error {
+				if c.Param("id") == "missing" {
+					return c.JSON(http.StatusNotFound, nil)
+				}
+				return c.JSON(http.StatusOK, nil)
+			})
+			e.POST("/fail", func(c echo.Context) error {
+				return errors.New("boom")
+			})
+			m.AttachHandlers(e)
+		})
+
+		It("counts requests by route pattern and status", func() {
+			Expect(serve(http.MethodGet, "/v1/projects/p1")).To(Equal(http.StatusOK))
+			Expect(serve(http.MethodGet, "/v1/projects/p2")).To(Equal(http.StatusOK))
+			Expect(serve(http.MethodGet, "/v1/projects/missing")).To(Equal(http.StatusNotFound))
+			Expect(serve(http.MethodPost, "/fail")).To(Equal(http.StatusInternalServerError))
+
+			Expect(m.HTTPRequests.WithLabelValues("GET", "/v1/projects/:id", "200").Value()).To(Equal(2.0))
+			Expect(m.HTTPRequests.WithLabelValues("GET", "/v1/projects/:id", "404").Value()).To(Equal(1.0))
+			Expect(m.HTTPRequests.WithLabelValues("POST", "/fail", "500").Value()).To(Equal(1.0))
+			Expect(m.HTTPDuration.WithLabelValues("GET", "/v1/projects/:id").Count()).To(BeEquivalentTo(3))
+		})
+
+		It("groups requests that match no route", func() {
+			Expect(serve(http.MethodGet, "/wp-admin/1")).To(Equal(http.StatusNotFound))
+			Expect(serve(http.MethodGet, "/wp-admin/2")).To(Equal(http.StatusNotFound))
+			Expect(m.HTTPRequests.WithLabelValues("GET", metrics.UnmatchedRoute, "404").Value()).To(Equal(2.0))
+			Expect(scrape()).ToNot(ContainSubstring("wp-admin"))
+		})
+
+		It("serves the metrics in the Prometheus text format", func() {
+			serve(http.MethodGet, "/v1/projects/p1")
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(metrics.ContentType))
+			Expect(rec.Body.String()).To(ContainSubstring(`bidding_http_requests_total{method="GET",route="/v1/projects/:id",status="200"} 1`))
+			Expect(rec.Body.String()).To(ContainSubstring("# TYPE bidding_mongo_operation_duration_seconds histogram"))
+		})
+	})
+
+	Describe("InstrumentMongoClient", func() {
+		var (
+			fake   *fakes.FakeMongoClient
+			client util.MongoClient
+			ctx    = context.TODO()
+		)
+
+		BeforeEach(func() {
+			fake = &fakes.FakeMongoClient{}
+			client = metrics.InstrumentMongoClient(fake, m)
+		})
+
+		It("times each operation and passes results through", func() {
+			fake.InsertDataReturns(&mongo.InsertOneResult{InsertedID: "x"}, nil)
+			result, err := client.InsertData(ctx, "db", "c", "doc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.InsertedID).To(Equal("x"))
+			Expect(fake.InsertDataCallCount()).To(Equal(1))
+
+			Expect(m.MongoDuration.WithLabelValues("insert").Count()).To(BeEquivalentTo(1))
+			Expect(m.MongoErrors.WithLabelValues("insert").Value()).To(BeZero())
+		})
+
+		It("counts failures but not lookups that find nothing", func() {
+			fake.FindObjectReturns(mongo.ErrNoDocuments)
+			Expect(client.FindObject(ctx, "db", "c", nil, nil)).To(Equal(mongo.ErrNoDocuments))
+			fake.FindObjectsReturns(errors.New("server selection timeout"))
+			Expect(client.FindObjects(ctx, "db", "c", nil, nil)).To(HaveOccurred())
+
+			Expect(m.MongoErrors.WithLabelValues("find_one").Value()).To(BeZero())
+			Expect(m.MongoErrors.WithLabelValues("find").Value()).To(Equal(1.0))
+			Expect(m.MongoDuration.WithLabelValues("find_one").Count()).To(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("InstrumentBidManager", func() {
+		var (
+			stub *stubBidManager
+			bm   bidManager.BidManager
+			ctx  = context.TODO()
+		)
+
+		BeforeEach(func() {
+			stub = &stubBidManager{}
+			bm = metrics.InstrumentBidManager(stub, m)
+		})
+
+		It("counts bids and records accepted amounts", func() {
+			Expect(bm.DoBID(ctx, "p1", project.BID{Amount: 250}, 0)).To(Succeed())
+			stub.err = project.ErrBidTaken
+			Expect(bm.DoBID(ctx, "p1", project.BID{Amount: 900}, 0)).To(Equal(project.ErrBidTaken))
+
+			Expect(m.Bids.WithLabelValues(metrics.ResultAccepted).Value()).To(Equal(1.0))
+			Expect(m.Bids.WithLabelValues(metrics.ResultRejected).Value()).To(Equal(1.0))
+			Expect(m.BidAmounts.WithLabelValues().Sum()).To(Equal(250.0))
+			Expect(scrape()).To(ContainSubstring(`bidding_bid_amount_bucket{le="500"} 1`))
+		})
+
+		It("counts auctions by strategy", func() {
+			stub.result = bidManager.AuctionResult{Strategy: bidManager.StrategySecondPrice}
+			_, err := bm.ComputeBID(ctx, "p1", 0)
+			Expect(err).ToNot(HaveOccurred())
+			stub.result, stub.err = bidManager.AuctionResult{}, bidManager.ErrNoBids
+			_, err = bm.ComputeBID(ctx, "p2", 0)
+			Expect(err).To(Equal(bidManager.ErrNoBids))
+
+			Expect(m.Auctions.WithLabelValues(bidManager.StrategySecondPrice, metrics.ResultAwarded).Value()).To(Equal(1.0))
+			Expect(m.Auctions.WithLabelValues("", metrics.ResultFailed).Value()).To(Equal(1.0))
+		})
+	})
+})
@@ -0,0 +1,211 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/migrations"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+var _ = Describe("Migrations", func() {
+	var (
+		client util.MongoClient
+		names  config.DatabaseDetails
+		pm     project.ProjectManager
+		now    time.Time
+	)
+	ctx := context.TODO()
+
+	newMigrator := func(steps []migrations.Migration) migrations.Migrator {
+		locker := scheduler.NewLocker(client, names.ProjectDBName, "test")
+		return migrations.NewMigrator(client, names, locker, migrations.Options{
+			Migrations: steps, Now: func() time.Time { return now },
+		})
+	}
+
+	// versions returns the versions of statuses.
+	versions := func(statuses []migrations.Status) []int {
+		found := []int{}
+		for _, s := range statuses {
+			found = append(found, s.Version)
+		}
+		return found
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		client = util.NewMemoryMongoClient()
+		names = config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		}
+		pm = project.NewProjectManager(client, names)
+	})
+
+	It("applies pending migrations in version order and records them once", func() {
+		ran := []int{}
+		step := func(version int) migrations.Migration {
+			return migrations.Migration{Version: version, Description: "step", Up: func(context.Context, migrations.Database) error {
+				ran = append(ran, version)
+				return nil
+			}}
+		}
+		migrator := newMigrator([]migrations.Migration{step(2), step(1), step(3)})
+
+		applied, err := migrator.Up(ctx, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions(applied)).To(Equal([]int{1, 2, 3}))
+		Expect(ran).To(Equal([]int{1, 2, 3}))
+
+		applied, err = migrator.Up(ctx, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applied).To(BeEmpty())
+		Expect(ran).To(HaveLen(3))
+
+		statuses, err := migrator.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions(statuses)).To(Equal([]int{1, 2, 3}))
+		for _, s := range statuses {
+			Expect(s.Applied()).To(BeTrue())
+			Expect(s.AppliedAt).To(Equal(now))
+		}
+	})
+
+	It("stops at a failing migration and retries it next time", func() {
+		fail := true
+		migrator := newMigrator([]migrations.Migration{
+			{Version: 1, Up: func(context.Context, migrations.Database) error { return nil }},
+			{Version: 2, Up: func(context.Context, migrations.Database) error {
+				if fail {
+					return errors.New("boom")
+				}
+				return nil
+			}},
+			{Version: 3, Up: func(context.Context, migrations.Database) error { return nil }},
+		})
+
+		applied, err := migrator.Up(ctx, false)
+		Expect(err).To(MatchError(ContainSubstring("boom")))
+		Expect(versions(applied)).To(Equal([]int{1}))
+
+		fail = false
+		applied, err = migrator.Up(ctx, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions(applied)).To(Equal([]int{2, 3}))
+	})
+
+	It("lists migrations recorded by a newer version after the known ones", func() {
+		_, err := client.InsertData(ctx, names.ProjectDBName, migrations.Collection,
+			migrations.Status{Version: 9, Description: "from the future", AppliedAt: now})
+		Expect(err).ToNot(HaveOccurred())
+
+		statuses, err := newMigrator(migrations.All).Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions(statuses)).To(Equal([]int{1, 2, 3, 4, 5, 6, 7, 9}))
+		Expect(statuses[0].Applied()).To(BeFalse())
+		Expect(statuses[7].Applied()).To(BeTrue())
+	})
+
+	It("waits for another instance holding the migrations lease", func() {
+		other := scheduler.NewLocker(client, names.ProjectDBName, "other")
+		Expect(other.Acquire(ctx, "schema-migrations", time.Hour, now)).To(BeTrue())
+
+		waiting, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := newMigrator(migrations.All).Up(waiting, false)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+	})
+
+	Describe("All", func() {
+		It("only lists pending migrations on a dry run", func() {
+			migrator := newMigrator(migrations.All)
+			pending, err := migrator.Up(ctx, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions(pending)).To(Equal([]int{1, 2, 3, 4, 5, 6, 7}))
+
+			// Nothing was recorded and no unique index built
+			pending, err = migrator.Up(ctx, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pending).To(HaveLen(7))
+			Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+			Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+			Expect(pm.GetBuyers(ctx)).To(HaveLen(2))
+		})
+
+		It("rejects duplicate ids once applied", func() {
+			_, err := newMigrator(migrations.All).Up(ctx, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+			Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Equal(project.ErrBuyerExists))
+			Expect(pm.CreateSeller(ctx, project.Seller{ID: "seller1"})).To(Succeed())
+			Expect(pm.CreateSeller(ctx, project.Seller{ID: "seller1"})).To(Equal(project.ErrSellerExists))
+			Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p1", SellerID: "seller1"})).To(Succeed())
+			Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p1", SellerID: "seller2"})).To(Equal(project.ErrProjectExists))
+		})
+
+		It("fails to build a unique index over duplicates until they are removed", func() {
+			Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p1", SellerID: "seller1"})).To(Succeed())
+			Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p1", SellerID: "seller2"})).To(Succeed())
+			migrator := newMigrator(migrations.All)
+
+			applied, err := migrator.Up(ctx, false)
+			Expect(err).To(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+
+			_, err = client.DeleteOne(ctx, names.ProjectDBName, names.CollectionName, bson.M{"seller_id": "seller2"})
+			Expect(err).ToNot(HaveOccurred())
+			applied, err = migrator.Up(ctx, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(HaveLen(7))
+		})
+
+		It("backfills the status, bid count and version of older projects", func() {
+			for _, p := range []bson.M{
+				{"id": "p1", "seller_id": "seller1"},
+				{"id": "p2", "seller_id": "seller1"},
+				{"id": "p3", "seller_id": "seller1", "status": project.StatusOpen, "bid_count": 0, "version": 2},
+			} {
+				_, err := client.InsertData(ctx, names.ProjectDBName, names.CollectionName, p)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			for _, bid := range []bson.M{
+				{"id": "b1", "project_id": "p1", "buyer_id": "buyer1"},
+				{"id": "b2", "project_id": "p1", "buyer_id": "buyer2"},
+			} {
+				_, err := client.InsertData(ctx, names.BidsDBName, names.CollectionName, bid)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := newMigrator(migrations.All).Up(ctx, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			page, err := pm.ListProjects(ctx, project.ProjectQuery{Status: project.StatusOpen, Sort: "-bid_count"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.Projects).To(HaveLen(3))
+			Expect(page.Projects[0].ID).To(Equal("p1"))
+			Expect(page.Projects[0].BidCount).To(Equal(2))
+			Expect(page.Projects[1].BidCount).To(Equal(0))
+			for _, p := range page.Projects[:2] {
+				Expect(p.Version).To(Equal(1))
+			}
+
+			// A count taken back to zero is unset, like one never counted
+			var p3 bson.M
+			Expect(client.FindObject(ctx, names.ProjectDBName, names.CollectionName, bson.M{"id": "p3"}, &p3)).To(Succeed())
+			Expect(p3).ToNot(HaveKey("bid_count"))
+		})
+	})
+})
@@ -0,0 +1,150 @@
+package money_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/money"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+var _ = Describe("Money", func() {
+	It("parses decimal strings exactly in the currency's minor unit", func() {
+		Expect(money.ParseDecimal("12.5", "USD")).To(Equal(1250))
+		Expect(money.ParseDecimal("12", "JPY")).To(Equal(12))
+		Expect(money.ParseDecimal("0.125", "KWD")).To(Equal(125))
+
+		_, err := money.ParseDecimal("12.345", "USD")
+		Expect(err).To(MatchError(money.ErrTooPrecise))
+		_, err = money.ParseDecimal("1e3", "USD")
+		Expect(err).To(MatchError(money.ErrInvalidAmount))
+		_, err = money.ParseDecimal("12", "XYZ")
+		Expect(err).To(MatchError(money.ErrUnknownCurrency))
+	})
+
+	It("formats amounts in major units", func() {
+		Expect(money.Money{Amount: 1250, Currency: "USD"}.String()).To(Equal("12.50 USD"))
+		Expect(money.Money{Amount: 5, Currency: "EUR"}.String()).To(Equal("0.05 EUR"))
+		Expect(money.Money{Amount: 1250, Currency: "JPY"}.String()).To(Equal("1250 JPY"))
+	})
+
+	It("reads bid amounts under both JSON keys", func() {
+		var bid project.BID
+		Expect(json.Unmarshal([]byte(`{"id":"b1","ammount":70}`), &bid)).To(Succeed())
+		Expect(bid.Amount).To(Equal(70))
+		Expect(json.Unmarshal([]byte(`{"amount":"12.50","currency":"EUR"}`), &bid)).To(Succeed())
+		Expect(bid.Amount).To(Equal(1250))
+		Expect(bid.ID).To(Equal("b1"))
+		Expect(json.Unmarshal([]byte(`{"amount":"12.50"}`), &project.BID{})).To(MatchError(ContainSubstring("need a currency")))
+
+		out, err := json.Marshal(bid)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring(`"amount":1250`))
+		Expect(string(out)).To(ContainSubstring(`"ammount":1250`))
+	})
+
+	Describe("rate tables", func() {
+		var (
+			dir   string
+			rates money.RateTable
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "rates")
+			Expect(err).ToNot(HaveOccurred())
+			path := filepath.Join(dir, "rates.toml")
+			Expect(os.WriteFile(path, []byte("[rates]\n\"EUR/USD\" = \"1.0850\"\n\"USD/JPY\" = \"149.50\"\n"), 0o600)).To(Succeed())
+			rates, err = money.LoadRates(path)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("converts both ways, rounding to the target's minor unit", func() {
+			Expect(rates.Convert(money.Money{Amount: 10000, Currency: "EUR"}, "USD")).To(Equal(money.Money{Amount: 10850, Currency: "USD"}))
+			Expect(rates.Convert(money.Money{Amount: 10850, Currency: "USD"}, "EUR")).To(Equal(money.Money{Amount: 10000, Currency: "EUR"}))
+			Expect(rates.Convert(money.Money{Amount: 1001, Currency: "USD"}, "JPY")).To(Equal(money.Money{Amount: 1496, Currency: "JPY"}))
+		})
+
+		It("has no rate for unknown pairs", func() {
+			_, err := rates.Convert(money.Money{Amount: 100, Currency: "EUR"}, "JPY")
+			Expect(err).To(MatchError(money.ErrNoRate))
+		})
+
+		It("rejects malformed rates", func() {
+			_, err := money.NewRateTable(map[string]string{"EUR-USD": "1.1"})
+			Expect(err).To(HaveOccurred())
+			_, err = money.NewRateTable(map[string]string{"EUR/USD": "-1"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Bids in other currencies", func() {
+	var (
+		pm project.ProjectManager
+		bm bidManager.BidManager
+	)
+	ctx := context.TODO()
+
+	BeforeEach(func() {
+		pm = project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		rates, err := money.NewRateTable(map[string]string{"EUR/USD": "1.0850"})
+		Expect(err).ToNot(HaveOccurred())
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Rates: rates})
+
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "usd", SellerID: "s1", Currency: "USD"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "convert", SellerID: "s1", Currency: "USD", CurrencyPolicy: project.CurrencyConvert,
+		})).To(Succeed())
+	})
+
+	It("prices bids without a currency in the project's", func() {
+		Expect(bm.DoBID(ctx, "usd", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 500}, 0)).To(Succeed())
+		bid, err := pm.GetBid(ctx, "usd", "b1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bid.Currency).To(Equal("USD"))
+		Expect(bid.Original).To(BeNil())
+	})
+
+	It("rejects bids in another currency unless the project converts them", func() {
+		bid := project.BID{ID: "b1", BuyerID: "buyer1", Amount: 10000, Currency: "EUR"}
+		Expect(bm.DoBID(ctx, "usd", bid, 0)).To(MatchError(project.ErrCurrencyMismatch))
+
+		Expect(bm.DoBID(ctx, "convert", bid, 0)).To(Succeed())
+		stored, err := pm.GetBid(ctx, "convert", "b1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored.Amount).To(Equal(10850))
+		Expect(stored.Currency).To(Equal("USD"))
+		Expect(*stored.Original).To(Equal(money.Money{Amount: 10000, Currency: "EUR"}))
+		Expect(stored.Revisions[0].Original).ToNot(BeNil())
+
+		bid.Currency = "GBP"
+		Expect(bm.DoBID(ctx, "convert", bid, 0)).To(MatchError(money.ErrNoRate))
+	})
+
+	It("requires a known currency to convert into", func() {
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p2", SellerID: "s1", Currency: "XYZ"})).
+			To(MatchError(money.ErrUnknownCurrency))
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p3", SellerID: "s1", CurrencyPolicy: project.CurrencyConvert})).
+			To(MatchError(project.ErrCurrencyRequired))
+	})
+})
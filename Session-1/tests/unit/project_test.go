@@ -3,14 +3,19 @@ package project_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo" // Ginkgo BDD testing framework
 	. "github.com/onsi/gomega" // Gomega matchers for assertions
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/21keshav/IBackendApplication/config"
 	. "github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
 	"github.com/21keshav/IBackendApplication/util/fakes"
 )
 
@@ -20,6 +25,7 @@ var _ = Describe("Project", func() {
 		fakeMongoClient *fakes.FakeMongoClient // Fake Mongo client used for mocking DB calls
 		dbConfig        config.DatabaseDetails // Database config injected into ProjectManager
 	)
+	ctx := context.TODO()
 
 	// Runs before each test case
 	BeforeEach(func() {
@@ -27,11 +33,11 @@ var _ = Describe("Project", func() {
 			BuyersDBName:   "buyers",
 			SellersDBName:  "sellers",
 			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
 			CollectionName: "Collections",
 		}
-		ctx := context.TODO()
 		fakeMongoClient = &fakes.FakeMongoClient{}
-		pm = NewProjectManager(fakeMongoClient, ctx, dbConfig)
+		pm = NewProjectManager(fakeMongoClient, dbConfig)
 	})
 
 	// --- Tests for CreateProject ---
@@ -62,10 +68,31 @@ var _ = Describe("Project", func() {
 		})
 
 		It("creates project successfully", func() {
-			err := pm.CreateProject(projectDetails)
+			err := pm.CreateProject(ctx, projectDetails)
 			Expect(err).ToNot(HaveOccurred()) // Expect no error
 		})
 
+		It("defaults new projects to open", func() {
+			Expect(pm.CreateProject(ctx, projectDetails)).To(Succeed())
+
+			_, _, _, inserted := fakeMongoClient.InsertDataArgsForCall(0)
+			Expect(inserted.(ProjectDetails).Status).To(Equal(StatusOpen))
+		})
+
+		It("rejects a bidding window that ends before it starts", func() {
+			projectDetails.StartDate = time.Now()
+			projectDetails.EndDate = projectDetails.StartDate.Add(-time.Hour)
+
+			Expect(pm.CreateProject(ctx, projectDetails)).To(Equal(ErrInvalidWindow))
+			Expect(fakeMongoClient.InsertDataCallCount()).To(Equal(0))
+		})
+
+		It("rejects projects created past the open state", func() {
+			projectDetails.Status = StatusAwarded
+
+			Expect(pm.CreateProject(ctx, projectDetails)).To(Equal(ErrInvalidStatus))
+		})
+
 		Context("Errors", func() {
 			BeforeEach(func() {
 				// Simulate Mongo insert failure
@@ -73,7 +100,7 @@ var _ = Describe("Project", func() {
 			})
 
 			It("returns an error", func() {
-				err := pm.CreateProject(projectDetails)
+				err := pm.CreateProject(ctx, projectDetails)
 				Expect(err).To(HaveOccurred()) // Expect error
 			})
 		})
@@ -102,7 +129,7 @@ var _ = Describe("Project", func() {
 		})
 
 		It("creates seller successfully", func() {
-			err := pm.CreateSeller(seller)
+			err := pm.CreateSeller(ctx, seller)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -113,7 +140,7 @@ var _ = Describe("Project", func() {
 			})
 
 			It("returns an error", func() {
-				err := pm.CreateSeller(seller)
+				err := pm.CreateSeller(ctx, seller)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -142,7 +169,7 @@ var _ = Describe("Project", func() {
 		})
 
 		It("creates buyer successfully", func() {
-			err := pm.CreateBuyer(buyer)
+			err := pm.CreateBuyer(ctx, buyer)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -153,9 +180,532 @@ var _ = Describe("Project", func() {
 			})
 
 			It("returns an error", func() {
-				err := pm.CreateBuyer(buyer)
+				err := pm.CreateBuyer(ctx, buyer)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
+
+	// --- Tests for UpdateProjectStatus ---
+	Describe("UpdateProjectStatus", func() {
+		BeforeEach(func() {
+			fakeMongoClient.FindObjectStub = func(_ context.Context, _, _ string, _, result interface{}) error {
+				*result.(*ProjectDetails) = ProjectDetails{ID: "p1", Status: StatusOpen}
+				return nil
+			}
+			fakeMongoClient.UpdateOneReturns(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		})
+
+		It("applies an allowed transition", func() {
+			Expect(pm.UpdateProjectStatus(ctx, "p1", StatusClosed)).To(Succeed())
+			Expect(fakeMongoClient.UpdateOneCallCount()).To(Equal(1))
+		})
+
+		It("rejects a disallowed transition", func() {
+			Expect(pm.UpdateProjectStatus(ctx, "p1", StatusDraft)).To(Equal(ErrInvalidTransition))
+			Expect(fakeMongoClient.UpdateOneCallCount()).To(Equal(0))
+		})
+
+		It("leaves awarding to AwardProject", func() {
+			fakeMongoClient.FindObjectStub = func(_ context.Context, _, _ string, _, result interface{}) error {
+				*result.(*ProjectDetails) = ProjectDetails{ID: "p1", Status: StatusClosed}
+				return nil
+			}
+
+			Expect(pm.UpdateProjectStatus(ctx, "p1", StatusAwarded)).To(Equal(ErrInvalidTransition))
+			Expect(fakeMongoClient.UpdateOneCallCount()).To(Equal(0))
+		})
+
+		It("reports a concurrent transition as a conflict", func() {
+			fakeMongoClient.UpdateOneReturns(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+			Expect(pm.UpdateProjectStatus(ctx, "p1", StatusClosed)).To(Equal(ErrInvalidTransition))
+		})
+	})
+
+	// --- Tests for GetExpiredProjects and AwardProject ---
+	Describe("Closing auctions", func() {
+		var (
+			memoryPM ProjectManager
+			now      time.Time
+		)
+
+		BeforeEach(func() {
+			memoryPM = NewProjectManager(util.NewMemoryMongoClient(), dbConfig)
+			now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			for _, p := range []ProjectDetails{
+				{ID: "ended", SellerID: "s1", EndDate: now.Add(-time.Minute)},
+				{ID: "ends-now", SellerID: "s1", EndDate: now},
+				{ID: "running", SellerID: "s1", EndDate: now.Add(time.Minute)},
+				{ID: "no-deadline", SellerID: "s1"},
+				{ID: "draft", SellerID: "s1", Status: StatusDraft, EndDate: now.Add(-time.Minute)},
+			} {
+				Expect(memoryPM.CreateProject(ctx, p)).To(Succeed())
+			}
+		})
+
+		It("finds open projects whose end date has passed", func() {
+			expired, err := memoryPM.GetExpiredProjects(ctx, now)
+			Expect(err).ToNot(HaveOccurred())
+
+			ids := []string{}
+			for _, p := range expired {
+				ids = append(ids, p.ID)
+			}
+			Expect(ids).To(ConsistOf("ended", "ends-now"))
+		})
+
+		It("records the award on a closed project", func() {
+			award := Award{BuyerID: "b1", BidID: "bid1", Price: 10, Strategy: "reverse", ClosedAt: now}
+			Expect(memoryPM.UpdateProjectStatus(ctx, "ended", StatusClosed)).To(Succeed())
+			Expect(memoryPM.AwardProject(ctx, "ended", award, 0)).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "ended")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Status).To(Equal(StatusAwarded))
+			Expect(saved.Award).ToNot(BeNil())
+			Expect(saved.Award.BuyerID).To(Equal("b1"))
+			Expect(saved.Award.ClosedAt.Equal(now)).To(BeTrue())
+		})
+
+		It("awards an open project in the same write that ends its bidding", func() {
+			Expect(memoryPM.AwardProject(ctx, "running", Award{BuyerID: "b1"}, 0)).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "running")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Status).To(Equal(StatusAwarded))
+			Expect(saved.Award.BuyerID).To(Equal("b1"))
+		})
+
+		It("awards a project only once", func() {
+			Expect(memoryPM.UpdateProjectStatus(ctx, "ended", StatusClosed)).To(Succeed())
+			Expect(memoryPM.AwardProject(ctx, "ended", Award{BuyerID: "b1"}, 0)).To(Succeed())
+
+			Expect(memoryPM.AwardProject(ctx, "ended", Award{BuyerID: "b2"}, 0)).To(Equal(ErrInvalidTransition))
+			Expect(memoryPM.AwardProject(ctx, "draft", Award{BuyerID: "b2"}, 0)).To(Equal(ErrInvalidTransition))
+			Expect(memoryPM.AwardProject(ctx, "missing", Award{BuyerID: "b2"}, 0)).To(Equal(ErrProjectNotFound))
+		})
+
+		It("ignores an award sent with a new project", func() {
+			Expect(memoryPM.CreateProject(ctx, ProjectDetails{ID: "p2", SellerID: "s1", Award: &Award{BuyerID: "b1"}})).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "p2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Award).To(BeNil())
+		})
+	})
+
+	// --- Tests for bid storage ---
+	Describe("Bids", func() {
+		var (
+			memoryPM ProjectManager
+			at       time.Time
+		)
+
+		// placed returns the arguments of AddBidRevision for a new bid on p1.
+		placed := func(id, buyer string, amount int) (string, BID, BidRevision) {
+			return "p1", BID{ID: id, BuyerID: buyer},
+				BidRevision{Revision: 1, Action: ActionPlaced, Amount: amount, At: at}
+		}
+
+		// place adds a new bid on p1.
+		place := func(id, buyer string, amount int) error {
+			projectID, bid, rev := placed(id, buyer, amount)
+			return memoryPM.AddBidRevision(ctx, projectID, bid, rev, BidCondition{})
+		}
+
+		BeforeEach(func() {
+			memoryPM = NewProjectManager(util.NewMemoryMongoClient(), dbConfig)
+			at = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			Expect(memoryPM.CreateProject(ctx, ProjectDetails{ID: "p1", SellerID: "s1"})).To(Succeed())
+		})
+
+		It("stores bids outside the project document", func() {
+			Expect(place("b1", "buyer1", 10)).To(Succeed())
+
+			var doc bson.M
+			Expect(memoryPM.(*ProjectManagerImpl).MongoClient.FindObject(ctx, dbConfig.ProjectDBName,
+				dbConfig.CollectionName, bson.M{"id": "p1"}, &doc)).To(Succeed())
+			Expect(doc).ToNot(HaveKey("bids"))
+
+			bid, err := memoryPM.GetBid(ctx, "p1", "b1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bid.ProjectID).To(Equal("p1"))
+			Expect(bid.Amount).To(Equal(10))
+			Expect(bid.Status).To(Equal(BidActive))
+			Expect(bid.Revision).To(Equal(1))
+			Expect(bid.CreatedAt.Equal(at)).To(BeTrue())
+			Expect(bid.Revisions).To(HaveLen(1))
+		})
+
+		It("appends revisions and updates the current state", func() {
+			_, bid, rev := placed("b1", "buyer1", 10)
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Succeed())
+			later := at.Add(time.Minute)
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 2, Action: ActionAmended, Amount: 8, At: later}, BidCondition{})).To(Succeed())
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 3, Action: ActionRetracted, Amount: 8, Reason: "oops", At: later}, BidCondition{})).To(Succeed())
+
+			saved, err := memoryPM.GetBid(ctx, "p1", "b1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Amount).To(Equal(8))
+			Expect(saved.Status).To(Equal(BidRetracted))
+			Expect(saved.RetractReason).To(Equal("oops"))
+			Expect(saved.Revision).To(Equal(3))
+			Expect(saved.UpdatedAt.Equal(later)).To(BeTrue())
+			Expect(saved.CreatedAt.Equal(at)).To(BeTrue())
+
+			amounts := []int{}
+			for _, r := range saved.Revisions {
+				amounts = append(amounts, r.Amount)
+			}
+			Expect(amounts).To(Equal([]int{10, 8, 8}))
+		})
+
+		It("reports a stale revision as a conflict", func() {
+			_, bid, rev := placed("b1", "buyer1", 10)
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Succeed())
+
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Equal(ErrBidConflict))
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 3, Amount: 5}, BidCondition{})).To(Equal(ErrBidConflict))
+			Expect(memoryPM.AddBidRevision(ctx, "p1", BID{ID: "nope"}, BidRevision{Revision: 2, Amount: 5}, BidCondition{})).To(Equal(ErrBidNotFound))
+		})
+
+		It("lets exactly one of many concurrent amendments win each revision", func() {
+			_, bid, rev := placed("b1", "buyer1", 100)
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Succeed())
+
+			const n = 50
+			var (
+				wg   sync.WaitGroup
+				mu   sync.Mutex
+				wins int
+			)
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					err := memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 2, Action: ActionAmended, Amount: i + 1}, BidCondition{})
+					if err == nil {
+						mu.Lock()
+						wins++
+						mu.Unlock()
+						return
+					}
+					Expect(err).To(Equal(ErrBidConflict))
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(wins).To(Equal(1))
+			saved, err := memoryPM.GetBid(ctx, "p1", "b1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Revisions).To(HaveLen(2))
+		})
+
+		It("keeps every concurrent bid", func() {
+			const n = 100
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(place(fmt.Sprintf("bid-%d", i), "buyer", i+1)).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			bids, err := memoryPM.GetBids(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bids).To(HaveLen(n))
+		})
+
+		It("lists a project's bids ordered by ID", func() {
+			Expect(memoryPM.CreateProject(ctx, ProjectDetails{ID: "p2", SellerID: "s1"})).To(Succeed())
+			Expect(place("b2", "buyer1", 10)).To(Succeed())
+			Expect(place("b1", "buyer2", 20)).To(Succeed())
+			_, bid, rev := placed("b3", "buyer1", 30)
+			Expect(memoryPM.AddBidRevision(ctx, "p2", bid, rev, BidCondition{})).To(Succeed())
+
+			bids, err := memoryPM.GetBids(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bids).To(HaveLen(2))
+			Expect(bids[0].ID).To(Equal("b1"))
+			Expect(bids[1].ID).To(Equal("b2"))
+
+			_, err = memoryPM.GetBids(ctx, "missing")
+			Expect(err).To(Equal(ErrProjectNotFound))
+		})
+
+		It("rejects bid ids that are not valid keys", func() {
+			for _, id := range []string{"", "a.b", "$set"} {
+				_, bid, rev := placed(id, "buyer1", 10)
+				Expect(pm.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Equal(ErrInvalidBidID))
+			}
+			Expect(fakeMongoClient.UpsertOneCallCount()).To(Equal(0))
+		})
+
+		It("reports a missing bid as not found", func() {
+			fakeMongoClient.FindObjectReturns(mongo.ErrNoDocuments)
+
+			_, err := pm.GetBid(ctx, "p1", "b1")
+			Expect(err).To(Equal(ErrBidNotFound))
+		})
+	})
+
+	// --- Tests for project versions ---
+	Describe("Versions", func() {
+		var memoryPM ProjectManager
+
+		version := func() int {
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			return saved.Version
+		}
+
+		BeforeEach(func() {
+			memoryPM = NewProjectManager(util.NewMemoryMongoClient(), dbConfig)
+			end := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			p := ProjectDetails{ID: "p1", SellerID: "s1", EndDate: end, SoftCloseMinutes: 5, ExtendMinutes: 5, Version: 9}
+			Expect(memoryPM.CreateProject(ctx, p)).To(Succeed())
+		})
+
+		It("starts at 1 and is incremented by every write", func() {
+			Expect(version()).To(Equal(1))
+
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}})).To(Succeed())
+			Expect(version()).To(Equal(2))
+
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}, BidCondition{})).To(Succeed())
+			Expect(version()).To(Equal(3))
+
+			Expect(memoryPM.UpdateProjectStatus(ctx, "p1", StatusClosed)).To(Succeed())
+			Expect(memoryPM.AwardProject(ctx, "p1", Award{BuyerID: "buyer1", BidID: "b1"}, 0)).To(Succeed())
+			Expect(version()).To(Equal(5))
+		})
+
+		It("extends the end date in the same write as the bid", func() {
+			end := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10, At: end.Add(-time.Minute)}
+			extend := BidCondition{Version: 1, EndDate: end, ExtendTo: end.Add(5 * time.Minute)}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, extend)).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.EndDate).To(BeTemporally("==", end.Add(5*time.Minute)))
+			Expect(saved.Extensions).To(Equal(1))
+			Expect(saved.BidCount).To(Equal(1))
+			Expect(saved.Version).To(Equal(2))
+
+			amended := BidRevision{Revision: 2, Action: ActionAmended, Amount: 20, At: end}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, amended, BidCondition{EndDate: end, ExtendTo: end.Add(5 * time.Minute)})).To(Equal(ErrEndDateMoved))
+			Expect(memoryPM.UpdateProjectStatus(ctx, "p1", StatusClosed)).To(Succeed())
+			extend = BidCondition{EndDate: end.Add(5 * time.Minute), ExtendTo: end.Add(10 * time.Minute)}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, amended, extend)).To(Equal(ErrProjectNotOpen))
+
+			stored, err := memoryPM.GetBid(ctx, "p1", "b1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Revision).To(Equal(1))
+			Expect(version()).To(Equal(3))
+		})
+
+		It("only edits a project still at the version given", func() {
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}, Version: 1})).To(Succeed())
+			Expect(version()).To(Equal(2))
+
+			stale := ProjectDetails{Tags: []string{"metal"}, Version: 1}
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", stale)).To(Equal(ErrVersionMismatch))
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Version: 1})).To(Equal(ErrVersionMismatch))
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Tags).To(Equal([]string{"wood"}))
+			Expect(saved.Version).To(Equal(2))
+		})
+
+		It("reports a version changed between reading and writing", func() {
+			fakeMongoClient.FindObjectStub = func(_ context.Context, _, _ string, _, result interface{}) error {
+				*result.(*ProjectDetails) = ProjectDetails{ID: "p1", Status: StatusOpen, Version: 3}
+				return nil
+			}
+			fakeMongoClient.UpdateOneReturns(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+			Expect(pm.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}, Version: 3})).To(Equal(ErrVersionMismatch))
+			_, _, _, filter, _ := fakeMongoClient.UpdateOneArgsForCall(0)
+			Expect(filter).To(HaveKeyWithValue("version", 3))
+		})
+
+		It("is incremented by every bid revision, in the write that counts new bids", func() {
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}, BidCondition{Version: 1})).To(Succeed())
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 2, Action: ActionAmended, Amount: 20}, BidCondition{Version: 2})).To(Succeed())
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 3, Action: ActionRetracted, Amount: 20}, BidCondition{})).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Version).To(Equal(4))
+			Expect(saved.BidCount).To(Equal(1))
+		})
+
+		It("only places bids on a project still at the version given", func() {
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}})).To(Succeed())
+
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{Version: 1})).To(Equal(ErrVersionMismatch))
+			_, err := memoryPM.GetBid(ctx, "p1", "b1")
+			Expect(err).To(Equal(ErrBidNotFound))
+			Expect(memoryPM.AddBidRevision(ctx, "missing", bid, rev, BidCondition{Version: 1})).To(Equal(ErrProjectNotFound))
+		})
+
+		It("does not count a bid that could not be created", func() {
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Succeed())
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Equal(ErrBidConflict))
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.BidCount).To(Equal(1))
+			Expect(saved.Version).To(Equal(2))
+		})
+
+		It("takes back the count and version when the bid write fails", func() {
+			client := &failingUpsertClient{MongoClient: util.NewMemoryMongoClient(), err: errors.New("connection reset")}
+			memoryPM = NewProjectManager(client, dbConfig)
+			end := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			p := ProjectDetails{ID: "p1", SellerID: "s1", EndDate: end, SoftCloseMinutes: 5, ExtendMinutes: 5}
+			Expect(memoryPM.CreateProject(ctx, p)).To(Succeed())
+
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10, At: end.Add(-time.Minute)}
+			extend := BidCondition{Version: 1, EndDate: end, ExtendTo: end.Add(5 * time.Minute)}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, extend)).To(MatchError("connection reset"))
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.BidCount).To(Equal(0))
+			Expect(saved.Version).To(Equal(1))
+			Expect(saved.EndDate).To(BeTemporally("==", end))
+			Expect(saved.Extensions).To(Equal(0))
+
+			client.err = nil
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, extend)).To(Succeed())
+		})
+
+		It("pages a project whose only bid failed with those never bid on", func() {
+			client := &failingUpsertClient{MongoClient: util.NewMemoryMongoClient(), err: errors.New("connection reset")}
+			memoryPM = NewProjectManager(client, dbConfig)
+			for _, id := range []string{"p1", "p2", "p3"} {
+				Expect(memoryPM.CreateProject(ctx, ProjectDetails{ID: id, SellerID: "s1"})).To(Succeed())
+			}
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(HaveOccurred())
+
+			var listed []string
+			query := ProjectQuery{Sort: SortBidCount, Limit: 1}
+			for pages := 0; pages < 5; pages++ {
+				page, err := memoryPM.ListProjects(ctx, query)
+				Expect(err).ToNot(HaveOccurred())
+				for _, p := range page.Projects {
+					listed = append(listed, p.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			Expect(listed).To(Equal([]string{"p1", "p2", "p3"}))
+		})
+
+		It("takes back the version when an amendment loses a race", func() {
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}, BidCondition{Version: 1})).To(Succeed())
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 2, Action: ActionAmended, Amount: 20}, BidCondition{Version: 2})).To(Succeed())
+
+			stale := BidRevision{Revision: 2, Action: ActionAmended, Amount: 30}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, stale, BidCondition{Version: 3})).To(Equal(ErrBidConflict))
+			Expect(version()).To(Equal(3))
+
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, BidRevision{Revision: 3, Action: ActionAmended, Amount: 30}, BidCondition{Version: 3})).To(Succeed())
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Version).To(Equal(4))
+			Expect(saved.BidCount).To(Equal(1))
+		})
+
+		It("locks the strategy and price rules once the project has a bid", func() {
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{ReservePrice: 50})).To(Succeed())
+
+			bid := BID{ID: "b1", BuyerID: "buyer1"}
+			rev := BidRevision{Revision: 1, Action: ActionPlaced, Amount: 10}
+			Expect(memoryPM.AddBidRevision(ctx, "p1", bid, rev, BidCondition{})).To(Succeed())
+
+			for _, changes := range []ProjectDetails{
+				{Strategy: "second-price"},
+				{ReservePrice: 5},
+				{ReserveHidden: true},
+				{StartPrice: 5},
+				{BidIncrement: 5},
+				{BuyNowPrice: 500},
+			} {
+				Expect(memoryPM.UpdateProjectDetails(ctx, "p1", changes)).To(Equal(ErrTermsLocked))
+			}
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}})).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.ReservePrice).To(Equal(50))
+			Expect(saved.Strategy).To(BeEmpty())
+		})
+
+		It("only deletes or awards a project still at the version given", func() {
+			Expect(memoryPM.DeleteProject(ctx, "p1", 2)).To(Equal(ErrVersionMismatch))
+			Expect(memoryPM.AwardProject(ctx, "p1", Award{BuyerID: "buyer1"}, 2)).To(Equal(ErrVersionMismatch))
+			Expect(version()).To(Equal(1))
+
+			Expect(memoryPM.AwardProject(ctx, "p1", Award{BuyerID: "buyer1"}, 1)).To(Succeed())
+			Expect(memoryPM.DeleteProject(ctx, "p1", 2)).To(Succeed())
+			Expect(memoryPM.DeleteProject(ctx, "p1", 2)).To(Equal(ErrProjectNotFound))
+		})
+
+		It("changes the status in the same write as the other edits", func() {
+			changes := ProjectDetails{Tags: []string{"wood"}, Status: StatusCancelled, Version: 1}
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", changes)).To(Succeed())
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Tags).To(Equal([]string{"wood"}))
+			Expect(saved.Status).To(Equal(StatusCancelled))
+			Expect(saved.Version).To(Equal(2))
+
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Status: StatusOpen, Version: 2})).To(Equal(ErrInvalidTransition))
+		})
+
+		It("leaves the status alone when the version is stale", func() {
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Tags: []string{"wood"}})).To(Succeed())
+			Expect(memoryPM.UpdateProjectDetails(ctx, "p1", ProjectDetails{Status: StatusClosed, Version: 1})).To(Equal(ErrVersionMismatch))
+
+			saved, err := memoryPM.GetProject(ctx, "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.CurrentStatus()).To(Equal(StatusOpen))
+		})
+	})
 })
+
+// failingUpsertClient fails every upsert with err, if set.
+type failingUpsertClient struct {
+	util.MongoClient
+	err error
+}
+
+func (c *failingUpsertClient) UpsertOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.MongoClient.UpsertOne(ctx, dbName, collectionName, filter, update)
+}
@@ -0,0 +1,170 @@
+package proxy_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+// retractingProjectManager reports every bid as retracted, as if all were
+// retracted just before the proxies are run.
+type retractingProjectManager struct {
+	project.ProjectManager
+}
+
+func (r retractingProjectManager) GetBids(ctx context.Context, projectID string) ([]project.BID, error) {
+	bids, err := r.ProjectManager.GetBids(ctx, projectID)
+	for i := range bids {
+		bids[i].Status = project.BidRetracted
+	}
+	return bids, err
+}
+
+var _ = Describe("Proxy bids", func() {
+	var (
+		pm  project.ProjectManager
+		bm  bidManager.BidManager
+		now time.Time
+	)
+	ctx := context.TODO()
+
+	// tick advances the clock so every change has its own time.
+	tick := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	amountOf := func(bidID string) int {
+		bid, err := pm.GetBid(ctx, "p1", bidID)
+		Expect(err).ToNot(HaveOccurred())
+		return bid.Amount
+	}
+
+	proxy := func(buyerID, bidID string, maximum int) error {
+		return bm.SetProxyBid(ctx, "p1", project.ProxyBid{BuyerID: buyerID, BidID: bidID, Maximum: maximum})
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		pm = project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: tick})
+
+		for _, id := range []string{"buyer1", "buyer2", "buyer3"} {
+			Expect(pm.CreateBuyer(ctx, project.Buyer{ID: id})).To(Succeed())
+		}
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p1", SellerID: "s1", Strategy: bidManager.StrategyFirstPrice, BidIncrement: 10,
+		})).To(Succeed())
+	})
+
+	It("raises the buyer's bid by the increment whenever they are outbid", func() {
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}, 0)).To(Succeed())
+		Expect(proxy("buyer2", "b2", 500)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(110))
+
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 200}, 0)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(210))
+
+		bid, err := pm.GetBid(ctx, "p1", "b2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bid.Revisions).To(HaveLen(2))
+		Expect(bid.Revisions[0].Auto).To(BeTrue())
+		Expect(bid.Revisions[1].Auto).To(BeTrue())
+		Expect(bid.Revisions[1].Action).To(Equal(project.ActionAmended))
+	})
+
+	It("stops raising once a manual bid beats the maximum", func() {
+		Expect(proxy("buyer2", "b2", 300)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(10))
+
+		// Bidding 300 would fall short of the leading bid plus the increment
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 400}, 0)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(10))
+
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.WinningBid.ID).To(Equal("b1"))
+	})
+
+	It("lets the higher proxy win at one increment over the other", func() {
+		Expect(proxy("buyer1", "b1", 300)).To(Succeed())
+		Expect(proxy("buyer2", "b2", 500)).To(Succeed())
+		Expect(amountOf("b1")).To(Equal(300))
+		Expect(amountOf("b2")).To(Equal(310))
+	})
+
+	It("gives equal maximums to the proxy set first", func() {
+		Expect(proxy("buyer1", "b1", 300)).To(Succeed())
+		Expect(proxy("buyer2", "b2", 300)).To(Succeed())
+		Expect(amountOf("b1")).To(Equal(300))
+		_, err := pm.GetBid(ctx, "p1", "b2")
+		Expect(err).To(MatchError(project.ErrBidNotFound))
+
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.WinningBid.ID).To(Equal("b1"))
+	})
+
+	It("never raises by less than the increment", func() {
+		Expect(proxy("buyer2", "b2", 300)).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 295}, 0)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(10))
+
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.WinningBid.ID).To(Equal("b1"))
+	})
+
+	It("refuses maximums no bid could be placed at", func() {
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}, 0)).To(Succeed())
+		Expect(proxy("buyer2", "b2", 105)).To(MatchError(bidManager.ErrIncrementNotMet))
+
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p2", SellerID: "s1", Strategy: bidManager.StrategyFirstPrice, StartPrice: 50,
+		})).To(Succeed())
+		err := bm.SetProxyBid(ctx, "p2", project.ProxyBid{BuyerID: "buyer1", BidID: "b3", Maximum: 40})
+		Expect(err).To(MatchError(bidManager.ErrStartPriceNotMet))
+		_, err = pm.GetProxyBid(ctx, "p2", "buyer1")
+		Expect(err).To(MatchError(project.ErrProxyNotFound))
+	})
+
+	It("is cancelled when its bid is retracted", func() {
+		Expect(proxy("buyer2", "b2", 500)).To(Succeed())
+		Expect(bm.RetractBid(ctx, "p1", "b2", "changed my mind")).To(Succeed())
+		_, err := pm.GetProxyBid(ctx, "p1", "buyer2")
+		Expect(err).To(MatchError(project.ErrProxyNotFound))
+
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}, 0)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(10))
+	})
+
+	It("places nothing when only proxies of retracted bids remain", func() {
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b2", BuyerID: "buyer2", Amount: 10}, 0)).To(Succeed())
+
+		bm = bidManager.NewBidManager(retractingProjectManager{pm}, bidManager.Options{Now: tick})
+		Expect(proxy("buyer2", "b2", 500)).To(Succeed())
+		Expect(amountOf("b2")).To(Equal(10))
+	})
+
+	It("is only available on open ascending auctions", func() {
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{ID: "p2", SellerID: "s1"})).To(Succeed())
+		err := bm.SetProxyBid(ctx, "p2", project.ProxyBid{BuyerID: "buyer1", BidID: "b1", Maximum: 100})
+		Expect(err).To(MatchError(bidManager.ErrProxyNotSupported))
+
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 100}, 0)).To(Succeed())
+		Expect(proxy("buyer2", "b1", 500)).To(MatchError(project.ErrBidTaken))
+	})
+})
@@ -0,0 +1,143 @@
+package rules_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+type recorder struct{ events []events.Event }
+
+func (r *recorder) Publish(event events.Event) { r.events = append(r.events, event) }
+
+var _ = Describe("Price rules", func() {
+	var (
+		pm        project.ProjectManager
+		bm        bidManager.BidManager
+		published *recorder
+		now       time.Time
+	)
+	ctx := context.TODO()
+
+	// tick advances the clock so every change has its own time.
+	tick := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	create := func(p project.ProjectDetails) {
+		p.ID, p.SellerID = "p1", "s1"
+		Expect(pm.CreateProject(ctx, p)).To(Succeed())
+	}
+
+	bid := func(bidID, buyerID string, amount int) error {
+		return bm.DoBID(ctx, "p1", project.BID{ID: bidID, BuyerID: buyerID, Amount: amount}, 0)
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		published = &recorder{}
+		pm = project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: tick, Publisher: published})
+
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+	})
+
+	It("rejects bids below the starting price", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategyFirstPrice, StartPrice: 100})
+		Expect(bid("b1", "buyer1", 99)).To(MatchError(bidManager.ErrStartPriceNotMet))
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+	})
+
+	It("applies the starting price as a ceiling on reverse auctions", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategyReverse, StartPrice: 100})
+		Expect(bid("b1", "buyer1", 101)).To(MatchError(bidManager.ErrStartPriceNotMet))
+		Expect(bid("b1", "buyer1", 90)).To(Succeed())
+	})
+
+	It("requires bids to beat another buyer's leading bid by the larger increment", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategyFirstPrice, BidIncrement: 5, BidIncrementPercent: 10})
+		Expect(bid("b1", "buyer1", 200)).To(Succeed())
+		Expect(bid("b2", "buyer2", 219)).To(MatchError(bidManager.ErrIncrementNotMet))
+		Expect(bid("b2", "buyer2", 220)).To(Succeed())
+
+		// The leader may raise their own bid by any amount
+		Expect(bid("b2", "buyer2", 221)).To(Succeed())
+	})
+
+	It("closes the project without a winner when no bid reaches the reserve", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategyFirstPrice, ReservePrice: 500})
+		Expect(bid("b1", "buyer1", 400)).To(Succeed())
+
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Outcome).To(Equal(bidManager.OutcomeReserveNotMet))
+		Expect(result.WinningBid.ID).To(BeEmpty())
+		Expect(result.RankedBids).To(HaveLen(1))
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusClosed))
+		Expect(p1.Award).To(BeNil())
+
+		last := published.events[len(published.events)-1]
+		Expect(last.Type).To(Equal(events.ProjectClosed))
+		Expect(last.Data.(bidManager.AuctionResult).Outcome).To(Equal(bidManager.OutcomeReserveNotMet))
+	})
+
+	It("never clears below the reserve price", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategySecondPrice, ReservePrice: 500})
+		Expect(bid("b1", "buyer1", 600)).To(Succeed())
+		Expect(bid("b2", "buyer2", 300)).To(Succeed())
+
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Outcome).To(Equal(bidManager.OutcomeAwarded))
+		Expect(result.WinningBid.ID).To(Equal("b1"))
+		Expect(result.ClearingPrice).To(Equal(500))
+	})
+
+	It("awards the project as soon as a bid reaches the buy-it-now price", func() {
+		create(project.ProjectDetails{Strategy: bidManager.StrategyFirstPrice, BuyNowPrice: 1000})
+		Expect(bid("b1", "buyer1", 999)).To(Succeed())
+		Expect(bid("b2", "buyer2", 1000)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusAwarded))
+		Expect(p1.Award.BidID).To(Equal("b2"))
+		Expect(bid("b1", "buyer1", 1100)).ToNot(Succeed())
+	})
+
+	It("hides a hidden reserve price from the public view", func() {
+		p := project.ProjectDetails{ReservePrice: 500, ReserveHidden: true}
+		Expect(p.Public().ReservePrice).To(BeZero())
+		p.ReserveHidden = false
+		Expect(p.Public().ReservePrice).To(Equal(500))
+	})
+
+	It("only allows a reserve price on sealed projects", func() {
+		end := now.Add(time.Hour)
+		sealed := project.ProjectDetails{
+			ID: "p2", SellerID: "s1", Sealed: true, EndDate: end, RevealEndDate: end.Add(time.Hour), StartPrice: 10,
+		}
+		Expect(pm.CreateProject(ctx, sealed)).To(MatchError(project.ErrSealedPriceRules))
+		sealed.StartPrice, sealed.ReservePrice = 0, 10
+		Expect(pm.CreateProject(ctx, sealed)).To(Succeed())
+	})
+})
@@ -0,0 +1,213 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	. "github.com/21keshav/IBackendApplication/resources/scheduler"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+// recorder is a Publisher that keeps every event.
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) Publish(event events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := []string{}
+	for _, e := range r.events {
+		types = append(types, e.Type+":"+e.ProjectID)
+	}
+	return types
+}
+
+// fakeClock is a clock tests move forward by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var _ = Describe("Scheduler", func() {
+	var (
+		mongoClient util.MongoClient
+		pm          project.ProjectManager
+		bm          bidManager.BidManager
+		clock       *fakeClock
+		published   *recorder
+		start       time.Time
+	)
+	ctx := context.TODO()
+
+	newScheduler := func(owner string) Scheduler {
+		locker := NewLocker(mongoClient, "projectDetails", owner)
+		// Awards are published by the bid manager, closes by the scheduler
+		awarding := bidManager.NewBidManager(pm, bidManager.Options{Now: clock.Now, Publisher: published})
+		return NewScheduler(awarding, pm, locker, published, Config{LeaseTTL: time.Minute, Now: clock.Now})
+	}
+
+	BeforeEach(func() {
+		start = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock = &fakeClock{now: start}
+		published = &recorder{}
+		mongoClient = util.NewMemoryMongoClient()
+		pm = project.NewProjectManager(mongoClient, config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: clock.Now})
+
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p1", SellerID: "s1", EndDate: start.Add(time.Hour),
+		})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p2", SellerID: "s1", EndDate: start.Add(2 * time.Hour),
+		})).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1", Amount: 300}, 0)).To(Succeed())
+		Expect(bm.DoBID(ctx, "p1", project.BID{ID: "b2", BuyerID: "buyer2", Amount: 200}, 0)).To(Succeed())
+	})
+
+	It("leaves projects alone until their end date", func() {
+		Expect(newScheduler("a").RunOnce(ctx)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusOpen))
+		Expect(published.types()).To(BeEmpty())
+	})
+
+	It("awards an expired project and records the award", func() {
+		clock.Advance(time.Hour)
+		Expect(newScheduler("a").RunOnce(ctx)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusAwarded))
+		Expect(p1.Award).ToNot(BeNil())
+		Expect(p1.Award.BuyerID).To(Equal("buyer2"))
+		Expect(p1.Award.BidID).To(Equal("b2"))
+		Expect(p1.Award.Price).To(Equal(200))
+		Expect(p1.Award.ClosedAt.Equal(clock.Now())).To(BeTrue())
+
+		Expect(published.types()).To(Equal([]string{events.ProjectAwarded + ":p1"}))
+		result := published.events[0].Data.(bidManager.AuctionResult)
+		Expect(result.Winner.ID).To(Equal("buyer2"))
+		Expect(published.events[0].Time).To(Equal(clock.Now()))
+	})
+
+	It("awards an expired project whose winning buyer was deleted", func() {
+		Expect(pm.DeleteBuyer(ctx, "buyer2")).To(Succeed())
+		clock.Advance(time.Hour)
+		Expect(newScheduler("a").RunOnce(ctx)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusAwarded))
+		Expect(p1.Award.BuyerID).To(Equal("buyer2"))
+		Expect(p1.Award.BidID).To(Equal("b2"))
+
+		result := published.events[0].Data.(bidManager.AuctionResult)
+		Expect(result.Winner.ID).To(Equal("buyer2"))
+	})
+
+	It("closes an expired project without bids", func() {
+		clock.Advance(2 * time.Hour)
+		Expect(newScheduler("a").RunOnce(ctx)).To(Succeed())
+
+		p2, err := pm.GetProject(ctx, "p2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p2.Status).To(Equal(project.StatusClosed))
+		Expect(p2.Award).To(BeNil())
+		Expect(published.types()).To(ConsistOf(events.ProjectAwarded+":p1", events.ProjectClosed+":p2"))
+	})
+
+	It("does not close a project twice", func() {
+		clock.Advance(time.Hour)
+		s := newScheduler("a")
+		Expect(s.RunOnce(ctx)).To(Succeed())
+		Expect(s.RunOnce(ctx)).To(Succeed())
+
+		Expect(published.types()).To(HaveLen(1))
+	})
+
+	It("closes each project once when many replicas run at the same time", func() {
+		clock.Advance(2 * time.Hour)
+
+		var wg sync.WaitGroup
+		for _, owner := range []string{"a", "b", "c", "d", "e"} {
+			wg.Add(1)
+			go func(s Scheduler) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(s.RunOnce(ctx)).To(Succeed())
+			}(newScheduler(owner))
+		}
+		wg.Wait()
+
+		Expect(published.types()).To(ConsistOf(events.ProjectAwarded+":p1", events.ProjectClosed+":p2"))
+	})
+
+	Describe("Locker", func() {
+		var a, b Locker
+
+		BeforeEach(func() {
+			a = NewLocker(mongoClient, "projectDetails", "a")
+			b = NewLocker(mongoClient, "projectDetails", "b")
+		})
+
+		It("grants a lease to one owner at a time", func() {
+			Expect(a.Acquire(ctx, "project:p1", time.Minute, start)).To(BeTrue())
+			Expect(b.Acquire(ctx, "project:p1", time.Minute, start)).To(BeFalse())
+			Expect(a.Acquire(ctx, "project:p1", time.Minute, start)).To(BeTrue())
+		})
+
+		It("lets another owner take an expired lease", func() {
+			Expect(a.Acquire(ctx, "project:p1", time.Minute, start)).To(BeTrue())
+
+			Expect(b.Acquire(ctx, "project:p1", time.Minute, start.Add(time.Minute))).To(BeTrue())
+			Expect(a.Acquire(ctx, "project:p1", time.Minute, start.Add(time.Minute))).To(BeFalse())
+		})
+
+		It("frees a lease on release, but only for its owner", func() {
+			Expect(a.Acquire(ctx, "project:p1", time.Minute, start)).To(BeTrue())
+			Expect(b.Release(ctx, "project:p1")).To(Succeed())
+			Expect(b.Acquire(ctx, "project:p1", time.Minute, start)).To(BeFalse())
+
+			Expect(a.Release(ctx, "project:p1")).To(Succeed())
+			Expect(b.Acquire(ctx, "project:p1", time.Minute, start)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,140 @@
+package sealed_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+const salt = "0123456789abcdef"
+
+type recorder struct{ events []events.Event }
+
+func (r *recorder) Publish(event events.Event) { r.events = append(r.events, event) }
+
+var _ = Describe("Sealed bids", func() {
+	var (
+		mongoClient util.MongoClient
+		pm          project.ProjectManager
+		bm          bidManager.BidManager
+		published   *recorder
+		now         time.Time
+		end         time.Time
+	)
+	ctx := context.TODO()
+	clock := func() time.Time { return now }
+
+	commit := func(bidID, buyerID string, amount int) error {
+		return bm.DoBID(ctx, "p1", project.BID{
+			ID: bidID, BuyerID: buyerID, Commitment: project.Commit("p1", bidID, amount, salt),
+		}, 0)
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		end = now.Add(time.Hour)
+		published = &recorder{}
+		mongoClient = util.NewMemoryMongoClient()
+		pm = project.NewProjectManager(mongoClient, config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: clock, Publisher: published})
+
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p1", SellerID: "s1", Sealed: true, EndDate: end, RevealEndDate: end.Add(time.Hour),
+		})).To(Succeed())
+		Expect(commit("b1", "buyer1", 300)).To(Succeed())
+		Expect(commit("b2", "buyer2", 200)).To(Succeed())
+	})
+
+	It("stores only the commitment while bidding is open", func() {
+		bid, err := pm.GetBid(ctx, "p1", "b1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bid.Amount).To(BeZero())
+		Expect(bid.Commitment).To(Equal(project.Commit("p1", "b1", 300, salt)))
+		Expect(bid.Revisions[0].Amount).To(BeZero())
+
+		for _, event := range published.events {
+			Expect(event.Type).To(Equal(events.BidPlaced))
+			Expect(event.Data.(bidManager.BidActivity).Amount).To(BeZero())
+		}
+	})
+
+	It("only accepts reveals between the end date and the reveal end date", func() {
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, salt)).To(MatchError(project.ErrRevealNotStarted))
+
+		now = end
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, salt)).To(Succeed())
+
+		now = end.Add(time.Hour)
+		Expect(bm.RevealBid(ctx, "p1", "b2", 200, salt)).To(MatchError(project.ErrRevealEnded))
+	})
+
+	It("checks the amount and salt against the commitment", func() {
+		now = end
+		Expect(bm.RevealBid(ctx, "p1", "b1", 299, salt)).To(MatchError(project.ErrRevealMismatch))
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, "another-salt-value")).To(MatchError(project.ErrRevealMismatch))
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, salt)).To(Succeed())
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, salt)).To(MatchError(project.ErrBidRevealed))
+
+		bid, err := pm.GetBid(ctx, "p1", "b1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bid.Amount).To(Equal(300))
+		Expect(bid.Revealed).To(BeTrue())
+		Expect(bid.Revisions[1].Action).To(Equal(project.ActionRevealed))
+	})
+
+	It("awards among revealed bids once the reveal phase is over", func() {
+		now = end
+		Expect(bm.RevealBid(ctx, "p1", "b1", 300, salt)).To(Succeed())
+		_, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).To(MatchError(project.ErrRevealPending))
+
+		// b2 is lower but never revealed, so it does not take part
+		now = end.Add(time.Hour)
+		result, err := bm.ComputeBID(ctx, "p1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.WinningBid.ID).To(Equal("b1"))
+		Expect(result.RankedBids).To(HaveLen(1))
+	})
+
+	It("is closed by the scheduler after the reveal end date", func() {
+		locker := scheduler.NewLocker(mongoClient, "projectDetails", "a")
+		sched := scheduler.NewScheduler(bm, pm, locker, published, scheduler.Config{LeaseTTL: time.Minute, Now: clock})
+
+		now = end
+		Expect(sched.RunOnce(ctx)).To(Succeed())
+		Expect(bm.RevealBid(ctx, "p1", "b2", 200, salt)).To(Succeed())
+
+		now = end.Add(time.Hour)
+		Expect(sched.RunOnce(ctx)).To(Succeed())
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusAwarded))
+		Expect(p1.Award.BidID).To(Equal("b2"))
+	})
+
+	It("requires the reveal end date to follow the end date on sealed projects", func() {
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p2", SellerID: "s1", Sealed: true, EndDate: end, RevealEndDate: end,
+		})).To(MatchError(project.ErrInvalidRevealWindow))
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p3", SellerID: "s1", EndDate: end, RevealEndDate: end.Add(time.Hour),
+		})).To(MatchError(project.ErrInvalidRevealWindow))
+	})
+})
@@ -0,0 +1,217 @@
+package softclose_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/util"
+)
+
+type recorder struct{ events []events.Event }
+
+func (r *recorder) Publish(event events.Event) { r.events = append(r.events, event) }
+
+// racingProjectManager lets another late bid extend the project just before
+// the first extension it is asked for.
+type racingProjectManager struct {
+	project.ProjectManager
+	raced bool
+}
+
+func (r *racingProjectManager) AddBidRevision(ctx context.Context, projectID string, bid project.BID,
+	rev project.BidRevision, cond project.BidCondition) error {
+	if !r.raced && !cond.ExtendTo.IsZero() {
+		r.raced = true
+		other := project.BID{ID: "b0", BuyerID: "buyer2"}
+		Expect(r.ProjectManager.AddBidRevision(ctx, projectID, other, rev, cond)).To(Succeed())
+	}
+	return r.ProjectManager.AddBidRevision(ctx, projectID, bid, rev, cond)
+}
+
+// closingClient closes the project just before the first write that would
+// extend it.
+type closingClient struct {
+	util.MongoClient
+	closed bool
+}
+
+func (c *closingClient) UpdateOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	if _, extends := filter.(bson.M)["end_date"]; extends && !c.closed {
+		c.closed = true
+		closing := bson.M{"$set": bson.M{"status": project.StatusClosed}, "$inc": bson.M{"version": 1}}
+		_, err := c.MongoClient.UpdateOne(ctx, dbName, collectionName, bson.M{"id": filter.(bson.M)["id"]}, closing)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	return c.MongoClient.UpdateOne(ctx, dbName, collectionName, filter, update)
+}
+
+var _ = Describe("Soft close", func() {
+	var (
+		pm        project.ProjectManager
+		bm        bidManager.BidManager
+		published *recorder
+		now       time.Time
+		end       time.Time
+	)
+	ctx := context.TODO()
+	clock := func() time.Time { return now }
+
+	bid := func(bidID, buyerID string, amount int) error {
+		return bm.DoBID(ctx, "p1", project.BID{ID: bidID, BuyerID: buyerID, Amount: amount}, 0)
+	}
+
+	endDate := func() time.Time {
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		return p1.EndDate
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		end = now.Add(time.Hour)
+		published = &recorder{}
+		pm = project.NewProjectManager(util.NewMemoryMongoClient(), config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projectDetails",
+			BidsDBName:     "bids",
+			CollectionName: "Collections",
+		})
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: clock, Publisher: published})
+
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer1"})).To(Succeed())
+		Expect(pm.CreateBuyer(ctx, project.Buyer{ID: "buyer2"})).To(Succeed())
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p1", SellerID: "s1", Strategy: bidManager.StrategyFirstPrice, EndDate: end,
+			SoftCloseMinutes: 5, ExtendMinutes: 10, HardCloseDate: end.Add(15 * time.Minute),
+		})).To(Succeed())
+	})
+
+	It("leaves the end date alone for bids before the final minutes", func() {
+		now = end.Add(-6 * time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+		Expect(endDate()).To(BeTemporally("==", end))
+	})
+
+	It("extends the end date for late bids and announces it", func() {
+		now = end.Add(-time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.EndDate).To(BeTemporally("==", end.Add(10*time.Minute)))
+		Expect(p1.Extensions).To(Equal(1))
+
+		var extended []events.Event
+		for _, event := range published.events {
+			if event.Type == events.AuctionExtended {
+				extended = append(extended, event)
+			}
+		}
+		Expect(extended).To(HaveLen(1))
+		Expect(extended[0].Data).To(BeTemporally("==", end.Add(10*time.Minute)))
+	})
+
+	It("does not extend the end date for rejected late bids", func() {
+		now = end.Add(-10 * time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+
+		now = end.Add(-time.Minute)
+		Expect(bid("b1", "buyer2", 200)).To(MatchError(project.ErrBidTaken))
+		Expect(bm.RetractBid(ctx, "p1", "b1", "typo")).To(Succeed())
+		Expect(bid("b1", "buyer1", 300)).To(MatchError(project.ErrBidRetracted))
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.EndDate).To(BeTemporally("==", end))
+		Expect(p1.Extensions).To(BeZero())
+		for _, event := range published.events {
+			Expect(event.Type).ToNot(Equal(events.AuctionExtended))
+		}
+	})
+
+	It("never extends past the hard close date", func() {
+		now = end.Add(-time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+		now = end.Add(9 * time.Minute)
+		Expect(bid("b2", "buyer2", 200)).To(Succeed())
+		Expect(endDate()).To(BeTemporally("==", end.Add(15*time.Minute)))
+
+		now = end.Add(14 * time.Minute)
+		Expect(bid("b1", "buyer1", 300)).To(Succeed())
+		Expect(endDate()).To(BeTemporally("==", end.Add(15*time.Minute)))
+
+		now = end.Add(15 * time.Minute)
+		Expect(bid("b2", "buyer2", 400)).To(MatchError(project.ErrBiddingWindowEnded))
+	})
+
+	It("accepts the end date a concurrent late bid set", func() {
+		racing := &racingProjectManager{ProjectManager: pm}
+		bm = bidManager.NewBidManager(racing, bidManager.Options{Now: clock, Publisher: published})
+
+		now = end.Add(-time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(Succeed())
+
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Extensions).To(Equal(1))
+		Expect(p1.EndDate).To(BeTemporally("==", end.Add(10*time.Minute)))
+		_, err = pm.GetBid(ctx, "p1", "b1")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects a late bid when the project closes before it is stored", func() {
+		dbConfig := config.DatabaseDetails{ProjectDBName: "projectDetails", BidsDBName: "bids", CollectionName: "Collections"}
+		pm = project.NewProjectManager(&closingClient{MongoClient: util.NewMemoryMongoClient()}, dbConfig)
+		bm = bidManager.NewBidManager(pm, bidManager.Options{Now: clock, Publisher: published})
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p1", SellerID: "s1", Strategy: bidManager.StrategyFirstPrice, EndDate: end,
+			SoftCloseMinutes: 5, ExtendMinutes: 10,
+		})).To(Succeed())
+
+		now = end.Add(-time.Minute)
+		Expect(bid("b1", "buyer1", 100)).To(MatchError(project.ErrProjectNotOpen))
+
+		_, err := pm.GetBid(ctx, "p1", "b1")
+		Expect(err).To(MatchError(project.ErrBidNotFound))
+		p1, err := pm.GetProject(ctx, "p1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p1.Status).To(Equal(project.StatusClosed))
+		Expect(p1.EndDate).To(BeTemporally("==", end))
+		Expect(p1.Extensions).To(BeZero())
+		Expect(p1.BidCount).To(BeZero())
+		Expect(published.events).To(BeEmpty())
+	})
+
+	It("only moves the end date it was given", func() {
+		late := project.BidRevision{Revision: 1, Action: project.ActionPlaced, Amount: 100, At: end.Add(-time.Minute)}
+		moved := project.BidCondition{EndDate: end.Add(time.Minute), ExtendTo: end.Add(time.Hour)}
+		Expect(pm.AddBidRevision(ctx, "p1", project.BID{ID: "b1", BuyerID: "buyer1"}, late, moved)).To(MatchError(project.ErrEndDateMoved))
+		_, err := pm.GetBid(ctx, "p1", "b1")
+		Expect(err).To(MatchError(project.ErrBidNotFound))
+
+		extend := project.BidCondition{EndDate: end, ExtendTo: end.Add(time.Hour)}
+		Expect(pm.AddBidRevision(ctx, "nope", project.BID{ID: "b1", BuyerID: "buyer1"}, late, extend)).To(MatchError(project.ErrProjectNotFound))
+	})
+
+	It("validates the soft close settings", func() {
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p2", SellerID: "s1", SoftCloseMinutes: 5, ExtendMinutes: 10,
+		})).To(MatchError(project.ErrInvalidSoftClose))
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p3", SellerID: "s1", EndDate: end, SoftCloseMinutes: 5,
+		})).To(MatchError(project.ErrInvalidSoftClose))
+		Expect(pm.CreateProject(ctx, project.ProjectDetails{
+			ID: "p4", SellerID: "s1", EndDate: end, SoftCloseMinutes: 5, ExtendMinutes: 10, HardCloseDate: end.Add(-time.Minute),
+		})).To(MatchError(project.ErrInvalidHardClose))
+	})
+})
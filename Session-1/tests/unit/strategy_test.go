@@ -0,0 +1,81 @@
+package bidManager_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/project"
+)
+
+var _ = Describe("AuctionStrategy", func() {
+	var bids []project.BID
+
+	BeforeEach(func() {
+		bids = []project.BID{
+			{ID: "b1", BuyerID: "buyer1", Amount: 300},
+			{ID: "b2", BuyerID: "buyer2", Amount: 100},
+			{ID: "b3", BuyerID: "buyer3", Amount: 200},
+		}
+	})
+
+	rank := func(name string) (AuctionStrategy, []project.BID) {
+		strategy, err := StrategyFor(name)
+		Expect(err).ToNot(HaveOccurred())
+		return strategy, strategy.Rank(bids)
+	}
+
+	It("defaults to the reverse auction", func() {
+		strategy, err := StrategyFor("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strategy.Name()).To(Equal(StrategyReverse))
+	})
+
+	It("reverse: lowest bid wins and pays its bid", func() {
+		strategy, ranked := rank(StrategyReverse)
+		Expect(ranked[0].ID).To(Equal("b2"))
+		Expect(strategy.ClearingPrice(ranked)).To(Equal(100))
+	})
+
+	It("first-price: highest bid wins and pays its bid", func() {
+		strategy, ranked := rank(StrategyFirstPrice)
+		Expect(ranked[0].ID).To(Equal("b1"))
+		Expect(strategy.ClearingPrice(ranked)).To(Equal(300))
+	})
+
+	It("second-price: highest bid wins and pays the runner-up's bid", func() {
+		strategy, ranked := rank(StrategySecondPrice)
+		Expect(ranked[0].ID).To(Equal("b1"))
+		Expect(strategy.ClearingPrice(ranked)).To(Equal(200))
+	})
+
+	It("second-price: a sole bidder pays their own bid", func() {
+		strategy, err := StrategyFor(StrategySecondPrice)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strategy.ClearingPrice(bids[:1])).To(Equal(300))
+	})
+
+	It("resolves aliases to the canonical strategy", func() {
+		strategy, err := StrategyFor(StrategyHighestWins)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strategy.Name()).To(Equal(StrategyFirstPrice))
+	})
+
+	It("breaks ties by bid ID", func() {
+		bids = append(bids, project.BID{ID: "a0", BuyerID: "buyer4", Amount: 100})
+		_, ranked := rank(StrategyReverse)
+		Expect(ranked[0].ID).To(Equal("a0"))
+		Expect(ranked[1].ID).To(Equal("b2"))
+	})
+
+	It("does not reorder the caller's bids", func() {
+		rank(StrategyFirstPrice)
+		Expect(bids[0].ID).To(Equal("b1"))
+		Expect(bids[1].ID).To(Equal("b2"))
+	})
+
+	It("rejects unknown strategies", func() {
+		_, err := StrategyFor("dutch")
+		Expect(err).To(Equal(ErrUnknownStrategy))
+	})
+})
@@ -0,0 +1,940 @@
+package controller_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/auth"
+	"github.com/21keshav/IBackendApplication/config"
+	"github.com/21keshav/IBackendApplication/controller"
+	"github.com/21keshav/IBackendApplication/events"
+	"github.com/21keshav/IBackendApplication/logging"
+	"github.com/21keshav/IBackendApplication/migrations"
+	"github.com/21keshav/IBackendApplication/resources/bidManager"
+	"github.com/21keshav/IBackendApplication/resources/idempotency"
+	"github.com/21keshav/IBackendApplication/resources/project"
+	"github.com/21keshav/IBackendApplication/resources/scheduler"
+	"github.com/21keshav/IBackendApplication/util"
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+)
+
+// The v1 API is exercised through the router against the in-memory backend,
+// so path parameters, status codes and persistence are tested together.
+var _ = Describe("v1 API", func() {
+	var (
+		e            *echo.Echo
+		tokenManager auth.TokenManager
+		token        string // sent by do; set per test to act as a different caller
+		pm           project.ProjectManager
+		bm           bidManager.BidManager
+		client       util.MongoClient
+	)
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			Expect(json.NewEncoder(&buf).Encode(body)).To(Succeed())
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tokenFor := func(role auth.Role, id string) string {
+		t, _, err := tokenManager.IssueToken(role, id)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	BeforeEach(func() {
+		dbConfig := config.DatabaseDetails{
+			BuyersDBName:   "buyers",
+			SellersDBName:  "sellers",
+			ProjectDBName:  "projects",
+			BidsDBName:     "bids",
+			CollectionName: "items",
+		}
+		client = util.NewMemoryMongoClient()
+		locker := scheduler.NewLocker(client, dbConfig.ProjectDBName, "test")
+		_, err := migrations.NewMigrator(client, dbConfig, locker, migrations.Options{}).Up(context.Background(), false)
+		Expect(err).ToNot(HaveOccurred())
+		pm = project.NewProjectManager(client, dbConfig)
+		bm = bidManager.NewBidManager(pm, bidManager.Options{})
+		tokenManager = auth.NewTokenManager("test-secret", 0, "admin-pass")
+		e = echo.New()
+		controller.NewController(bm, pm, tokenManager, controller.Options{}).AttachHandlers(e)
+
+		token = ""
+		Expect(do(http.MethodPost, "/v1/sellers", project.Seller{ID: "s1", SellerName: "Seller"}).Code).To(Equal(http.StatusCreated))
+		Expect(do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u1", BuyerName: "Buyer"}).Code).To(Equal(http.StatusCreated))
+
+		token = tokenFor(auth.RoleSeller, "s1")
+		Expect(do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p1"}).Code).To(Equal(http.StatusCreated))
+	})
+
+	Describe("request contexts", func() {
+		It("returns 504 when the operation deadline passes", func() {
+			e = echo.New()
+			controller.NewController(bm, pm, tokenManager, controller.Options{OperationTimeout: time.Nanosecond}).AttachHandlers(e)
+
+			rec := do(http.MethodGet, "/v1/projects/p1", nil)
+			Expect(rec.Code).To(Equal(http.StatusGatewayTimeout))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"timeout"`))
+		})
+
+		It("stops work for requests whose client has gone away", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			req := httptest.NewRequest(http.MethodGet, "/v1/projects/p1", nil).WithContext(ctx)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"request_cancelled"`))
+		})
+	})
+
+	Describe("logging", func() {
+		It("tags every line of a request with its request ID and the IDs it works on", func() {
+			var logs bytes.Buffer
+			log, err := logging.New(&logs, logging.FormatJSON, "debug")
+			Expect(err).ToNot(HaveOccurred())
+			e = echo.New()
+			e.Use(logging.Middleware(log))
+			controller.NewController(bm, pm, tokenManager, controller.Options{}).AttachHandlers(e)
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			var buf bytes.Buffer
+			Expect(json.NewEncoder(&buf).Encode(project.BID{ID: "b1", Amount: 50})).To(Succeed())
+			req := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/bids", &buf)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			req.Header.Set(echo.HeaderXRequestID, "trace-42")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Header().Get(echo.HeaderXRequestID)).To(Equal("trace-42"))
+
+			// The bid's revision is written by the ProjectManager through the MongoClient
+			var found bool
+			for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+				var entry map[string]interface{}
+				Expect(json.Unmarshal(line, &entry)).To(Succeed())
+				Expect(entry).To(HaveKeyWithValue("request_id", "trace-42"))
+				if entry["msg"] == "memory-upsert-data-started" {
+					found = true
+					Expect(entry).To(HaveKeyWithValue("project_id", "p1"))
+					Expect(entry).To(HaveKeyWithValue("bid_id", "b1"))
+					Expect(entry).To(HaveKeyWithValue("buyer_id", "u1"))
+				}
+			}
+			Expect(found).To(BeTrue())
+		})
+	})
+
+	Describe("event streams", func() {
+		var (
+			hub    *events.Hub
+			server *httptest.Server
+			buyer  string
+			client = &http.Client{Timeout: 5 * time.Second}
+		)
+
+		// readEvent reads one server-sent event, skipping keep-alive comments.
+		readEvent := func(r *bufio.Reader) (id, name, data string) {
+			for {
+				line, err := r.ReadString('\n')
+				Expect(err).ToNot(HaveOccurred())
+				line = strings.TrimSuffix(line, "\n")
+				switch {
+				case line == "" && name != "":
+					return id, name, data
+				case strings.HasPrefix(line, "id: "):
+					id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					name = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					data = strings.TrimPrefix(line, "data: ")
+				}
+			}
+		}
+
+		stream := func(lastEventID string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/projects/p1/events?access_token="+buyer, nil)
+			Expect(err).ToNot(HaveOccurred())
+			if lastEventID != "" {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+			resp, err := client.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get(echo.HeaderContentType)).To(Equal("text/event-stream"))
+			return resp
+		}
+
+		BeforeEach(func() {
+			hub = events.NewHub(events.HubConfig{})
+			bm = bidManager.NewBidManager(pm, bidManager.Options{Publisher: hub})
+			e = echo.New()
+			controller.NewController(bm, pm, tokenManager, controller.Options{Subscriber: hub}).AttachHandlers(e)
+			server = httptest.NewServer(e)
+			buyer = tokenFor(auth.RoleBuyer, "u1")
+		})
+
+		AfterEach(func() {
+			hub.Close()
+			server.Close()
+		})
+
+		It("streams a project's events and resumes after Last-Event-ID", func() {
+			resp := stream("")
+			defer resp.Body.Close()
+
+			token = buyer
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 50}).Code).To(Equal(http.StatusCreated))
+
+			r := bufio.NewReader(resp.Body)
+			id, name, data := readEvent(r)
+			Expect(name).To(Equal(events.BidPlaced))
+			Expect(data).To(ContainSubstring(`"bid_id":"b1"`))
+			Expect(data).To(ContainSubstring(`"id":` + id))
+			_, name, _ = readEvent(r)
+			Expect(name).To(Equal(events.LeaderChanged))
+
+			resumed := stream(id)
+			defer resumed.Body.Close()
+			_, name, _ = readEvent(bufio.NewReader(resumed.Body))
+			Expect(name).To(Equal(events.LeaderChanged))
+		})
+
+		It("sends the same events over a WebSocket", func() {
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/projects/p1/events/ws?access_token=" + buyer
+			ws, err := websocket.Dial(url, "", server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			defer ws.Close()
+			Expect(ws.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+
+			token = buyer
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 50}).Code).To(Equal(http.StatusCreated))
+
+			var event map[string]interface{}
+			Expect(websocket.JSON.Receive(ws, &event)).To(Succeed())
+			Expect(event).To(HaveKeyWithValue("type", events.BidPlaced))
+			Expect(event).To(HaveKeyWithValue("project_id", "p1"))
+		})
+
+		It("checks the token, the project and the stream position before streaming", func() {
+			token = ""
+			Expect(do(http.MethodGet, "/v1/projects/p1/events", nil).Code).To(Equal(http.StatusUnauthorized))
+			token = buyer
+			Expect(do(http.MethodGet, "/v1/projects/nope/events", nil).Code).To(Equal(http.StatusNotFound))
+			rec := do(http.MethodGet, "/v1/projects/p1/events?lastEventId=abc", nil)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"invalid_last_event_id"`))
+		})
+	})
+
+	Describe("projects", func() {
+		It("gets a single project", func() {
+			rec := do(http.MethodGet, "/v1/projects/p1", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"seller_id":"s1"`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"status":"open"`))
+		})
+
+		It("generates ids that sort in creation order and rejects taken ones", func() {
+			var ids []string
+			for i := 0; i < 2; i++ {
+				rec := do(http.MethodPost, "/v1/projects", project.ProjectDetails{})
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				var created project.ProjectDetails
+				Expect(json.Unmarshal(rec.Body.Bytes(), &created)).To(Succeed())
+				Expect(rec.Header().Get(echo.HeaderLocation)).To(Equal("/v1/projects/" + created.ID))
+				ids = append(ids, created.ID)
+			}
+			Expect(ids[0] < ids[1]).To(BeTrue())
+
+			rec := do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p1"})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"project_exists"`))
+		})
+
+		It("returns 404 for an unknown project", func() {
+			Expect(do(http.MethodGet, "/v1/projects/nope", nil).Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("patches editable fields", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{
+				"details":  []string{"updated"},
+				"strategy": bidManager.StrategyFirstPrice,
+			})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"details":["updated"]`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"strategy":"first-price"`))
+		})
+
+		It("rejects an unknown strategy", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{"strategy": "dutch"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("validates patched fields", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{"status": "paused", "id": "a.b"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(controller.MIMEApplicationProblemJSON))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"status"`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"id"`))
+		})
+
+		It("rejects projects for sellers that do not exist", func() {
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			rec := do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p2", SellerID: "ghost"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"not_found"`))
+		})
+
+		It("changes status through PATCH", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{"status": "cancelled"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"status":"cancelled"`))
+
+			rec = do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{"details": []string{"late"}})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("deletes a project", func() {
+			Expect(do(http.MethodDelete, "/v1/projects/p1", nil).Code).To(Equal(http.StatusNoContent))
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Code).To(Equal(http.StatusNotFound))
+			Expect(do(http.MethodDelete, "/v1/projects/p1", nil).Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("shows a hidden reserve price only to the seller", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1", map[string]interface{}{"reserve_price": 500, "reserve_hidden": true})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"reserve_price":500`))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Body.String()).ToNot(ContainSubstring(`"reserve_price"`))
+			Expect(do(http.MethodGet, "/get-projects", nil).Body.String()).ToNot(ContainSubstring(`"reserve_price"`))
+			Expect(do(http.MethodGet, "/v1/projects?fields=reserve_price", nil).Body.String()).ToNot(ContainSubstring(`"reserve_price"`))
+		})
+
+		It("lists projects a page at a time", func() {
+			for _, id := range []string{"p2", "p3"} {
+				Expect(do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: id, Tags: []string{"roads"}}).Code).To(Equal(http.StatusCreated))
+			}
+
+			rec := do(http.MethodGet, "/v1/projects?tag=roads&limit=1&fields=status", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(HavePrefix(`[{"id":"p2","status":"open",`))
+			Expect(rec.Body.String()).ToNot(ContainSubstring(`"seller_id"`))
+			link := rec.Header().Get("Link")
+			Expect(link).To(HaveSuffix(`>; rel="next"`))
+
+			rec = do(http.MethodGet, strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`), nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(HavePrefix(`[{"id":"p3","status":"open",`))
+			Expect(rec.Header().Get("Link")).To(BeEmpty())
+		})
+
+		It("rejects malformed listing parameters", func() {
+			for query, code := range map[string]string{
+				"limit=many":       "invalid_query",
+				"end_after=monday": "invalid_query",
+				"status=paused":    "invalid_query",
+				"limit=1000":       "invalid_limit",
+				"sort=price":       "invalid_sort",
+				"fields=colour":    "invalid_field",
+				"cursor=x":         "invalid_cursor",
+			} {
+				rec := do(http.MethodGet, "/v1/projects?"+query, nil)
+				Expect(rec.Code).To(Equal(http.StatusBadRequest), query)
+				Expect(rec.Body.String()).To(ContainSubstring(`"code":"`+code+`"`), query)
+			}
+		})
+	})
+
+	Describe("bids", func() {
+		BeforeEach(func() {
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec := do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 50})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Body.String()).To(ContainSubstring(`"ammount":50`))
+		})
+
+		It("lists a project's bids", func() {
+			rec := do(http.MethodGet, "/v1/projects/p1/bids", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var bids []project.BID
+			Expect(json.Unmarshal(rec.Body.Bytes(), &bids)).To(Succeed())
+			Expect(bids).To(HaveLen(1))
+			Expect(bids[0].ID).To(Equal("b1"))
+		})
+
+		It("takes amounts under either key and in the project's currency", func() {
+			token = tokenFor(auth.RoleSeller, "s1")
+			Expect(do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p2", Currency: "EUR"}).Code).To(Equal(http.StatusCreated))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec := do(http.MethodPost, "/v1/projects/p2/bids", echo.Map{"id": "b1", "amount": "12.50", "currency": "EUR"})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Body.String()).To(ContainSubstring(`"amount":1250`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"ammount":1250`))
+
+			rec = do(http.MethodPatch, "/v1/projects/p2/bids/b1", echo.Map{"amount": 1300, "currency": "USD"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"currency_mismatch"`))
+
+			rec = do(http.MethodPatch, "/v1/projects/p2/bids/b1", echo.Map{"amount": "12.505"})
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("patches a bid's amount", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1/bids/b1", map[string]interface{}{"ammount": 40})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"ammount":40`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"buyer_id":"u1"`))
+		})
+
+		It("keeps every amendment in the bid's history", func() {
+			Expect(do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 40}).Code).To(Equal(http.StatusOK))
+			Expect(do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 45}).Code).To(Equal(http.StatusOK))
+
+			rec := do(http.MethodGet, "/v1/projects/p1/bids/b1", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			var bid project.BID
+			Expect(json.Unmarshal(rec.Body.Bytes(), &bid)).To(Succeed())
+			Expect(bid.Amount).To(Equal(45))
+			Expect(bid.Status).To(Equal(project.BidActive))
+			Expect(bid.Revision).To(Equal(3))
+			Expect(bid.Revisions).To(HaveLen(3))
+			Expect(bid.Revisions[0].Action).To(Equal(project.ActionPlaced))
+			Expect(bid.Revisions[0].Amount).To(Equal(50))
+			Expect(bid.Revisions[1].Amount).To(Equal(40))
+			Expect(bid.Revisions[2].Action).To(Equal(project.ActionAmended))
+		})
+
+		It("does not let another buyer reuse a bid id", func() {
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			Expect(do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u2"}).Code).To(Equal(http.StatusCreated))
+
+			token = tokenFor(auth.RoleBuyer, "u2")
+			rec := do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 10})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"bid_exists"`))
+		})
+
+		It("generates the id of a bid placed without one", func() {
+			rec := do(http.MethodPost, "/v1/projects/p1/bids", project.BID{Amount: 60})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			var bid project.BID
+			Expect(json.Unmarshal(rec.Body.Bytes(), &bid)).To(Succeed())
+			Expect(bid.ID).To(HaveLen(24))
+			Expect(rec.Header().Get(echo.HeaderLocation)).To(Equal("/v1/projects/p1/bids/" + bid.ID))
+			Expect(do(http.MethodGet, rec.Header().Get(echo.HeaderLocation), nil).Code).To(Equal(http.StatusOK))
+
+			rec = do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 45})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"bid_exists"`))
+		})
+
+		It("enforces the improve-only amendment policy", func() {
+			token = tokenFor(auth.RoleSeller, "s1")
+			Expect(do(http.MethodPatch, "/v1/projects/p1", echo.Map{"amendment_policy": "improve-only"}).Code).To(Equal(http.StatusOK))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec := do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 60})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"bid_not_improved"`))
+			Expect(do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 30}).Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects an unknown amendment policy", func() {
+			token = tokenFor(auth.RoleSeller, "s1")
+			rec := do(http.MethodPatch, "/v1/projects/p1", echo.Map{"amendment_policy": "sometimes"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"amendment_policy"`))
+		})
+
+		It("rejects a patch that makes the bid invalid", func() {
+			rec := do(http.MethodPatch, "/v1/projects/p1/bids/b1", map[string]interface{}{"ammount": -1})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"amount"`))
+		})
+
+		It("retracts a bid with a reason and keeps it in the history", func() {
+			rec := do(http.MethodPost, "/v1/projects/p1/bids/b1/retraction", echo.Map{"reason": "priced wrong"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var bid project.BID
+			Expect(json.Unmarshal(rec.Body.Bytes(), &bid)).To(Succeed())
+			Expect(bid.Status).To(Equal(project.BidRetracted))
+			Expect(bid.RetractReason).To(Equal("priced wrong"))
+			Expect(bid.Revisions).To(HaveLen(2))
+			Expect(bid.Revisions[1].Action).To(Equal(project.ActionRetracted))
+			Expect(bid.Revisions[1].Reason).To(Equal("priced wrong"))
+
+			rec = do(http.MethodPost, "/v1/projects/p1/bids/b1/retraction", echo.Map{"reason": "again"})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 40}).Code).To(Equal(http.StatusConflict))
+		})
+
+		It("requires a reason to retract", func() {
+			rec := do(http.MethodPost, "/v1/projects/p1/bids/b1/retraction", echo.Map{})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"reason"`))
+			Expect(do(http.MethodDelete, "/v1/projects/p1/bids/b1", nil).Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("retracts a bid through DELETE", func() {
+			Expect(do(http.MethodDelete, "/v1/projects/p1/bids/b1?reason=withdrawn", nil).Code).To(Equal(http.StatusNoContent))
+
+			rec := do(http.MethodGet, "/v1/projects/p1/bids/b1", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"status":"retracted"`))
+		})
+
+		It("leaves retracted bids out of the award", func() {
+			Expect(do(http.MethodDelete, "/v1/projects/p1/bids/b1?reason=withdrawn", nil).Code).To(Equal(http.StatusNoContent))
+
+			token = tokenFor(auth.RoleSeller, "s1")
+			rec := do(http.MethodPost, "/v1/projects/p1/award", nil)
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"no_bids"`))
+		})
+
+		It("awards the project", func() {
+			token = tokenFor(auth.RoleSeller, "s1")
+			rec := do(http.MethodPost, "/v1/projects/p1/award", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"buyer_name":"Buyer"`))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec = do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b2", Amount: 10})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("sealed bids", func() {
+		const salt = "a-long-random-salt"
+
+		BeforeEach(func() {
+			Expect(do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p2", Sealed: true}).Code).To(Equal(http.StatusCreated))
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			Expect(do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u2"}).Code).To(Equal(http.StatusCreated))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec := do(http.MethodPost, "/v1/projects/p2/bids", project.BID{ID: "b1", Commitment: project.Commit("p2", "b1", 70, salt)})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Body.String()).ToNot(ContainSubstring(`"ammount":70`))
+		})
+
+		It("takes a commitment instead of an amount", func() {
+			rec := do(http.MethodPost, "/v1/projects/p2/bids", project.BID{ID: "b2", Amount: 70})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"amount"`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"commitment"`))
+
+			rec = do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b2", Amount: 70, Commitment: project.Commit("p1", "b2", 70, salt)})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"commitment"`))
+		})
+
+		It("reveals bids once bidding has closed", func() {
+			rec := do(http.MethodPost, "/v1/projects/p2/bids/b1/reveal", echo.Map{"ammount": 70, "salt": salt})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"reveal_not_started"`))
+
+			token = tokenFor(auth.RoleSeller, "s1")
+			Expect(do(http.MethodPatch, "/v1/projects/p2", echo.Map{"status": "closed"}).Code).To(Equal(http.StatusOK))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec = do(http.MethodPost, "/v1/projects/p2/bids/b1/reveal", echo.Map{"ammount": 60, "salt": salt})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"reveal_mismatch"`))
+			rec = do(http.MethodPost, "/v1/projects/p2/bids/b1/reveal", echo.Map{"ammount": 70, "salt": "short"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"salt"`))
+
+			rec = do(http.MethodPost, "/v1/projects/p2/bids/b1/reveal", echo.Map{"ammount": 70, "salt": salt})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"ammount":70`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"revealed":true`))
+		})
+
+		It("hides revealed amounts from everyone but the buyer until the award", func() {
+			token = tokenFor(auth.RoleSeller, "s1")
+			Expect(do(http.MethodPatch, "/v1/projects/p2", echo.Map{"status": "closed"}).Code).To(Equal(http.StatusOK))
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(do(http.MethodPost, "/v1/projects/p2/bids/b1/reveal", echo.Map{"ammount": 70, "salt": salt}).Code).To(Equal(http.StatusOK))
+
+			for _, caller := range []string{tokenFor(auth.RoleBuyer, "u2"), tokenFor(auth.RoleSeller, "s1")} {
+				token = caller
+				Expect(do(http.MethodGet, "/v1/projects/p2/bids", nil).Body.String()).ToNot(ContainSubstring(`"ammount":70`))
+				Expect(do(http.MethodGet, "/v1/projects/p2/bids/b1", nil).Body.String()).ToNot(ContainSubstring(`"ammount":70`))
+			}
+
+			rec := do(http.MethodPost, "/v1/projects/p2/award", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			token = tokenFor(auth.RoleBuyer, "u2")
+			Expect(do(http.MethodGet, "/v1/projects/p2/bids/b1", nil).Body.String()).To(ContainSubstring(`"ammount":70`))
+		})
+	})
+
+	Describe("proxy bids", func() {
+		BeforeEach(func() {
+			Expect(do(http.MethodPatch, "/v1/projects/p1", echo.Map{"strategy": "first-price"}).Code).To(Equal(http.StatusOK))
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			Expect(do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u2"}).Code).To(Equal(http.StatusCreated))
+			token = tokenFor(auth.RoleBuyer, "u1")
+		})
+
+		It("keeps a buyer's maximum to themselves while bidding for them", func() {
+			rec := do(http.MethodPut, "/v1/projects/p1/proxies/u1", echo.Map{"bid_id": "b1", "maximum": 90})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"maximum":90`))
+
+			token = tokenFor(auth.RoleBuyer, "u2")
+			Expect(do(http.MethodGet, "/v1/projects/p1/proxies/u1", nil).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b2", Amount: 50}).Code).To(Equal(http.StatusCreated))
+
+			rec = do(http.MethodGet, "/v1/projects/p1/bids/b1", nil)
+			Expect(rec.Body.String()).To(ContainSubstring(`"ammount":51`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"auto":true`))
+			Expect(rec.Body.String()).ToNot(ContainSubstring(`"maximum"`))
+		})
+
+		It("validates and cancels proxies", func() {
+			rec := do(http.MethodPut, "/v1/projects/p1/proxies/u1", echo.Map{"bid_id": "b1"})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"field":"maximum"`))
+			Expect(do(http.MethodPut, "/v1/projects/p1/proxies/u2", echo.Map{"bid_id": "b1", "maximum": 90}).Code).To(Equal(http.StatusForbidden))
+
+			Expect(do(http.MethodPut, "/v1/projects/p1/proxies/u1", echo.Map{"bid_id": "b1", "maximum": 90}).Code).To(Equal(http.StatusOK))
+			Expect(do(http.MethodDelete, "/v1/projects/p1/proxies/u1", nil).Code).To(Equal(http.StatusNoContent))
+			rec = do(http.MethodGet, "/v1/projects/p1/proxies/u1", nil)
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"proxy_not_found"`))
+		})
+	})
+
+	Describe("buyers and sellers", func() {
+		It("gets, patches and deletes a buyer", func() {
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(do(http.MethodGet, "/v1/buyers/u1", nil).Code).To(Equal(http.StatusOK))
+
+			rec := do(http.MethodPatch, "/v1/buyers/u1", project.Buyer{BuyerName: "Renamed"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"buyer_name":"Renamed"`))
+
+			Expect(do(http.MethodDelete, "/v1/buyers/u1", nil).Code).To(Equal(http.StatusNoContent))
+			Expect(do(http.MethodGet, "/v1/buyers/u1", nil).Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("lists sellers and patches one", func() {
+			rec := do(http.MethodGet, "/v1/sellers", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"id":"s1"`))
+
+			rec = do(http.MethodPatch, "/v1/sellers/s1", project.Seller{SellerName: "New"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"seller_name":"New"`))
+
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			Expect(do(http.MethodPatch, "/v1/sellers/nope", project.Seller{SellerName: "x"}).Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("registers buyers and sellers with generated ids and rejects taken ones", func() {
+			rec := do(http.MethodPost, "/v1/buyers", project.Buyer{BuyerName: "New", BuyerID: "ignored"})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			var buyer project.Buyer
+			Expect(json.Unmarshal(rec.Body.Bytes(), &buyer)).To(Succeed())
+			Expect(buyer.ID).ToNot(BeEmpty())
+			Expect(buyer.BuyerID).To(Equal(buyer.ID))
+			Expect(rec.Header().Get(echo.HeaderLocation)).To(Equal("/v1/buyers/" + buyer.ID))
+
+			rec = do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u1"})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"buyer_exists"`))
+			rec = do(http.MethodPost, "/v1/sellers", project.Seller{ID: "s1"})
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"seller_exists"`))
+			Expect(do(http.MethodPost, "/v1/sellers", project.Seller{ID: "s2"}).Header().Get(echo.HeaderLocation)).To(Equal("/v1/sellers/s2"))
+		})
+
+		It("returns 400 for a malformed body", func() {
+			req := httptest.NewRequest(http.MethodPost, "/v1/buyers", bytes.NewBufferString("{bad"))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("idempotency keys", func() {
+		var key string
+
+		// send is do with an Idempotency-Key.
+		send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+			var buf bytes.Buffer
+			Expect(json.NewEncoder(&buf).Encode(body)).To(Succeed())
+			req := httptest.NewRequest(method, path, &buf)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(controller.HeaderIdempotencyKey, key)
+			if token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		BeforeEach(func() {
+			key = "retry-1"
+			store := idempotency.NewStore(client, "projects", idempotency.Options{})
+			e = echo.New()
+			controller.NewController(bm, pm, tokenManager, controller.Options{Idempotency: store}).AttachHandlers(e)
+		})
+
+		It("replays the response to a retried creation without creating again", func() {
+			first := send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Fence"}})
+			Expect(first.Code).To(Equal(http.StatusCreated))
+			Expect(first.Header().Get(controller.HeaderIdempotencyReplayed)).To(BeEmpty())
+
+			retry := send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Fence"}})
+			Expect(retry.Code).To(Equal(http.StatusCreated))
+			Expect(retry.Header().Get(controller.HeaderIdempotencyReplayed)).To(Equal("true"))
+			Expect(retry.Header().Get(echo.HeaderLocation)).To(Equal(first.Header().Get(echo.HeaderLocation)))
+			Expect(retry.Header().Get(controller.HeaderETag)).To(Equal(`"1"`))
+			Expect(retry.Body.String()).To(Equal(first.Body.String()))
+
+			projects, err := pm.GetProjects(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(projects).To(HaveLen(2))
+		})
+
+		It("places a retried bid once", func() {
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(send(http.MethodPost, "/v1/projects/p1/bids", project.BID{Amount: 50}).Code).To(Equal(http.StatusCreated))
+			Expect(send(http.MethodPost, "/v1/projects/p1/bids", project.BID{Amount: 50}).Code).To(Equal(http.StatusCreated))
+
+			rec := do(http.MethodGet, "/v1/projects/p1/bids", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			var bids []project.BID
+			Expect(json.Unmarshal(rec.Body.Bytes(), &bids)).To(Succeed())
+			Expect(bids).To(HaveLen(1))
+		})
+
+		It("replays errors too", func() {
+			Expect(send(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p1"}).Code).To(Equal(http.StatusConflict))
+			Expect(pm.DeleteProject(context.Background(), "p1", 0)).To(Succeed())
+
+			retry := send(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p1"})
+			Expect(retry.Code).To(Equal(http.StatusConflict))
+			Expect(retry.Header().Get(controller.HeaderIdempotencyReplayed)).To(Equal("true"))
+		})
+
+		It("rejects a key reused for a different request with 422", func() {
+			Expect(send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Fence"}}).Code).To(Equal(http.StatusCreated))
+
+			rec := send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Shed"}})
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"idempotency_key_reused"`))
+		})
+
+		It("keeps the keys of different callers apart", func() {
+			Expect(send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Fence"}}).Code).To(Equal(http.StatusCreated))
+
+			Expect(send(http.MethodPost, "/v1/sellers", project.Seller{ID: "s2"}).Code).To(Equal(http.StatusCreated))
+			token = tokenFor(auth.RoleSeller, "s2")
+			rec := send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Shed"}})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Header().Get(controller.HeaderIdempotencyReplayed)).To(BeEmpty())
+		})
+
+		It("rejects overlong keys with 400", func() {
+			key = strings.Repeat("k", idempotency.MaxKeyLength)
+			rec := send(http.MethodPost, "/v1/projects", project.ProjectDetails{Details: []string{"Fence"}})
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"invalid_idempotency_key"`))
+		})
+
+		It("also covers the legacy creation routes", func() {
+			token = ""
+			Expect(send(http.MethodPost, "/create-buyer", project.Buyer{BuyerName: "Retry"}).Code).To(Equal(http.StatusCreated))
+			retry := send(http.MethodPost, "/create-buyer", project.Buyer{BuyerName: "Retry"})
+			Expect(retry.Code).To(Equal(http.StatusCreated))
+			Expect(retry.Header().Get(controller.HeaderIdempotencyReplayed)).To(Equal("true"))
+
+			buyers, err := pm.GetBuyers(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buyers).To(HaveLen(2))
+		})
+	})
+
+	Describe("conditional requests", func() {
+		// conditional is do with a conditional header.
+		conditional := func(method, path, header, value string, body interface{}) *httptest.ResponseRecorder {
+			var buf bytes.Buffer
+			if body != nil {
+				Expect(json.NewEncoder(&buf).Encode(body)).To(Succeed())
+			}
+			req := httptest.NewRequest(method, path, &buf)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(header, value)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		It("serves projects with their version as ETag", func() {
+			rec := do(http.MethodGet, "/v1/projects/p1", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get(controller.HeaderETag)).To(Equal(`"1"`))
+			Expect(rec.Body.String()).To(ContainSubstring(`"version":1`))
+
+			rec = do(http.MethodPatch, "/v1/projects/p1", project.ProjectDetails{Tags: []string{"wood"}})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get(controller.HeaderETag)).To(Equal(`"2"`))
+		})
+
+		It("answers If-None-Match with 304 until the project changes", func() {
+			rec := conditional(http.MethodGet, "/v1/projects/p1", controller.HeaderIfNoneMatch, `W/"1"`, nil)
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+			Expect(rec.Body.Len()).To(BeZero())
+			Expect(rec.Header().Get(controller.HeaderETag)).To(Equal(`"1"`))
+
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 50}).Code).To(Equal(http.StatusCreated))
+
+			rec = conditional(http.MethodGet, "/v1/projects/p1", controller.HeaderIfNoneMatch, `"1"`, nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get(controller.HeaderETag)).To(Equal(`"2"`))
+		})
+
+		It("rejects edits with a stale If-Match with 412", func() {
+			rec := conditional(http.MethodPatch, "/v1/projects/p1", controller.HeaderIfMatch, `"1"`, project.ProjectDetails{Tags: []string{"wood"}})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			rec = conditional(http.MethodPatch, "/v1/projects/p1", controller.HeaderIfMatch, `"1"`, project.ProjectDetails{Tags: []string{"metal"}})
+			Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(rec.Body.String()).To(ContainSubstring(`"code":"precondition_failed"`))
+
+			saved, err := pm.GetProject(context.Background(), "p1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Tags).To(Equal([]string{"wood"}))
+
+			Expect(conditional(http.MethodDelete, "/v1/projects/p1", controller.HeaderIfMatch, `"1"`, nil).Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(conditional(http.MethodDelete, "/v1/projects/p1", controller.HeaderIfMatch, `"3", "2"`, nil).Code).To(Equal(http.StatusNoContent))
+		})
+
+		It("rejects bids on a project that changed since it was read", func() {
+			token = tokenFor(auth.RoleBuyer, "u1")
+			rec := conditional(http.MethodPost, "/v1/projects/p1/bids", controller.HeaderIfMatch, `"1"`, project.BID{ID: "b1", Amount: 50})
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+
+			rec = conditional(http.MethodPost, "/v1/projects/p1/bids", controller.HeaderIfMatch, `"1"`, project.BID{ID: "b2", Amount: 40})
+			Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(conditional(http.MethodPost, "/v1/projects/p1/bids", controller.HeaderIfMatch, "*", project.BID{ID: "b2", Amount: 40}).Code).To(Equal(http.StatusCreated))
+		})
+
+		It("never matches a weak ETag in If-Match", func() {
+			rec := conditional(http.MethodPatch, "/v1/projects/p1", controller.HeaderIfMatch, `W/"1"`, project.ProjectDetails{Tags: []string{"wood"}})
+			Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+		})
+	})
+
+	Describe("authentication", func() {
+		login := func(role auth.Role, id, password string) *httptest.ResponseRecorder {
+			token = ""
+			return do(http.MethodPost, "/v1/auth/token", echo.Map{"role": role, "id": id, "password": password})
+		}
+
+		BeforeEach(func() {
+			token = ""
+			Expect(do(http.MethodPost, "/v1/buyers", project.Buyer{ID: "u2", Password: "secret-pass"}).Code).To(Equal(http.StatusCreated))
+		})
+
+		It("never returns the password or its hash", func() {
+			token = tokenFor(auth.RoleBuyer, "u2")
+			rec := do(http.MethodGet, "/v1/buyers/u2", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).ToNot(ContainSubstring("password"))
+			Expect(rec.Body.String()).ToNot(ContainSubstring("secret"))
+		})
+
+		It("issues a token for valid credentials", func() {
+			rec := login(auth.RoleBuyer, "u2", "secret-pass")
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var res struct{ Token string }
+			Expect(json.Unmarshal(rec.Body.Bytes(), &res)).To(Succeed())
+			claims, err := tokenManager.ParseToken(res.Token)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims.Role).To(Equal(auth.RoleBuyer))
+			Expect(claims.Subject).To(Equal("u2"))
+
+			token = res.Token
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects bad credentials without revealing which accounts exist", func() {
+			Expect(login(auth.RoleBuyer, "u2", "wrong").Code).To(Equal(http.StatusUnauthorized))
+			Expect(login(auth.RoleBuyer, "nobody", "secret-pass").Code).To(Equal(http.StatusUnauthorized))
+			Expect(login(auth.RoleSeller, "u2", "secret-pass").Code).To(Equal(http.StatusUnauthorized))
+			Expect(login("root", "u2", "secret-pass").Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("logs the admin in with the configured password", func() {
+			Expect(login(auth.RoleAdmin, "", "admin-pass").Code).To(Equal(http.StatusOK))
+			Expect(login(auth.RoleAdmin, "", "nope").Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("requires a valid token on protected routes", func() {
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Code).To(Equal(http.StatusUnauthorized))
+
+			token = "not-a-jwt"
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Code).To(Equal(http.StatusUnauthorized))
+
+			token = tokenFor(auth.RoleBuyer, "u1")[1:]
+			Expect(do(http.MethodGet, "/v1/projects/p1", nil).Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("enforces ownership", func() {
+			token = tokenFor(auth.RoleBuyer, "u1")
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", BuyerID: "u2", Amount: 5}).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodPost, "/v1/projects", project.ProjectDetails{ID: "p2", SellerID: "s1"}).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodPost, "/v1/projects/p1/award", nil).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodPatch, "/v1/buyers/u2", project.Buyer{BuyerName: "x"}).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodPost, "/v1/projects/p1/bids", project.BID{ID: "b1", Amount: 5}).Code).To(Equal(http.StatusCreated))
+
+			token = tokenFor(auth.RoleBuyer, "u2")
+			Expect(do(http.MethodPatch, "/v1/projects/p1/bids/b1", echo.Map{"ammount": 1}).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodDelete, "/v1/projects/p1/bids/b1?reason=mine", nil).Code).To(Equal(http.StatusForbidden))
+
+			token = tokenFor(auth.RoleSeller, "s2")
+			Expect(do(http.MethodPatch, "/v1/projects/p1", echo.Map{"status": "cancelled"}).Code).To(Equal(http.StatusForbidden))
+			Expect(do(http.MethodDelete, "/v1/projects/p1", nil).Code).To(Equal(http.StatusForbidden))
+
+			token = tokenFor(auth.RoleAdmin, auth.AdminSubject)
+			Expect(do(http.MethodDelete, "/v1/projects/p1/bids/b1?reason=spam", nil).Code).To(Equal(http.StatusNoContent))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/21keshav/IBackendApplication/validation"
+)
+
+type line struct {
+	SKU      string `json:"sku" validate:"required,key"`
+	Quantity int    `json:"quantity" validate:"min=1,max=10"`
+}
+
+type order struct {
+	ID     string          `json:"id" validate:"required,max=4"`
+	Kind   string          `json:"kind" validate:"omitempty,oneof=retail wholesale"`
+	Notes  []string        `json:"notes" validate:"max=2"`
+	Lines  map[string]line `json:"lines"`
+	Extra  *line           `json:"extra"`
+	secret string
+}
+
+var _ = Describe("Struct", func() {
+	It("accepts a valid value", func() {
+		o := order{ID: "o1", Kind: "retail", Lines: map[string]line{"a": {SKU: "x", Quantity: 2}}}
+		Expect(validation.Struct(o)).To(Succeed())
+		Expect(validation.Struct(&o)).To(Succeed())
+	})
+
+	It("reports every failing field with its json path and code", func() {
+		o := order{
+			ID:    "too-long",
+			Kind:  "resale",
+			Notes: []string{"a", "b", "c"},
+			Lines: map[string]line{"a": {SKU: "$x", Quantity: 0}},
+			Extra: &line{Quantity: 11},
+		}
+		err := validation.Struct(o)
+		Expect(err).To(BeAssignableToTypeOf(validation.Errors{}))
+
+		codes := map[string]string{}
+		for _, fe := range err.(validation.Errors) {
+			codes[fe.Field] = fe.Code
+		}
+		Expect(codes).To(Equal(map[string]string{
+			"id":               validation.CodeTooLarge,
+			"kind":             validation.CodeNotAllowed,
+			"notes":            validation.CodeTooLarge,
+			"lines.a.sku":      validation.CodeInvalidKey,
+			"lines.a.quantity": validation.CodeTooSmall,
+			"extra.sku":        validation.CodeRequired,
+			"extra.quantity":   validation.CodeTooLarge,
+		}))
+	})
+
+	It("stops at the first failing rule of a field", func() {
+		err := validation.Struct(order{})
+		Expect(err).To(Equal(validation.Errors{
+			{Field: "id", Code: validation.CodeRequired, Message: "is required"},
+		}))
+		Expect(err.Error()).To(Equal("id: is required"))
+	})
+})
+
+var _ = Describe("Partial", func() {
+	It("skips required but still checks the fields that are set", func() {
+		Expect(validation.Partial(order{})).To(Succeed())
+		Expect(validation.Partial(order{Kind: "resale"})).To(HaveOccurred())
+	})
+})
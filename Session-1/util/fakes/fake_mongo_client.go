@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/21keshav/IBackendApplication/util"
@@ -9,13 +10,55 @@ import (
 )
 
 type FakeMongoClient struct {
-	FindAllObjectsStub        func(string, string, interface{}, int64) error
+	CreateIndexStub        func(context.Context, string, string, util.Index) error
+	createIndexMutex       sync.RWMutex
+	createIndexArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 util.Index
+	}
+	createIndexReturns struct {
+		result1 error
+	}
+	createIndexReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteOneStub        func(context.Context, string, string, interface{}) (*mongo.DeleteResult, error)
+	deleteOneMutex       sync.RWMutex
+	deleteOneArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+	}
+	deleteOneReturns struct {
+		result1 *mongo.DeleteResult
+		result2 error
+	}
+	deleteOneReturnsOnCall map[int]struct {
+		result1 *mongo.DeleteResult
+		result2 error
+	}
+	DisconnectStub        func(context.Context) error
+	disconnectMutex       sync.RWMutex
+	disconnectArgsForCall []struct {
+		arg1 context.Context
+	}
+	disconnectReturns struct {
+		result1 error
+	}
+	disconnectReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindAllObjectsStub        func(context.Context, string, string, interface{}, int64) error
 	findAllObjectsMutex       sync.RWMutex
 	findAllObjectsArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
-		arg4 int64
+		arg3 string
+		arg4 interface{}
+		arg5 int64
 	}
 	findAllObjectsReturns struct {
 		result1 error
@@ -23,13 +66,14 @@ type FakeMongoClient struct {
 	findAllObjectsReturnsOnCall map[int]struct {
 		result1 error
 	}
-	FindObjectStub        func(string, string, interface{}, interface{}) error
+	FindObjectStub        func(context.Context, string, string, interface{}, interface{}) error
 	findObjectMutex       sync.RWMutex
 	findObjectArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
+		arg5 interface{}
 	}
 	findObjectReturns struct {
 		result1 error
@@ -37,13 +81,14 @@ type FakeMongoClient struct {
 	findObjectReturnsOnCall map[int]struct {
 		result1 error
 	}
-	FindObjectsStub        func(string, string, interface{}, interface{}) error
+	FindObjectsStub        func(context.Context, string, string, interface{}, interface{}) error
 	findObjectsMutex       sync.RWMutex
 	findObjectsArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
+		arg5 interface{}
 	}
 	findObjectsReturns struct {
 		result1 error
@@ -51,6 +96,22 @@ type FakeMongoClient struct {
 	findObjectsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FindObjectsWithOptionsStub        func(context.Context, string, string, interface{}, util.FindOptions, interface{}) error
+	findObjectsWithOptionsMutex       sync.RWMutex
+	findObjectsWithOptionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+		arg5 util.FindOptions
+		arg6 interface{}
+	}
+	findObjectsWithOptionsReturns struct {
+		result1 error
+	}
+	findObjectsWithOptionsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetCollectionStub        func(string, string) *mongo.Collection
 	getCollectionMutex       sync.RWMutex
 	getCollectionArgsForCall []struct {
@@ -74,12 +135,13 @@ type FakeMongoClient struct {
 	getDatabaseReturnsOnCall map[int]struct {
 		result1 *mongo.Database
 	}
-	InsertDataStub        func(string, string, interface{}) (*mongo.InsertOneResult, error)
+	InsertDataStub        func(context.Context, string, string, interface{}) (*mongo.InsertOneResult, error)
 	insertDataMutex       sync.RWMutex
 	insertDataArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
+		arg4 interface{}
 	}
 	insertDataReturns struct {
 		result1 *mongo.InsertOneResult
@@ -89,13 +151,25 @@ type FakeMongoClient struct {
 		result1 *mongo.InsertOneResult
 		result2 error
 	}
-	UpdateOneStub        func(string, string, interface{}, interface{}) (*mongo.UpdateResult, error)
+	PingStub        func(context.Context) error
+	pingMutex       sync.RWMutex
+	pingArgsForCall []struct {
+		arg1 context.Context
+	}
+	pingReturns struct {
+		result1 error
+	}
+	pingReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateOneStub        func(context.Context, string, string, interface{}, interface{}) (*mongo.UpdateResult, error)
 	updateOneMutex       sync.RWMutex
 	updateOneArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
+		arg5 interface{}
 	}
 	updateOneReturns struct {
 		result1 *mongo.UpdateResult
@@ -105,23 +179,230 @@ type FakeMongoClient struct {
 		result1 *mongo.UpdateResult
 		result2 error
 	}
+	UpsertOneStub        func(context.Context, string, string, interface{}, interface{}) (*mongo.UpdateResult, error)
+	upsertOneMutex       sync.RWMutex
+	upsertOneArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+		arg5 interface{}
+	}
+	upsertOneReturns struct {
+		result1 *mongo.UpdateResult
+		result2 error
+	}
+	upsertOneReturnsOnCall map[int]struct {
+		result1 *mongo.UpdateResult
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeMongoClient) FindAllObjects(arg1 string, arg2 string, arg3 interface{}, arg4 int64) error {
+func (fake *FakeMongoClient) CreateIndex(arg1 context.Context, arg2 string, arg3 string, arg4 util.Index) error {
+	fake.createIndexMutex.Lock()
+	ret, specificReturn := fake.createIndexReturnsOnCall[len(fake.createIndexArgsForCall)]
+	fake.createIndexArgsForCall = append(fake.createIndexArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 util.Index
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("CreateIndex", []interface{}{arg1, arg2, arg3, arg4})
+	fake.createIndexMutex.Unlock()
+	if fake.CreateIndexStub != nil {
+		return fake.CreateIndexStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createIndexReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMongoClient) CreateIndexCallCount() int {
+	fake.createIndexMutex.RLock()
+	defer fake.createIndexMutex.RUnlock()
+	return len(fake.createIndexArgsForCall)
+}
+
+func (fake *FakeMongoClient) CreateIndexCalls(stub func(context.Context, string, string, util.Index) error) {
+	fake.createIndexMutex.Lock()
+	defer fake.createIndexMutex.Unlock()
+	fake.CreateIndexStub = stub
+}
+
+func (fake *FakeMongoClient) CreateIndexArgsForCall(i int) (context.Context, string, string, util.Index) {
+	fake.createIndexMutex.RLock()
+	defer fake.createIndexMutex.RUnlock()
+	argsForCall := fake.createIndexArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeMongoClient) CreateIndexReturns(result1 error) {
+	fake.createIndexMutex.Lock()
+	defer fake.createIndexMutex.Unlock()
+	fake.CreateIndexStub = nil
+	fake.createIndexReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) CreateIndexReturnsOnCall(i int, result1 error) {
+	fake.createIndexMutex.Lock()
+	defer fake.createIndexMutex.Unlock()
+	fake.CreateIndexStub = nil
+	if fake.createIndexReturnsOnCall == nil {
+		fake.createIndexReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createIndexReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) DeleteOne(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}) (*mongo.DeleteResult, error) {
+	fake.deleteOneMutex.Lock()
+	ret, specificReturn := fake.deleteOneReturnsOnCall[len(fake.deleteOneArgsForCall)]
+	fake.deleteOneArgsForCall = append(fake.deleteOneArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("DeleteOne", []interface{}{arg1, arg2, arg3, arg4})
+	fake.deleteOneMutex.Unlock()
+	if fake.DeleteOneStub != nil {
+		return fake.DeleteOneStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.deleteOneReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMongoClient) DeleteOneCallCount() int {
+	fake.deleteOneMutex.RLock()
+	defer fake.deleteOneMutex.RUnlock()
+	return len(fake.deleteOneArgsForCall)
+}
+
+func (fake *FakeMongoClient) DeleteOneCalls(stub func(context.Context, string, string, interface{}) (*mongo.DeleteResult, error)) {
+	fake.deleteOneMutex.Lock()
+	defer fake.deleteOneMutex.Unlock()
+	fake.DeleteOneStub = stub
+}
+
+func (fake *FakeMongoClient) DeleteOneArgsForCall(i int) (context.Context, string, string, interface{}) {
+	fake.deleteOneMutex.RLock()
+	defer fake.deleteOneMutex.RUnlock()
+	argsForCall := fake.deleteOneArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeMongoClient) DeleteOneReturns(result1 *mongo.DeleteResult, result2 error) {
+	fake.deleteOneMutex.Lock()
+	defer fake.deleteOneMutex.Unlock()
+	fake.DeleteOneStub = nil
+	fake.deleteOneReturns = struct {
+		result1 *mongo.DeleteResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMongoClient) DeleteOneReturnsOnCall(i int, result1 *mongo.DeleteResult, result2 error) {
+	fake.deleteOneMutex.Lock()
+	defer fake.deleteOneMutex.Unlock()
+	fake.DeleteOneStub = nil
+	if fake.deleteOneReturnsOnCall == nil {
+		fake.deleteOneReturnsOnCall = make(map[int]struct {
+			result1 *mongo.DeleteResult
+			result2 error
+		})
+	}
+	fake.deleteOneReturnsOnCall[i] = struct {
+		result1 *mongo.DeleteResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMongoClient) Disconnect(arg1 context.Context) error {
+	fake.disconnectMutex.Lock()
+	ret, specificReturn := fake.disconnectReturnsOnCall[len(fake.disconnectArgsForCall)]
+	fake.disconnectArgsForCall = append(fake.disconnectArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("Disconnect", []interface{}{arg1})
+	fake.disconnectMutex.Unlock()
+	if fake.DisconnectStub != nil {
+		return fake.DisconnectStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.disconnectReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMongoClient) DisconnectCallCount() int {
+	fake.disconnectMutex.RLock()
+	defer fake.disconnectMutex.RUnlock()
+	return len(fake.disconnectArgsForCall)
+}
+
+func (fake *FakeMongoClient) DisconnectCalls(stub func(context.Context) error) {
+	fake.disconnectMutex.Lock()
+	defer fake.disconnectMutex.Unlock()
+	fake.DisconnectStub = stub
+}
+
+func (fake *FakeMongoClient) DisconnectArgsForCall(i int) context.Context {
+	fake.disconnectMutex.RLock()
+	defer fake.disconnectMutex.RUnlock()
+	argsForCall := fake.disconnectArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMongoClient) DisconnectReturns(result1 error) {
+	fake.disconnectMutex.Lock()
+	defer fake.disconnectMutex.Unlock()
+	fake.DisconnectStub = nil
+	fake.disconnectReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) DisconnectReturnsOnCall(i int, result1 error) {
+	fake.disconnectMutex.Lock()
+	defer fake.disconnectMutex.Unlock()
+	fake.DisconnectStub = nil
+	if fake.disconnectReturnsOnCall == nil {
+		fake.disconnectReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.disconnectReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) FindAllObjects(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 int64) error {
 	fake.findAllObjectsMutex.Lock()
 	ret, specificReturn := fake.findAllObjectsReturnsOnCall[len(fake.findAllObjectsArgsForCall)]
 	fake.findAllObjectsArgsForCall = append(fake.findAllObjectsArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
-		arg4 int64
-	}{arg1, arg2, arg3, arg4})
-	fake.recordInvocation("FindAllObjects", []interface{}{arg1, arg2, arg3, arg4})
+		arg3 string
+		arg4 interface{}
+		arg5 int64
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("FindAllObjects", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.findAllObjectsMutex.Unlock()
 	if fake.FindAllObjectsStub != nil {
-		return fake.FindAllObjectsStub(arg1, arg2, arg3, arg4)
+		return fake.FindAllObjectsStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -136,17 +417,17 @@ func (fake *FakeMongoClient) FindAllObjectsCallCount() int {
 	return len(fake.findAllObjectsArgsForCall)
 }
 
-func (fake *FakeMongoClient) FindAllObjectsCalls(stub func(string, string, interface{}, int64) error) {
+func (fake *FakeMongoClient) FindAllObjectsCalls(stub func(context.Context, string, string, interface{}, int64) error) {
 	fake.findAllObjectsMutex.Lock()
 	defer fake.findAllObjectsMutex.Unlock()
 	fake.FindAllObjectsStub = stub
 }
 
-func (fake *FakeMongoClient) FindAllObjectsArgsForCall(i int) (string, string, interface{}, int64) {
+func (fake *FakeMongoClient) FindAllObjectsArgsForCall(i int) (context.Context, string, string, interface{}, int64) {
 	fake.findAllObjectsMutex.RLock()
 	defer fake.findAllObjectsMutex.RUnlock()
 	argsForCall := fake.findAllObjectsArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeMongoClient) FindAllObjectsReturns(result1 error) {
@@ -172,19 +453,20 @@ func (fake *FakeMongoClient) FindAllObjectsReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeMongoClient) FindObject(arg1 string, arg2 string, arg3 interface{}, arg4 interface{}) error {
+func (fake *FakeMongoClient) FindObject(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 interface{}) error {
 	fake.findObjectMutex.Lock()
 	ret, specificReturn := fake.findObjectReturnsOnCall[len(fake.findObjectArgsForCall)]
 	fake.findObjectArgsForCall = append(fake.findObjectArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
-	}{arg1, arg2, arg3, arg4})
-	fake.recordInvocation("FindObject", []interface{}{arg1, arg2, arg3, arg4})
+		arg5 interface{}
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("FindObject", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.findObjectMutex.Unlock()
 	if fake.FindObjectStub != nil {
-		return fake.FindObjectStub(arg1, arg2, arg3, arg4)
+		return fake.FindObjectStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -199,17 +481,17 @@ func (fake *FakeMongoClient) FindObjectCallCount() int {
 	return len(fake.findObjectArgsForCall)
 }
 
-func (fake *FakeMongoClient) FindObjectCalls(stub func(string, string, interface{}, interface{}) error) {
+func (fake *FakeMongoClient) FindObjectCalls(stub func(context.Context, string, string, interface{}, interface{}) error) {
 	fake.findObjectMutex.Lock()
 	defer fake.findObjectMutex.Unlock()
 	fake.FindObjectStub = stub
 }
 
-func (fake *FakeMongoClient) FindObjectArgsForCall(i int) (string, string, interface{}, interface{}) {
+func (fake *FakeMongoClient) FindObjectArgsForCall(i int) (context.Context, string, string, interface{}, interface{}) {
 	fake.findObjectMutex.RLock()
 	defer fake.findObjectMutex.RUnlock()
 	argsForCall := fake.findObjectArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeMongoClient) FindObjectReturns(result1 error) {
@@ -235,19 +517,20 @@ func (fake *FakeMongoClient) FindObjectReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeMongoClient) FindObjects(arg1 string, arg2 string, arg3 interface{}, arg4 interface{}) error {
+func (fake *FakeMongoClient) FindObjects(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 interface{}) error {
 	fake.findObjectsMutex.Lock()
 	ret, specificReturn := fake.findObjectsReturnsOnCall[len(fake.findObjectsArgsForCall)]
 	fake.findObjectsArgsForCall = append(fake.findObjectsArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
-	}{arg1, arg2, arg3, arg4})
-	fake.recordInvocation("FindObjects", []interface{}{arg1, arg2, arg3, arg4})
+		arg5 interface{}
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("FindObjects", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.findObjectsMutex.Unlock()
 	if fake.FindObjectsStub != nil {
-		return fake.FindObjectsStub(arg1, arg2, arg3, arg4)
+		return fake.FindObjectsStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -262,17 +545,17 @@ func (fake *FakeMongoClient) FindObjectsCallCount() int {
 	return len(fake.findObjectsArgsForCall)
 }
 
-func (fake *FakeMongoClient) FindObjectsCalls(stub func(string, string, interface{}, interface{}) error) {
+func (fake *FakeMongoClient) FindObjectsCalls(stub func(context.Context, string, string, interface{}, interface{}) error) {
 	fake.findObjectsMutex.Lock()
 	defer fake.findObjectsMutex.Unlock()
 	fake.FindObjectsStub = stub
 }
 
-func (fake *FakeMongoClient) FindObjectsArgsForCall(i int) (string, string, interface{}, interface{}) {
+func (fake *FakeMongoClient) FindObjectsArgsForCall(i int) (context.Context, string, string, interface{}, interface{}) {
 	fake.findObjectsMutex.RLock()
 	defer fake.findObjectsMutex.RUnlock()
 	argsForCall := fake.findObjectsArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeMongoClient) FindObjectsReturns(result1 error) {
@@ -298,6 +581,71 @@ func (fake *FakeMongoClient) FindObjectsReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeMongoClient) FindObjectsWithOptions(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 util.FindOptions, arg6 interface{}) error {
+	fake.findObjectsWithOptionsMutex.Lock()
+	ret, specificReturn := fake.findObjectsWithOptionsReturnsOnCall[len(fake.findObjectsWithOptionsArgsForCall)]
+	fake.findObjectsWithOptionsArgsForCall = append(fake.findObjectsWithOptionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+		arg5 util.FindOptions
+		arg6 interface{}
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.recordInvocation("FindObjectsWithOptions", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.findObjectsWithOptionsMutex.Unlock()
+	if fake.FindObjectsWithOptionsStub != nil {
+		return fake.FindObjectsWithOptionsStub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.findObjectsWithOptionsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMongoClient) FindObjectsWithOptionsCallCount() int {
+	fake.findObjectsWithOptionsMutex.RLock()
+	defer fake.findObjectsWithOptionsMutex.RUnlock()
+	return len(fake.findObjectsWithOptionsArgsForCall)
+}
+
+func (fake *FakeMongoClient) FindObjectsWithOptionsCalls(stub func(context.Context, string, string, interface{}, util.FindOptions, interface{}) error) {
+	fake.findObjectsWithOptionsMutex.Lock()
+	defer fake.findObjectsWithOptionsMutex.Unlock()
+	fake.FindObjectsWithOptionsStub = stub
+}
+
+func (fake *FakeMongoClient) FindObjectsWithOptionsArgsForCall(i int) (context.Context, string, string, interface{}, util.FindOptions, interface{}) {
+	fake.findObjectsWithOptionsMutex.RLock()
+	defer fake.findObjectsWithOptionsMutex.RUnlock()
+	argsForCall := fake.findObjectsWithOptionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *FakeMongoClient) FindObjectsWithOptionsReturns(result1 error) {
+	fake.findObjectsWithOptionsMutex.Lock()
+	defer fake.findObjectsWithOptionsMutex.Unlock()
+	fake.FindObjectsWithOptionsStub = nil
+	fake.findObjectsWithOptionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) FindObjectsWithOptionsReturnsOnCall(i int, result1 error) {
+	fake.findObjectsWithOptionsMutex.Lock()
+	defer fake.findObjectsWithOptionsMutex.Unlock()
+	fake.FindObjectsWithOptionsStub = nil
+	if fake.findObjectsWithOptionsReturnsOnCall == nil {
+		fake.findObjectsWithOptionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.findObjectsWithOptionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeMongoClient) GetCollection(arg1 string, arg2 string) *mongo.Collection {
 	fake.getCollectionMutex.Lock()
 	ret, specificReturn := fake.getCollectionReturnsOnCall[len(fake.getCollectionArgsForCall)]
@@ -419,18 +767,19 @@ func (fake *FakeMongoClient) GetDatabaseReturnsOnCall(i int, result1 *mongo.Data
 	}{result1}
 }
 
-func (fake *FakeMongoClient) InsertData(arg1 string, arg2 string, arg3 interface{}) (*mongo.InsertOneResult, error) {
+func (fake *FakeMongoClient) InsertData(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}) (*mongo.InsertOneResult, error) {
 	fake.insertDataMutex.Lock()
 	ret, specificReturn := fake.insertDataReturnsOnCall[len(fake.insertDataArgsForCall)]
 	fake.insertDataArgsForCall = append(fake.insertDataArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("InsertData", []interface{}{arg1, arg2, arg3})
+		arg3 string
+		arg4 interface{}
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("InsertData", []interface{}{arg1, arg2, arg3, arg4})
 	fake.insertDataMutex.Unlock()
 	if fake.InsertDataStub != nil {
-		return fake.InsertDataStub(arg1, arg2, arg3)
+		return fake.InsertDataStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -445,17 +794,17 @@ func (fake *FakeMongoClient) InsertDataCallCount() int {
 	return len(fake.insertDataArgsForCall)
 }
 
-func (fake *FakeMongoClient) InsertDataCalls(stub func(string, string, interface{}) (*mongo.InsertOneResult, error)) {
+func (fake *FakeMongoClient) InsertDataCalls(stub func(context.Context, string, string, interface{}) (*mongo.InsertOneResult, error)) {
 	fake.insertDataMutex.Lock()
 	defer fake.insertDataMutex.Unlock()
 	fake.InsertDataStub = stub
 }
 
-func (fake *FakeMongoClient) InsertDataArgsForCall(i int) (string, string, interface{}) {
+func (fake *FakeMongoClient) InsertDataArgsForCall(i int) (context.Context, string, string, interface{}) {
 	fake.insertDataMutex.RLock()
 	defer fake.insertDataMutex.RUnlock()
 	argsForCall := fake.insertDataArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeMongoClient) InsertDataReturns(result1 *mongo.InsertOneResult, result2 error) {
@@ -484,19 +833,80 @@ func (fake *FakeMongoClient) InsertDataReturnsOnCall(i int, result1 *mongo.Inser
 	}{result1, result2}
 }
 
-func (fake *FakeMongoClient) UpdateOne(arg1 string, arg2 string, arg3 interface{}, arg4 interface{}) (*mongo.UpdateResult, error) {
+func (fake *FakeMongoClient) Ping(arg1 context.Context) error {
+	fake.pingMutex.Lock()
+	ret, specificReturn := fake.pingReturnsOnCall[len(fake.pingArgsForCall)]
+	fake.pingArgsForCall = append(fake.pingArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("Ping", []interface{}{arg1})
+	fake.pingMutex.Unlock()
+	if fake.PingStub != nil {
+		return fake.PingStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pingReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMongoClient) PingCallCount() int {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	return len(fake.pingArgsForCall)
+}
+
+func (fake *FakeMongoClient) PingCalls(stub func(context.Context) error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = stub
+}
+
+func (fake *FakeMongoClient) PingArgsForCall(i int) context.Context {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	argsForCall := fake.pingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMongoClient) PingReturns(result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	fake.pingReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) PingReturnsOnCall(i int, result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	if fake.pingReturnsOnCall == nil {
+		fake.pingReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pingReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMongoClient) UpdateOne(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 interface{}) (*mongo.UpdateResult, error) {
 	fake.updateOneMutex.Lock()
 	ret, specificReturn := fake.updateOneReturnsOnCall[len(fake.updateOneArgsForCall)]
 	fake.updateOneArgsForCall = append(fake.updateOneArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 interface{}
+		arg3 string
 		arg4 interface{}
-	}{arg1, arg2, arg3, arg4})
-	fake.recordInvocation("UpdateOne", []interface{}{arg1, arg2, arg3, arg4})
+		arg5 interface{}
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("UpdateOne", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.updateOneMutex.Unlock()
 	if fake.UpdateOneStub != nil {
-		return fake.UpdateOneStub(arg1, arg2, arg3, arg4)
+		return fake.UpdateOneStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -511,17 +921,17 @@ func (fake *FakeMongoClient) UpdateOneCallCount() int {
 	return len(fake.updateOneArgsForCall)
 }
 
-func (fake *FakeMongoClient) UpdateOneCalls(stub func(string, string, interface{}, interface{}) (*mongo.UpdateResult, error)) {
+func (fake *FakeMongoClient) UpdateOneCalls(stub func(context.Context, string, string, interface{}, interface{}) (*mongo.UpdateResult, error)) {
 	fake.updateOneMutex.Lock()
 	defer fake.updateOneMutex.Unlock()
 	fake.UpdateOneStub = stub
 }
 
-func (fake *FakeMongoClient) UpdateOneArgsForCall(i int) (string, string, interface{}, interface{}) {
+func (fake *FakeMongoClient) UpdateOneArgsForCall(i int) (context.Context, string, string, interface{}, interface{}) {
 	fake.updateOneMutex.RLock()
 	defer fake.updateOneMutex.RUnlock()
 	argsForCall := fake.updateOneArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeMongoClient) UpdateOneReturns(result1 *mongo.UpdateResult, result2 error) {
@@ -550,23 +960,102 @@ func (fake *FakeMongoClient) UpdateOneReturnsOnCall(i int, result1 *mongo.Update
 	}{result1, result2}
 }
 
+func (fake *FakeMongoClient) UpsertOne(arg1 context.Context, arg2 string, arg3 string, arg4 interface{}, arg5 interface{}) (*mongo.UpdateResult, error) {
+	fake.upsertOneMutex.Lock()
+	ret, specificReturn := fake.upsertOneReturnsOnCall[len(fake.upsertOneArgsForCall)]
+	fake.upsertOneArgsForCall = append(fake.upsertOneArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 interface{}
+		arg5 interface{}
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("UpsertOne", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.upsertOneMutex.Unlock()
+	if fake.UpsertOneStub != nil {
+		return fake.UpsertOneStub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.upsertOneReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMongoClient) UpsertOneCallCount() int {
+	fake.upsertOneMutex.RLock()
+	defer fake.upsertOneMutex.RUnlock()
+	return len(fake.upsertOneArgsForCall)
+}
+
+func (fake *FakeMongoClient) UpsertOneCalls(stub func(context.Context, string, string, interface{}, interface{}) (*mongo.UpdateResult, error)) {
+	fake.upsertOneMutex.Lock()
+	defer fake.upsertOneMutex.Unlock()
+	fake.UpsertOneStub = stub
+}
+
+func (fake *FakeMongoClient) UpsertOneArgsForCall(i int) (context.Context, string, string, interface{}, interface{}) {
+	fake.upsertOneMutex.RLock()
+	defer fake.upsertOneMutex.RUnlock()
+	argsForCall := fake.upsertOneArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeMongoClient) UpsertOneReturns(result1 *mongo.UpdateResult, result2 error) {
+	fake.upsertOneMutex.Lock()
+	defer fake.upsertOneMutex.Unlock()
+	fake.UpsertOneStub = nil
+	fake.upsertOneReturns = struct {
+		result1 *mongo.UpdateResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMongoClient) UpsertOneReturnsOnCall(i int, result1 *mongo.UpdateResult, result2 error) {
+	fake.upsertOneMutex.Lock()
+	defer fake.upsertOneMutex.Unlock()
+	fake.UpsertOneStub = nil
+	if fake.upsertOneReturnsOnCall == nil {
+		fake.upsertOneReturnsOnCall = make(map[int]struct {
+			result1 *mongo.UpdateResult
+			result2 error
+		})
+	}
+	fake.upsertOneReturnsOnCall[i] = struct {
+		result1 *mongo.UpdateResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMongoClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.createIndexMutex.RLock()
+	defer fake.createIndexMutex.RUnlock()
+	fake.deleteOneMutex.RLock()
+	defer fake.deleteOneMutex.RUnlock()
+	fake.disconnectMutex.RLock()
+	defer fake.disconnectMutex.RUnlock()
 	fake.findAllObjectsMutex.RLock()
 	defer fake.findAllObjectsMutex.RUnlock()
 	fake.findObjectMutex.RLock()
 	defer fake.findObjectMutex.RUnlock()
 	fake.findObjectsMutex.RLock()
 	defer fake.findObjectsMutex.RUnlock()
+	fake.findObjectsWithOptionsMutex.RLock()
+	defer fake.findObjectsWithOptionsMutex.RUnlock()
 	fake.getCollectionMutex.RLock()
 	defer fake.getCollectionMutex.RUnlock()
 	fake.getDatabaseMutex.RLock()
 	defer fake.getDatabaseMutex.RUnlock()
 	fake.insertDataMutex.RLock()
 	defer fake.insertDataMutex.RUnlock()
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
 	fake.updateOneMutex.RLock()
 	defer fake.updateOneMutex.RUnlock()
+	fake.upsertOneMutex.RLock()
+	defer fake.upsertOneMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
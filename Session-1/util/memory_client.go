@@ -0,0 +1,934 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/21keshav/IBackendApplication/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryMongoClient
+//
+// In-memory implementation of the MongoClient interface, used to run the
+// server and the functional suite without a MongoDB instance.
+//
+// Documents are stored as BSON, so they are matched and decoded through their
+// `bson` tags exactly as the real driver would. Filters support equality on
+// (dotted) field paths plus $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin,
+// $exists, $and and $or. Updates support $set, $unset, $inc and $push, and
+// $setOnInsert for upserts. FindObjectsWithOptions sorts in MongoDB's order
+// (missing fields first, then numbers, strings and dates) and projects
+// top-level or dotted fields.
+// _id values are unique, as if backed by MongoDB's default _id index, and so
+// are the values of unique indexes made with CreateIndex; other indexes are
+// only recorded, so documents never expire.
+// Each call holds a lock for its full duration, so single-document updates
+// are atomic just like in MongoDB. Calls fail with the context's error once
+// the context passed to them is done.
+type MemoryMongoClient struct {
+	mu          sync.RWMutex
+	collections map[string][]bson.M // Keyed by "db.collection"
+	indexes     map[string][]Index  // Keyed like collections
+}
+
+// NewMemoryMongoClient creates an empty in-memory MongoClient.
+func NewMemoryMongoClient() MongoClient {
+	return &MemoryMongoClient{
+		collections: make(map[string][]bson.M),
+		indexes:     make(map[string][]Index),
+	}
+}
+
+// GetCollection: there is no driver collection behind the in-memory client, so nil is returned.
+func (mc *MemoryMongoClient) GetCollection(dbName, collectionName string) *mongo.Collection {
+	return nil
+}
+
+// GetDatabase: there is no driver database behind the in-memory client, so nil is returned.
+func (mc *MemoryMongoClient) GetDatabase(dbName string) *mongo.Database {
+	return nil
+}
+
+// InsertData: stores a copy of the document, assigning an _id if it has none.
+func (mc *MemoryMongoClient) InsertData(ctx context.Context, dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-insert-data-started")
+	defer log.Debug("memory-insert-data-completed")
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := toDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if err := mc.insert(collectionKey(dbName, collectionName), doc); err != nil {
+		return nil, err
+	}
+	return &mongo.InsertOneResult{InsertedID: doc["_id"]}, nil
+}
+
+// UpdateOne: applies update operators to the first document matching filter.
+func (mc *MemoryMongoClient) UpdateOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-update-data-started")
+	defer log.Debug("memory-update-data-completed")
+
+	return mc.update(ctx, dbName, collectionName, filter, update, false)
+}
+
+// UpsertOne: like UpdateOne, but when nothing matches it inserts the filter's
+// equality conditions with the update applied. Inserting an _id that already
+// exists fails with a duplicate key error, as in MongoDB.
+func (mc *MemoryMongoClient) UpsertOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-upsert-data-started")
+	defer log.Debug("memory-upsert-data-completed")
+
+	return mc.update(ctx, dbName, collectionName, filter, update, true)
+}
+
+func (mc *MemoryMongoClient) update(ctx context.Context, dbName, collectionName string, filter, update interface{}, upsert bool) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	key := collectionKey(dbName, collectionName)
+	docs := mc.collections[key]
+	for i, doc := range docs {
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		updated, err := applyUpdate(doc, ops, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := mc.checkUnique(key, updated, i); err != nil {
+			return nil, err
+		}
+		docs[i] = updated
+		return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+	}
+	if !upsert {
+		return &mongo.UpdateResult{}, nil
+	}
+
+	doc := bson.M{}
+	for field, cond := range query {
+		if _, isOps := operatorDocument(cond); !isOps && !strings.HasPrefix(field, "$") {
+			doc[field] = cond
+		}
+	}
+	if doc, err = applyUpdate(doc, ops, true); err != nil {
+		return nil, err
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+	if err := mc.insert(key, doc); err != nil {
+		return nil, err
+	}
+	return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: doc["_id"]}, nil
+}
+
+// DeleteOne: removes the first document matching filter.
+func (mc *MemoryMongoClient) DeleteOne(ctx context.Context, dbName, collectionName string, filter interface{}) (*mongo.DeleteResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-delete-data-started")
+	defer log.Debug("memory-delete-data-completed")
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	key := collectionKey(dbName, collectionName)
+	docs := mc.collections[key]
+	for i, doc := range docs {
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			mc.collections[key] = append(docs[:i:i], docs[i+1:]...)
+			return &mongo.DeleteResult{DeletedCount: 1}, nil
+		}
+	}
+	return &mongo.DeleteResult{}, nil
+}
+
+// FindObject: decodes the first document matching filter into result.
+func (mc *MemoryMongoClient) FindObject(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-find-object-started")
+	defer log.Debug("memory-find-object-completed")
+
+	docs, err := mc.find(ctx, dbName, collectionName, filter, 1)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return decodeDocument(docs[0], result)
+}
+
+// FindObjects: decodes all documents matching filter into the result slice.
+func (mc *MemoryMongoClient) FindObjects(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-find-objects-started")
+	defer log.Debug("memory-find-objects-completed")
+
+	docs, err := mc.find(ctx, dbName, collectionName, filter, 0)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// FindAllObjects: decodes up to limit documents into the result slice.
+func (mc *MemoryMongoClient) FindAllObjects(ctx context.Context, dbName, collectionName string, result interface{}, limit int64) error {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-find-all-objects-started")
+	defer log.Debug("memory-find-all-objects-completed")
+
+	docs, err := mc.find(ctx, dbName, collectionName, bson.D{}, limit)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// FindObjectsWithOptions: decodes the documents matching filter into the
+// result slice, sorted, skipped, limited and projected as opts says.
+func (mc *MemoryMongoClient) FindObjectsWithOptions(ctx context.Context, dbName, collectionName string, filter interface{}, opts FindOptions, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-find-objects-with-options-started")
+	defer log.Debug("memory-find-objects-with-options-completed")
+
+	docs, err := mc.find(ctx, dbName, collectionName, filter, 0)
+	if err != nil {
+		return err
+	}
+	sortDocuments(docs, opts.Sort)
+	if opts.Skip > 0 {
+		if opts.Skip >= int64(len(docs)) {
+			docs = nil
+		} else {
+			docs = docs[opts.Skip:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < int64(len(docs)) {
+		docs = docs[:opts.Limit]
+	}
+	for i, doc := range docs {
+		if docs[i], err = projectDocument(doc, opts.Projection); err != nil {
+			return err
+		}
+	}
+	return decodeDocuments(docs, result)
+}
+
+// CreateIndex: records index and, for a unique index, enforces it from now
+// on. It fails if documents already violate it, or if another index has the
+// same name.
+func (mc *MemoryMongoClient) CreateIndex(ctx context.Context, dbName, collectionName string, index Index) error {
+	log := logging.FromContext(ctx)
+	log.Debug("memory-create-index-started")
+	defer log.Debug("memory-create-index-completed")
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	key := collectionKey(dbName, collectionName)
+	for _, existing := range mc.indexes[key] {
+		if existing.Name != index.Name {
+			continue
+		}
+		if reflect.DeepEqual(existing, index) {
+			return nil
+		}
+		return fmt.Errorf("index %s already exists with different keys or options", index.Name)
+	}
+	if index.Unique {
+		docs := mc.collections[key]
+		for i, doc := range docs {
+			for _, other := range docs[:i] {
+				if sameKeys(doc, other, index) {
+					return duplicateKey(key, index.Name, doc, index)
+				}
+			}
+		}
+	}
+	mc.indexes[key] = append(mc.indexes[key], index)
+	return nil
+}
+
+// Ping: the in-memory store is always reachable while ctx is live.
+func (mc *MemoryMongoClient) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Disconnect: there is nothing to close; the data stays available.
+func (mc *MemoryMongoClient) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+// find returns copies of the documents matching filter, in insertion order.
+// A limit of zero or less means no limit.
+func (mc *MemoryMongoClient) find(ctx context.Context, dbName, collectionName string, filter interface{}, limit int64) ([]bson.M, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	var found []bson.M
+	for _, doc := range mc.collections[collectionKey(dbName, collectionName)] {
+		if limit > 0 && int64(len(found)) >= limit {
+			break
+		}
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, copyDocument(doc))
+		}
+	}
+	return found, nil
+}
+
+// insert appends doc to a collection, enforcing unique _ids. Callers hold mc.mu.
+func (mc *MemoryMongoClient) insert(key string, doc bson.M) error {
+	for _, existing := range mc.collections[key] {
+		if reflect.DeepEqual(existing["_id"], doc["_id"]) {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+				Code:    11000,
+				Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: _id_ dup key: %v", key, doc["_id"]),
+			}}}
+		}
+	}
+	if err := mc.checkUnique(key, doc, -1); err != nil {
+		return err
+	}
+	mc.collections[key] = append(mc.collections[key], doc)
+	return nil
+}
+
+// checkUnique fails if doc would repeat the values of a unique index held by
+// another document than the one at position self. Callers hold mc.mu.
+func (mc *MemoryMongoClient) checkUnique(key string, doc bson.M, self int) error {
+	for _, index := range mc.indexes[key] {
+		if !index.Unique {
+			continue
+		}
+		for i, other := range mc.collections[key] {
+			if i != self && sameKeys(doc, other, index) {
+				return duplicateKey(key, index.Name, doc, index)
+			}
+		}
+	}
+	return nil
+}
+
+// sameKeys reports whether a and b hold the same values for the fields of
+// index; a missing field counts as null, as in MongoDB.
+func sameKeys(a, b bson.M, index Index) bool {
+	for _, field := range index.Keys {
+		av, _ := lookup(a, field.Key)
+		bv, _ := lookup(b, field.Key)
+		if !reflect.DeepEqual(av, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// duplicateKey is the error MongoDB returns for a unique index violation.
+func duplicateKey(key, indexName string, doc bson.M, index Index) error {
+	values := make([]interface{}, len(index.Keys))
+	for i, field := range index.Keys {
+		values[i], _ = lookup(doc, field.Key)
+	}
+	return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+		Code:    11000,
+		Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s dup key: %v", key, indexName, values),
+	}}}
+}
+
+func collectionKey(dbName, collectionName string) string {
+	return dbName + "." + collectionName
+}
+
+//
+// Document conversion helpers
+//
+
+// toDocument round-trips v through BSON so that struct values are keyed by
+// their bson tags and all values use the same types as stored documents.
+func toDocument(v interface{}) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return normalize(doc).(bson.M), nil
+}
+
+// normalize converts nested documents to bson.M and arrays to primitive.A.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case bson.M:
+		for k, val := range t {
+			t[k] = normalize(val)
+		}
+		return t
+	case map[string]interface{}:
+		return normalize(bson.M(t))
+	case primitive.D:
+		m := bson.M{}
+		for _, e := range t {
+			m[e.Key] = normalize(e.Value)
+		}
+		return m
+	case primitive.A:
+		for i, val := range t {
+			t[i] = normalize(val)
+		}
+		return t
+	case []interface{}:
+		return normalize(primitive.A(t))
+	}
+	return v
+}
+
+// copyDocument deep-copies a stored document so callers cannot mutate storage.
+func copyDocument(doc bson.M) bson.M {
+	cp, _ := toDocument(doc)
+	return cp
+}
+
+func decodeDocument(doc bson.M, result interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+// decodeDocuments decodes docs into result, which must be a pointer to a slice.
+func decodeDocuments(docs []bson.M, result interface{}) error {
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("result must be a pointer to a slice, got %T", result)
+	}
+	slice := rv.Elem()
+	out := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		elem := reflect.New(slice.Type().Elem())
+		if err := decodeDocument(doc, elem.Interface()); err != nil {
+			return err
+		}
+		out = reflect.Append(out, elem.Elem())
+	}
+	slice.Set(out)
+	return nil
+}
+
+//
+// Field path helpers
+//
+
+// lookup resolves a dotted path such as "bids.b1.amount" inside doc.
+func lookup(doc bson.M, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// parent returns the document holding the last element of path, creating
+// intermediate documents when create is set.
+func parent(doc bson.M, path string, create bool) (bson.M, string, error) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok {
+			if !create {
+				return nil, "", nil
+			}
+			next = bson.M{}
+			current[part] = next
+		}
+		m, ok := next.(bson.M)
+		if !ok {
+			return nil, "", fmt.Errorf("cannot traverse non-document field %q in %q", part, path)
+		}
+		current = m
+	}
+	return current, parts[len(parts)-1], nil
+}
+
+//
+// Query matching
+//
+
+// matches reports whether doc satisfies query.
+func matches(doc, query bson.M) (bool, error) {
+	for key, cond := range query {
+		var (
+			ok  bool
+			err error
+		)
+		switch key {
+		case "$and", "$or":
+			ok, err = matchLogical(doc, key, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unsupported query operator %s", key)
+			}
+			ok, err = matchField(doc, key, cond)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	clauses, ok := cond.(primitive.A)
+	if !ok {
+		return false, fmt.Errorf("%s requires an array", op)
+	}
+	for _, clause := range clauses {
+		sub, ok := clause.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("%s clauses must be documents", op)
+		}
+		matched, err := matches(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		if op == "$or" && matched {
+			return true, nil
+		}
+		if op == "$and" && !matched {
+			return false, nil
+		}
+	}
+	return op == "$and", nil
+}
+
+func matchField(doc bson.M, path string, cond interface{}) (bool, error) {
+	value, exists := lookup(doc, path)
+	ops, isOps := operatorDocument(cond)
+	if !isOps {
+		return equalOrContains(value, exists, cond), nil
+	}
+	for op, operand := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = equalOrContains(value, exists, operand)
+		case "$ne":
+			ok = !equalOrContains(value, exists, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = exists && compareOp(op, value, operand)
+		case "$in", "$nin":
+			list, isList := operand.(primitive.A)
+			if !isList {
+				return false, fmt.Errorf("%s requires an array", op)
+			}
+			for _, candidate := range list {
+				if equalOrContains(value, exists, candidate) {
+					ok = true
+					break
+				}
+			}
+			if op == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			want, _ := operand.(bool)
+			ok = exists == want
+		default:
+			return false, fmt.Errorf("unsupported query operator %s", op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// operatorDocument reports whether cond is a document of $-operators.
+func operatorDocument(cond interface{}) (bson.M, bool) {
+	m, ok := cond.(bson.M)
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// equalOrContains follows Mongo equality: a missing field equals null, and an
+// array field matches if any element equals the wanted value.
+func equalOrContains(value interface{}, exists bool, want interface{}) bool {
+	if !exists {
+		return want == nil
+	}
+	if reflect.DeepEqual(value, want) {
+		return true
+	}
+	if arr, ok := value.(primitive.A); ok {
+		for _, elem := range arr {
+			if reflect.DeepEqual(elem, want) {
+				return true
+			}
+		}
+	}
+	return comparable(value, want) && compareValues(value, want) == 0
+}
+
+func compareOp(op string, value, operand interface{}) bool {
+	if !comparable(value, operand) {
+		return false
+	}
+	c := compareValues(value, operand)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+// comparable reports whether a and b are both numbers, strings or dates.
+func comparable(a, b interface{}) bool {
+	_, aNum := toFloat(a)
+	_, bNum := toFloat(b)
+	if aNum && bNum {
+		return true
+	}
+	switch a.(type) {
+	case string:
+		_, ok := b.(string)
+		return ok
+	case primitive.DateTime:
+		_, ok := b.(primitive.DateTime)
+		return ok
+	}
+	return false
+}
+
+// compareValues orders two comparable values, returning -1, 0 or 1.
+func compareValues(a, b interface{}) int {
+	if af, ok := toFloat(a); ok {
+		bf, _ := toFloat(b)
+		return compareFloats(af, bf)
+	}
+	switch at := a.(type) {
+	case string:
+		return strings.Compare(at, b.(string))
+	case primitive.DateTime:
+		return compareFloats(float64(at), float64(b.(primitive.DateTime)))
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+//
+// Sorting and projection
+//
+
+// sortDocuments orders docs by the sort keys, keeping insertion order between equal documents.
+func sortDocuments(docs []bson.M, keys bson.D) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			a, aok := lookup(docs[i], key.Key)
+			b, bok := lookup(docs[j], key.Key)
+			c := orderValues(a, aok, b, bok)
+			if c == 0 {
+				continue
+			}
+			if n, _ := toFloat(key.Value); n < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// orderValues orders two field values as MongoDB sorts them, returning -1, 0 or 1.
+func orderValues(a interface{}, aok bool, b interface{}, bok bool) int {
+	ra, rb := typeRank(a, aok), typeRank(b, bok)
+	if ra != rb {
+		return compareFloats(float64(ra), float64(rb))
+	}
+	if comparable(a, b) {
+		return compareValues(a, b)
+	}
+	if ab, ok := a.(bool); ok && ab != b.(bool) {
+		if ab {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+// typeRank is the position of a value's type in MongoDB's sort order.
+func typeRank(v interface{}, exists bool) int {
+	if !exists || v == nil {
+		return 0
+	}
+	if _, ok := toFloat(v); ok {
+		return 1
+	}
+	switch v.(type) {
+	case string:
+		return 2
+	case bson.M:
+		return 3
+	case primitive.A:
+		return 4
+	case primitive.ObjectID:
+		return 5
+	case bool:
+		return 6
+	case primitive.DateTime:
+		return 7
+	}
+	return 8
+}
+
+// projectDocument returns doc with only the fields projection includes, or
+// without the fields it excludes. _id is kept unless excluded explicitly.
+func projectDocument(doc, projection bson.M) (bson.M, error) {
+	if len(projection) == 0 {
+		return doc, nil
+	}
+	included, excluded := false, false
+	for path, flag := range projection {
+		if path != "_id" {
+			included = included || projectionFlag(flag)
+			excluded = excluded || !projectionFlag(flag)
+		}
+	}
+	if included && excluded {
+		return nil, errors.New("cannot mix inclusion and exclusion in a projection")
+	}
+	if !included {
+		for path, flag := range projection {
+			if projectionFlag(flag) {
+				continue
+			}
+			holder, last, err := parent(doc, path, false)
+			if err != nil {
+				return nil, err
+			}
+			if holder != nil {
+				delete(holder, last)
+			}
+		}
+		return doc, nil
+	}
+
+	projected := bson.M{}
+	if id, ok := doc["_id"]; ok {
+		if flag, set := projection["_id"]; !set || projectionFlag(flag) {
+			projected["_id"] = id
+		}
+	}
+	for path, flag := range projection {
+		if path == "_id" || !projectionFlag(flag) {
+			continue
+		}
+		value, ok := lookup(doc, path)
+		if !ok {
+			continue
+		}
+		holder, last, err := parent(projected, path, true)
+		if err != nil {
+			return nil, err
+		}
+		holder[last] = value
+	}
+	return projected, nil
+}
+
+// projectionFlag reports whether a projection value includes its field.
+func projectionFlag(flag interface{}) bool {
+	if on, ok := flag.(bool); ok {
+		return on
+	}
+	n, _ := toFloat(flag)
+	return n != 0
+}
+
+//
+// Update operators
+//
+
+// applyUpdate returns a copy of doc with the update operators applied.
+// $setOnInsert only takes effect when inserting is set, i.e. for an upsert
+// that matched nothing.
+func applyUpdate(doc, update bson.M, inserting bool) (bson.M, error) {
+	if _, ok := operatorDocument(update); !ok {
+		return nil, errors.New("update document must contain key beginning with '$'")
+	}
+	updated := copyDocument(doc)
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return nil, fmt.Errorf("%s requires a document", op)
+		}
+		if op == "$setOnInsert" {
+			if !inserting {
+				continue
+			}
+			op = "$set"
+		}
+		for path, value := range fields {
+			if err := applyOperator(updated, op, path, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return updated, nil
+}
+
+func applyOperator(doc bson.M, op, path string, value interface{}) error {
+	if path == "_id" {
+		return errors.New("the _id field cannot be modified")
+	}
+	holder, field, err := parent(doc, path, op != "$unset")
+	if err != nil || holder == nil {
+		return err
+	}
+	switch op {
+	case "$set":
+		holder[field] = value
+	case "$unset":
+		delete(holder, field)
+	case "$inc":
+		current, _ := toFloat(holder[field])
+		delta, ok := toFloat(value)
+		if !ok {
+			return fmt.Errorf("$inc requires a numeric value for %q", path)
+		}
+		holder[field] = addNumbers(holder[field], value, current+delta)
+	case "$push":
+		arr, _ := holder[field].(primitive.A)
+		holder[field] = append(arr, value)
+	default:
+		return fmt.Errorf("unsupported update operator %s", op)
+	}
+	return nil
+}
+
+// addNumbers keeps the integer width of the operands where possible.
+func addNumbers(current, delta interface{}, sum float64) interface{} {
+	_, curFloat := current.(float64)
+	_, deltaFloat := delta.(float64)
+	if curFloat || deltaFloat {
+		return sum
+	}
+	_, cur32 := current.(int32)
+	_, delta32 := delta.(int32)
+	if (current == nil || cur32) && delta32 {
+		return int32(sum)
+	}
+	return int64(sum)
+}
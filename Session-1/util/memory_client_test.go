@@ -0,0 +1,287 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	. "github.com/21keshav/IBackendApplication/util"
+)
+
+type item struct {
+	ID    string            `bson:"id,omitempty"`
+	Owner string            `bson:"owner,omitempty"`
+	Count int               `bson:"count,omitempty"`
+	Tags  []string          `bson:"tags,omitempty"`
+	Extra map[string]string `bson:"extra,omitempty"`
+}
+
+var _ = Describe("MemoryMongoClient", func() {
+	var client MongoClient
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		client = NewMemoryMongoClient()
+		for _, it := range []item{
+			{ID: "a", Owner: "alice", Count: 1, Tags: []string{"red"}},
+			{ID: "b", Owner: "bob", Count: 2, Tags: []string{"blue"}},
+			{ID: "c", Owner: "alice", Count: 3, Tags: []string{"red", "blue"}},
+		} {
+			res, err := client.InsertData(ctx, "db", "items", it)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.InsertedID).ToNot(BeNil())
+		}
+	})
+
+	Describe("FindObject", func() {
+		It("matches struct filters by their bson tags", func() {
+			var found item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "b"}, &found)).To(Succeed())
+			Expect(found.Owner).To(Equal("bob"))
+		})
+
+		It("returns ErrNoDocuments when nothing matches", func() {
+			var found item
+			err := client.FindObject(ctx, "db", "items", item{ID: "zzz"}, &found)
+			Expect(err).To(Equal(mongo.ErrNoDocuments))
+		})
+
+		It("keeps collections in different databases apart", func() {
+			var found item
+			err := client.FindObject(ctx, "other", "items", item{ID: "a"}, &found)
+			Expect(err).To(Equal(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("FindObjects", func() {
+		It("returns every match in insertion order", func() {
+			var found []item
+			Expect(client.FindObjects(ctx, "db", "items", bson.M{"owner": "alice"}, &found)).To(Succeed())
+			Expect(found).To(HaveLen(2))
+			Expect(found[0].ID).To(Equal("a"))
+			Expect(found[1].ID).To(Equal("c"))
+		})
+
+		It("supports comparison and set operators", func() {
+			var found []item
+			filter := bson.M{"count": bson.M{"$gte": 2}, "id": bson.M{"$nin": []string{"c"}}}
+			Expect(client.FindObjects(ctx, "db", "items", filter, &found)).To(Succeed())
+			Expect(found).To(HaveLen(1))
+			Expect(found[0].ID).To(Equal("b"))
+		})
+
+		It("matches array fields by element", func() {
+			var found []item
+			Expect(client.FindObjects(ctx, "db", "items", bson.M{"tags": "blue"}, &found)).To(Succeed())
+			Expect(found).To(HaveLen(2))
+		})
+
+		It("supports $or and $exists", func() {
+			var found []item
+			filter := bson.M{"$or": []bson.M{{"id": "a"}, {"extra": bson.M{"$exists": true}}}}
+			Expect(client.FindObjects(ctx, "db", "items", filter, &found)).To(Succeed())
+			Expect(found).To(HaveLen(1))
+		})
+	})
+
+	Describe("FindAllObjects", func() {
+		It("applies the limit", func() {
+			var found []item
+			Expect(client.FindAllObjects(ctx, "db", "items", &found, 2)).To(Succeed())
+			Expect(found).To(HaveLen(2))
+		})
+	})
+
+	Describe("FindObjectsWithOptions", func() {
+		It("sorts, skips and limits", func() {
+			var found []item
+			opts := FindOptions{Sort: bson.D{{Key: "owner", Value: 1}, {Key: "count", Value: -1}}, Skip: 1, Limit: 2}
+			Expect(client.FindObjectsWithOptions(ctx, "db", "items", bson.M{}, opts, &found)).To(Succeed())
+			Expect(found).To(HaveLen(2))
+			Expect(found[0].ID).To(Equal("a"))
+			Expect(found[1].ID).To(Equal("b"))
+		})
+
+		It("sorts documents missing the field first", func() {
+			_, err := client.InsertData(ctx, "db", "items", item{ID: "d"})
+			Expect(err).ToNot(HaveOccurred())
+
+			var found []item
+			opts := FindOptions{Sort: bson.D{{Key: "count", Value: 1}}}
+			Expect(client.FindObjectsWithOptions(ctx, "db", "items", nil, opts, &found)).To(Succeed())
+			Expect(found[0].ID).To(Equal("d"))
+		})
+
+		It("includes or excludes projected fields", func() {
+			var found []item
+			opts := FindOptions{Projection: bson.M{"id": 1, "count": 1}}
+			Expect(client.FindObjectsWithOptions(ctx, "db", "items", item{ID: "c"}, opts, &found)).To(Succeed())
+			Expect(found).To(Equal([]item{{ID: "c", Count: 3}}))
+
+			opts = FindOptions{Projection: bson.M{"tags": 0}}
+			Expect(client.FindObjectsWithOptions(ctx, "db", "items", item{ID: "c"}, opts, &found)).To(Succeed())
+			Expect(found).To(Equal([]item{{ID: "c", Owner: "alice", Count: 3}}))
+
+			opts = FindOptions{Projection: bson.M{"id": 1, "tags": 0}}
+			Expect(client.FindObjectsWithOptions(ctx, "db", "items", nil, opts, &found)).ToNot(Succeed())
+		})
+	})
+
+	Describe("CreateIndex", func() {
+		owner := Index{Name: "owner_1_count_1", Keys: bson.D{{Key: "owner", Value: 1}, {Key: "count", Value: 1}}, Unique: true}
+
+		It("rejects inserts and updates repeating a unique key", func() {
+			Expect(client.CreateIndex(ctx, "db", "items", owner)).To(Succeed())
+			Expect(client.CreateIndex(ctx, "db", "items", owner)).To(Succeed())
+
+			_, err := client.InsertData(ctx, "db", "items", item{ID: "d", Owner: "bob", Count: 2})
+			Expect(IsDuplicateKey(err)).To(BeTrue())
+			_, err = client.UpdateOne(ctx, "db", "items", item{ID: "c"}, bson.M{"$set": bson.M{"count": 1}})
+			Expect(IsDuplicateKey(err)).To(BeTrue())
+			_, err = client.InsertData(ctx, "db", "items", item{ID: "d", Owner: "bob", Count: 3})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fails to build over duplicates or to reuse a name", func() {
+			_, err := client.InsertData(ctx, "db", "items", item{ID: "d", Owner: "bob", Count: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateIndex(ctx, "db", "items", owner)).ToNot(Succeed())
+
+			Expect(client.CreateIndex(ctx, "db", "items", Index{Name: "id_1", Keys: bson.D{{Key: "id", Value: 1}}})).To(Succeed())
+			Expect(client.CreateIndex(ctx, "db", "items", Index{Name: "id_1", Keys: bson.D{{Key: "owner", Value: 1}}})).ToNot(Succeed())
+		})
+	})
+
+	Describe("UpdateOne", func() {
+		It("applies $set on nested paths and $inc", func() {
+			update := bson.M{
+				"$set": bson.M{"extra.colour": "green"},
+				"$inc": bson.M{"count": 5},
+			}
+			res, err := client.UpdateOne(ctx, "db", "items", item{ID: "a"}, update)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.MatchedCount).To(BeEquivalentTo(1))
+
+			var found item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "a"}, &found)).To(Succeed())
+			Expect(found.Extra).To(HaveKeyWithValue("colour", "green"))
+			Expect(found.Count).To(Equal(6))
+		})
+
+		It("reports zero matches when the filter misses", func() {
+			res, err := client.UpdateOne(ctx, "db", "items", item{ID: "zzz"}, bson.M{"$set": bson.M{"owner": "x"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.MatchedCount).To(BeEquivalentTo(0))
+		})
+
+		It("rejects whole-document replacements like MongoDB does", func() {
+			_, err := client.UpdateOne(ctx, "db", "items", item{ID: "a"}, item{Owner: "x"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("does not lose concurrent field-level updates", func() {
+			const n = 100
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					key := fmt.Sprintf("extra.k%d", i)
+					_, err := client.UpdateOne(ctx, "db", "items", item{ID: "b"}, bson.M{"$set": bson.M{key: "v"}})
+					Expect(err).ToNot(HaveOccurred())
+				}(i)
+			}
+			wg.Wait()
+
+			var found item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "b"}, &found)).To(Succeed())
+			Expect(found.Extra).To(HaveLen(n))
+		})
+	})
+
+	Describe("UpsertOne", func() {
+		It("updates the matching document", func() {
+			res, err := client.UpsertOne(ctx, "db", "items", item{ID: "a"}, bson.M{"$set": bson.M{"count": 9}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.MatchedCount).To(BeEquivalentTo(1))
+			Expect(res.UpsertedID).To(BeNil())
+
+			var found item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "a"}, &found)).To(Succeed())
+			Expect(found.Count).To(Equal(9))
+		})
+
+		It("inserts the filter's equality fields with the update applied", func() {
+			res, err := client.UpsertOne(ctx, "db", "items",
+				bson.M{"id": "d", "count": bson.M{"$gt": 5}}, bson.M{"$set": bson.M{"owner": "dave"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.UpsertedCount).To(BeEquivalentTo(1))
+			Expect(res.UpsertedID).ToNot(BeNil())
+
+			var found item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "d"}, &found)).To(Succeed())
+			Expect(found).To(Equal(item{ID: "d", Owner: "dave"}))
+		})
+
+		It("applies $setOnInsert only when inserting", func() {
+			update := bson.M{"$setOnInsert": bson.M{"owner": "new"}, "$inc": bson.M{"count": 1}}
+
+			_, err := client.UpsertOne(ctx, "db", "items", item{ID: "a"}, update)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.UpsertOne(ctx, "db", "items", item{ID: "e"}, update)
+			Expect(err).ToNot(HaveOccurred())
+
+			var a, e item
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "a"}, &a)).To(Succeed())
+			Expect(client.FindObject(ctx, "db", "items", item{ID: "e"}, &e)).To(Succeed())
+			Expect(a).To(Equal(item{ID: "a", Owner: "alice", Count: 2, Tags: []string{"red"}}))
+			Expect(e).To(Equal(item{ID: "e", Owner: "new", Count: 1}))
+		})
+
+		It("fails with a duplicate key error when the _id is taken", func() {
+			_, err := client.InsertData(ctx, "db", "locks", bson.M{"_id": "l1", "owner": "a"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.UpsertOne(ctx, "db", "locks",
+				bson.M{"_id": "l1", "owner": "b"}, bson.M{"$set": bson.M{"owner": "b"}})
+			Expect(IsDuplicateKey(err)).To(BeTrue())
+		})
+	})
+
+	It("rejects a second document with the same _id", func() {
+		_, err := client.InsertData(ctx, "db", "items", bson.M{"_id": "x"})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.InsertData(ctx, "db", "items", bson.M{"_id": "x"})
+		Expect(IsDuplicateKey(err)).To(BeTrue())
+		Expect(IsDuplicateKey(mongo.ErrNoDocuments)).To(BeFalse())
+	})
+
+	It("returns copies so callers cannot modify stored documents", func() {
+		var found item
+		Expect(client.FindObject(ctx, "db", "items", item{ID: "c"}, &found)).To(Succeed())
+		found.Tags[0] = "changed"
+
+		var again item
+		Expect(client.FindObject(ctx, "db", "items", item{ID: "c"}, &again)).To(Succeed())
+		Expect(again.Tags[0]).To(Equal("red"))
+	})
+
+	It("fails once the context is done", func() {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		var found item
+		Expect(client.FindObject(cancelled, "db", "items", item{ID: "a"}, &found)).To(Equal(context.Canceled))
+		_, err := client.UpdateOne(cancelled, "db", "items", item{ID: "a"}, bson.M{"$set": bson.M{"owner": "x"}})
+		Expect(err).To(Equal(context.Canceled))
+
+		Expect(client.FindObject(ctx, "db", "items", item{ID: "a"}, &found)).To(Succeed())
+		Expect(found.Owner).To(Equal("alice"))
+	})
+})
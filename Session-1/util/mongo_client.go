@@ -3,7 +3,7 @@ package util
 import (
 	"context"
 
-	"github.com/golang/glog"
+	"github.com/21keshav/IBackendApplication/logging"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -13,6 +13,8 @@ import (
 // MongoClient Interface
 //
 // This defines all database operations that the application needs.
+// Every operation runs under the caller's context, so it is abandoned when
+// the request that needs it is cancelled or times out.
 // By depending on the interface (instead of concrete implementation),
 // we can easily mock the database layer for testing.
 //
@@ -21,42 +23,89 @@ import (
 type MongoClient interface {
 	GetCollection(dbName, collectionName string) *mongo.Collection
 	GetDatabase(dbName string) *mongo.Database
-	InsertData(dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error)
-	UpdateOne(dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error)
-	FindObject(dbName, collectionName string, filter, result interface{}) error
-	FindObjects(dbName, collectionName string, filter, result interface{}) error
-	FindAllObjects(dbName, collectionName string, result interface{}, limit int64) error
+	InsertData(ctx context.Context, dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error)
+	UpdateOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error)
+	UpsertOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, dbName, collectionName string, filter interface{}) (*mongo.DeleteResult, error)
+	FindObject(ctx context.Context, dbName, collectionName string, filter, result interface{}) error
+	FindObjects(ctx context.Context, dbName, collectionName string, filter, result interface{}) error
+	FindAllObjects(ctx context.Context, dbName, collectionName string, result interface{}, limit int64) error
+	FindObjectsWithOptions(ctx context.Context, dbName, collectionName string, filter interface{}, opts FindOptions, result interface{}) error
+	CreateIndex(ctx context.Context, dbName, collectionName string, index Index) error
+	Ping(ctx context.Context) error
+	Disconnect(ctx context.Context) error
+}
+
+//
+// FindOptions narrows, orders and pages the documents returned by
+// FindObjectsWithOptions. The zero value returns every matching document,
+// whole and in natural order.
+//
+type FindOptions struct {
+	Projection bson.M // Fields to return ({"field": 1}) or leave out ({"field": 0})
+	Sort       bson.D // Sort keys in order, 1 ascending and -1 descending
+	Skip       int64  // Matching documents to skip before returning any
+	Limit      int64  // Most documents to return (0 = no limit)
+}
+
+//
+// Index describes an index created by CreateIndex. Creating an index that
+// already exists with the same keys and options does nothing.
+//
+type Index struct {
+	Name   string // Index name, unique within its collection
+	Keys   bson.D // Indexed fields in order, 1 ascending and -1 descending
+	Unique bool   // Reject documents repeating the indexed values of another
+	Expire bool   // Remove documents once the time in the indexed field has passed (TTL index on one field)
 }
 
 //
 // MongoClientImpl
 //
 // Concrete implementation of MongoClient interface.
-// Holds a mongo.Client and a context that is reused across DB operations.
+// Holds the mongo.Client shared by all DB operations.
 //
 type MongoClientImpl struct {
 	MongoClient *mongo.Client
-	ctx         context.Context
 }
 
 //
 // Factory method for creating a new MongoClient.
-// Accepts context and connection URL, creates a new client, and returns wrapper.
+// Accepts a context bounding the connection attempt and client options
+// (URI, credentials, pool size, timeouts), creates a new client, and returns wrapper.
+// Fails if the server cannot be reached, see ConnectMongoClient for retries.
 //
-func NewMongoClient(ctx context.Context, url string) MongoClient {
-	client, _ := CreateClient(ctx, url)
+func NewMongoClient(ctx context.Context, clientOptions *options.ClientOptions) (MongoClient, error) {
+	client, err := CreateClient(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
 	return &MongoClientImpl{
 		MongoClient: client,
-		ctx:         ctx,
-	}
+	}, nil
+}
+
+//
+// ConnectMongoClient is NewMongoClient retried with exponential backoff until
+// it succeeds, the retry budget of policy is spent or ctx is done.
+//
+func ConnectMongoClient(ctx context.Context, clientOptions *options.ClientOptions, policy RetryPolicy) (MongoClient, error) {
+	var client MongoClient
+	err := Retry(ctx, policy, func(ctx context.Context) error {
+		var err error
+		client, err = NewMongoClient(ctx, clientOptions)
+		return err
+	})
+	return client, err
 }
 
 //
 // GetCollection: returns a MongoDB collection reference.
 //
 func (mg *MongoClientImpl) GetCollection(dbName, collectionName string) *mongo.Collection {
-	glog.Info("get-collection-started")
-	defer glog.Info("get-collection-completed")
+	log := logging.Default()
+	log.Debug("get-collection-started")
+	defer log.Debug("get-collection-completed")
 
 	return mg.GetDatabase(dbName).Collection(collectionName)
 }
@@ -65,8 +114,9 @@ func (mg *MongoClientImpl) GetCollection(dbName, collectionName string) *mongo.C
 // GetDatabase: returns a MongoDB database reference.
 //
 func (mg *MongoClientImpl) GetDatabase(dbName string) *mongo.Database {
-	glog.Info("get-database-started")
-	defer glog.Info("get-database-completed")
+	log := logging.Default()
+	log.Debug("get-database-started")
+	defer log.Debug("get-database-completed")
 
 	return mg.MongoClient.Database(dbName)
 }
@@ -74,81 +124,186 @@ func (mg *MongoClientImpl) GetDatabase(dbName string) *mongo.Database {
 //
 // InsertData: inserts a single document into a collection.
 //
-func (mg *MongoClientImpl) InsertData(dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error) {
-	glog.Info("insert-data-started")
-	defer glog.Info("insert-data-completed")
+func (mg *MongoClientImpl) InsertData(ctx context.Context, dbName, collectionName string, data interface{}) (*mongo.InsertOneResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("insert-data-started")
+	defer log.Debug("insert-data-completed")
 
 	collection := mg.GetCollection(dbName, collectionName)
-	return collection.InsertOne(mg.ctx, data)
+	return collection.InsertOne(ctx, data)
 }
 
 //
 // UpdateOne: updates a single document matching filter.
 //
-func (mg *MongoClientImpl) UpdateOne(dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
-	glog.Info("update-data-started")
-	defer glog.Info("update-data-completed")
+func (mg *MongoClientImpl) UpdateOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("update-data-started")
+	defer log.Debug("update-data-completed")
 
 	collection := mg.GetCollection(dbName, collectionName)
-	return collection.UpdateOne(mg.ctx, filter, update)
+	return collection.UpdateOne(ctx, filter, update)
+}
+
+//
+// UpsertOne: updates a single document matching filter, inserting one built
+// from the filter's equality conditions and the update if none matches.
+//
+func (mg *MongoClientImpl) UpsertOne(ctx context.Context, dbName, collectionName string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("upsert-data-started")
+	defer log.Debug("upsert-data-completed")
+
+	collection := mg.GetCollection(dbName, collectionName)
+	return collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+}
+
+//
+// DeleteOne: deletes a single document matching filter.
+//
+func (mg *MongoClientImpl) DeleteOne(ctx context.Context, dbName, collectionName string, filter interface{}) (*mongo.DeleteResult, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("delete-data-started")
+	defer log.Debug("delete-data-completed")
+
+	collection := mg.GetCollection(dbName, collectionName)
+	return collection.DeleteOne(ctx, filter)
 }
 
 //
 // FindObject: finds a single document matching filter and decodes into result.
 //
-func (mg *MongoClientImpl) FindObject(dbName, collectionName string, filter, result interface{}) error {
-	glog.Info("find-object-started")
-	defer glog.Info("find-object-completed")
+func (mg *MongoClientImpl) FindObject(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("find-object-started")
+	defer log.Debug("find-object-completed")
 
 	collection := mg.GetCollection(dbName, collectionName)
-	return collection.FindOne(mg.ctx, filter).Decode(result)
+	return collection.FindOne(ctx, filter).Decode(result)
 }
 
 //
 // FindObjects: finds all documents matching filter and decodes into result slice.
 //
 
-func (mg *MongoClientImpl) FindObjects(dbName, collectionName string, filter, result interface{}) error {
-	glog.Info("find-objects-started")
-	defer glog.Info("find-objects-completed")
+func (mg *MongoClientImpl) FindObjects(ctx context.Context, dbName, collectionName string, filter, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("find-objects-started")
+	defer log.Debug("find-objects-completed")
 
 	collection := mg.GetCollection(dbName, collectionName)
-	cursor, err := collection.Find(mg.ctx, filter)
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return err
 	}
-	defer cursor.Close(mg.ctx)
+	defer cursor.Close(ctx)
 
-	return cursor.All(mg.ctx, result) // safer than Decode
+	return cursor.All(ctx, result) // safer than Decode
 }
 
 //
 // FindAllObjects: fetches all documents (with optional limit) and decodes into result slice.
 //
-func (mg *MongoClientImpl) FindAllObjects(dbName, collectionName string, result interface{}, limit int64) error {
-	glog.Info("find-all-objects-started")
-	defer glog.Info("find-all-objects-completed")
+func (mg *MongoClientImpl) FindAllObjects(ctx context.Context, dbName, collectionName string, result interface{}, limit int64) error {
+	log := logging.FromContext(ctx)
+	log.Debug("find-all-objects-started")
+	defer log.Debug("find-all-objects-completed")
 
 	findOptions := options.Find().SetLimit(limit)
 	collection := mg.GetCollection(dbName, collectionName)
 
-	cursor, err := collection.Find(mg.ctx, bson.D{}, findOptions)
+	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
 	if err != nil {
 		return err
 	}
-	defer cursor.Close(mg.ctx)
+	defer cursor.Close(ctx)
 
-	return cursor.All(mg.ctx, result) // safer than Decode
+	return cursor.All(ctx, result) // safer than Decode
+}
+
+//
+// FindObjectsWithOptions: finds the documents matching filter, projected,
+// sorted and paged by opts, and decodes them into result slice.
+//
+func (mg *MongoClientImpl) FindObjectsWithOptions(ctx context.Context, dbName, collectionName string, filter interface{}, opts FindOptions, result interface{}) error {
+	log := logging.FromContext(ctx)
+	log.Debug("find-objects-with-options-started")
+	defer log.Debug("find-objects-with-options-completed")
+
+	findOptions := options.Find()
+	if opts.Projection != nil {
+		findOptions.SetProjection(opts.Projection)
+	}
+	if opts.Sort != nil {
+		findOptions.SetSort(opts.Sort)
+	}
+	if opts.Skip > 0 {
+		findOptions.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		findOptions.SetLimit(opts.Limit)
+	}
+	collection := mg.GetCollection(dbName, collectionName)
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, result)
+}
+
+//
+// CreateIndex: builds index on a collection. A unique index fails to build
+// while documents violate it.
+//
+func (mg *MongoClientImpl) CreateIndex(ctx context.Context, dbName, collectionName string, index Index) error {
+	log := logging.FromContext(ctx)
+	log.Debug("create-index-started")
+	defer log.Debug("create-index-completed")
+
+	model := mongo.IndexModel{
+		Keys:    index.Keys,
+		Options: options.Index().SetName(index.Name).SetUnique(index.Unique),
+	}
+	if index.Expire {
+		model.Options.SetExpireAfterSeconds(0)
+	}
+	_, err := mg.GetCollection(dbName, collectionName).Indexes().CreateOne(ctx, model)
+	return err
+}
+
+//
+// Ping: checks that the server is reachable, for readiness checks.
+//
+func (mg *MongoClientImpl) Ping(ctx context.Context) error {
+	return mg.MongoClient.Ping(ctx, nil)
+}
+
+//
+// Disconnect: closes all connections once in-flight operations finish,
+// or when ctx is done.
+//
+func (mg *MongoClientImpl) Disconnect(ctx context.Context) error {
+	log := logging.FromContext(ctx)
+	log.Debug("disconnect-started")
+	defer log.Debug("disconnect-completed")
+
+	if mg.MongoClient == nil {
+		return nil
+	}
+	return mg.MongoClient.Disconnect(ctx)
 }
 
 //
 // CreateClient: connects to MongoDB and verifies connection with Ping.
 //
-func CreateClient(ctx context.Context, url string) (*mongo.Client, error) {
-	glog.Info("creating-mongo-client-started")
-	defer glog.Info("creating-mongo-client-completed")
+func CreateClient(ctx context.Context, clientOptions *options.ClientOptions) (*mongo.Client, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("creating-mongo-client-started")
+	defer log.Debug("creating-mongo-client-completed")
 
-	clientOptions := options.Client().ApplyURI(url)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
@@ -156,9 +311,31 @@ func CreateClient(ctx context.Context, url string) (*mongo.Client, error) {
 
 	// Verify connection with a ping
 	if err := client.Ping(ctx, nil); err != nil {
-		glog.Error("mongo-connection-ping-failed", err)
+		log.Error("mongo-connection-ping-failed", logging.Err(err))
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 
 	return client, nil
 }
+
+// duplicateKeyCodes are the server error codes for unique index violations.
+var duplicateKeyCodes = map[int]bool{11000: true, 11001: true, 12582: true}
+
+//
+// IsDuplicateKey reports whether err is a unique index violation, e.g. from
+// inserting a second document with the same _id.
+//
+func IsDuplicateKey(err error) bool {
+	switch e := err.(type) {
+	case mongo.WriteException:
+		for _, we := range e.WriteErrors {
+			if duplicateKeyCodes[we.Code] {
+				return true
+			}
+		}
+	case mongo.CommandError:
+		return duplicateKeyCodes[int(e.Code)]
+	}
+	return false
+}
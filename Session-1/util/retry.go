@@ -0,0 +1,75 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	"github.com/21keshav/IBackendApplication/logging"
+)
+
+//
+// Retry with Exponential Backoff
+//
+// Used at startup to wait for dependencies such as MongoDB. Attempts are
+// spaced InitialBackoff, 2*InitialBackoff, 4*InitialBackoff ... apart, never
+// more than MaxBackoff, and stop once the next wait would go past Budget.
+//
+
+// Defaults used for zero RetryPolicy fields.
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+)
+
+// RetryPolicy controls how long and how often Retry tries.
+type RetryPolicy struct {
+	Budget         time.Duration // Total time to keep retrying; zero tries once
+	InitialBackoff time.Duration // Wait after the first failure
+	MaxBackoff     time.Duration // Upper bound for a single wait
+	AttemptTimeout time.Duration // Deadline for a single attempt; zero means none
+}
+
+// Retry calls op until it succeeds, the policy's budget is spent or ctx is
+// done, and returns the last error from op.
+func Retry(ctx context.Context, policy RetryPolicy, op func(ctx context.Context) error) error {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultInitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultMaxBackoff
+	}
+
+	deadline := time.Now().Add(policy.Budget)
+	backoff := policy.InitialBackoff
+	for n := 1; ; n++ {
+		err := attempt(ctx, policy.AttemptTimeout, op)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		logging.FromContext(ctx).Warn("attempt-failed", "attempt", n, "retry_in", backoff.String(), logging.Err(err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// attempt runs op once, bounded by timeout when it is set.
+func attempt(ctx context.Context, timeout time.Duration, op func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return op(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return op(ctx)
+}
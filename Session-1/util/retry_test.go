@@ -0,0 +1,84 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/21keshav/IBackendApplication/util"
+)
+
+var _ = Describe("Retry", func() {
+	var (
+		ctx      context.Context
+		attempts int
+		errDown  = errors.New("connection refused")
+		policy   RetryPolicy
+	)
+
+	failUntil := func(n int) func(context.Context) error {
+		return func(context.Context) error {
+			attempts++
+			if attempts < n {
+				return errDown
+			}
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		attempts = 0
+		policy = RetryPolicy{
+			Budget:         time.Second,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     4 * time.Millisecond,
+		}
+	})
+
+	It("retries until the operation succeeds", func() {
+		Expect(Retry(ctx, policy, failUntil(4))).To(Succeed())
+		Expect(attempts).To(Equal(4))
+	})
+
+	It("returns the last error once the budget is spent", func() {
+		policy.Budget = 20 * time.Millisecond
+		start := time.Now()
+		Expect(Retry(ctx, policy, failUntil(1000))).To(Equal(errDown))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(attempts).To(BeNumerically(">", 1))
+	})
+
+	It("tries once without a budget", func() {
+		policy.Budget = 0
+		Expect(Retry(ctx, policy, failUntil(2))).To(Equal(errDown))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("stops waiting when the context is done", func() {
+		policy.InitialBackoff = time.Hour
+		policy.MaxBackoff = time.Hour
+		policy.Budget = 2 * time.Hour
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		Expect(Retry(ctx, policy, failUntil(2))).To(Equal(errDown))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("bounds every attempt by the attempt timeout", func() {
+		policy.AttemptTimeout = 5 * time.Millisecond
+		err := Retry(ctx, policy, func(ctx context.Context) error {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attempts).To(Equal(2))
+	})
+})
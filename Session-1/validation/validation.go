@@ -0,0 +1,218 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//
+// Declarative Validation
+//
+// Struct fields declare their rules in a `validate` tag, for example
+//
+//	Amount int `json:"ammount" validate:"min=1"`
+//
+// Rules are applied in order and stop at the first failure for a field:
+//
+//	required    value must not be the zero value
+//	omitempty   skip the remaining rules when the value is empty
+//	min=N       ints must be >= N; strings, slices and maps need length >= N
+//	max=N       ints must be <= N; strings, slices and maps need length <= N
+//	oneof=a b   value must be one of the space-separated options
+//	key         string must be usable as a document key: no "." and no leading "$"
+//
+// Nested structs, and maps or slices of structs, are validated recursively.
+// Field paths use the json names, e.g. "bids.b1.ammount".
+//
+
+// Machine-readable codes reported in FieldError.Code.
+const (
+	CodeRequired   = "required"
+	CodeTooSmall   = "too_small"
+	CodeTooLarge   = "too_large"
+	CodeNotAllowed = "not_allowed"
+	CodeInvalidKey = "invalid_key"
+	CodeNotFound   = "not_found"
+)
+
+// FieldError describes one invalid field.
+type FieldError struct {
+	Field   string `json:"field"`   // Path to the field, using json names
+	Code    string `json:"code"`    // Machine-readable reason, one of the Code constants
+	Message string `json:"message"` // Human-readable explanation
+}
+
+// Errors is a list of field errors; it implements error.
+type Errors []FieldError
+
+// Error joins the messages of all field errors.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// NewError returns Errors holding a single field error.
+func NewError(field, code, message string) Errors {
+	return Errors{{Field: field, Code: code, Message: message}}
+}
+
+// Struct checks every rule declared on v, which must be a struct or pointer to one.
+// It returns nil or an Errors value.
+func Struct(v interface{}) error {
+	return check(v, false)
+}
+
+// Partial is like Struct but ignores "required", for partial updates where
+// omitted fields keep their current values.
+func Partial(v interface{}) error {
+	return check(v, true)
+}
+
+func check(v interface{}, partial bool) error {
+	var errs Errors
+	walk(reflect.ValueOf(v), "", partial, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// walk validates the tagged fields of a struct and descends into nested values.
+func walk(v reflect.Value, path string, partial bool, errs *Errors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // unexported
+			}
+			fieldPath := join(path, fieldName(f))
+			if f.Anonymous {
+				fieldPath = path
+			}
+			if tag, ok := f.Tag.Lookup("validate"); ok {
+				if fe, failed := apply(v.Field(i), fieldPath, tag, partial); failed {
+					*errs = append(*errs, fe)
+					continue
+				}
+			}
+			walk(v.Field(i), fieldPath, partial, errs)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			walk(v.MapIndex(key), join(path, fmt.Sprint(key.Interface())), partial, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), join(path, strconv.Itoa(i)), partial, errs)
+		}
+	}
+}
+
+// apply runs the rules in tag against v, returning the first failure.
+func apply(v reflect.Value, path, tag string, partial bool) (FieldError, bool) {
+	fail := func(code, format string, args ...interface{}) (FieldError, bool) {
+		return FieldError{Field: path, Code: code, Message: fmt.Sprintf(format, args...)}, true
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+
+		switch name {
+		case "required":
+			if !partial && v.IsZero() {
+				return fail(CodeRequired, "is required")
+			}
+		case "omitempty":
+			if v.IsZero() {
+				return FieldError{}, false
+			}
+		case "min", "max":
+			limit, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				panic("validation: bad " + name + " argument in tag " + tag)
+			}
+			n, isLen := size(v)
+			if name == "min" && n < limit {
+				if isLen {
+					return fail(CodeTooSmall, "must have at least %d characters or items", limit)
+				}
+				return fail(CodeTooSmall, "must be at least %d", limit)
+			}
+			if name == "max" && n > limit {
+				if isLen {
+					return fail(CodeTooLarge, "must have at most %d characters or items", limit)
+				}
+				return fail(CodeTooLarge, "must be at most %d", limit)
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if !contains(options, fmt.Sprint(v.Interface())) {
+				return fail(CodeNotAllowed, "must be one of: %s", strings.Join(options, ", "))
+			}
+		case "key":
+			s := v.String()
+			if strings.Contains(s, ".") || strings.HasPrefix(s, "$") {
+				return fail(CodeInvalidKey, "must not contain \".\" or start with \"$\"")
+			}
+		default:
+			panic("validation: unknown rule " + name)
+		}
+	}
+	return FieldError{}, false
+}
+
+// size returns the numeric value of ints, or the length of strings and collections.
+func size(v reflect.Value) (n int64, isLen bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), false
+	case reflect.String:
+		return int64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return int64(v.Len()), true
+	}
+	panic("validation: min/max not supported for " + v.Kind().String())
+}
+
+// fieldName returns the json name of a struct field.
+func fieldName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(options []string, s string) bool {
+	for _, o := range options {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}